@@ -0,0 +1,221 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package problem
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"math/rand"
+	"mime"
+	"net"
+	"net/http"
+	"time"
+)
+
+type (
+	// Auditor is a function used to receive a Sample of a problem response for offline analysis (e.g. by storing it).
+	//
+	// An Auditor is called synchronously once the HTTP response has been written and so should return quickly (e.g. by
+	// queueing the Sample to be stored asynchronously).
+	Auditor func(ctx context.Context, sample Sample)
+
+	// RecordingOptions contains options that can be used by RecordingMiddleware to control which problem responses are
+	// sampled and what is recorded.
+	//
+	// All fields are optional with default behaviour clearly documented.
+	RecordingOptions struct {
+		// MaxBodySize is the maximum number of bytes of the body of a problem response to be recorded within a Sample.
+		//
+		// If zero or less, DefaultRecordingMaxBodySize will be used.
+		MaxBodySize int
+		// Rate is the fraction of problem responses to be sampled, between zero and one.
+		//
+		// If zero or less, DefaultRecordingRate will be used. If one or more, every problem response is sampled.
+		Rate float64
+	}
+
+	// Sample is a recording of a problem response, including metadata about the HTTP request that produced it.
+	Sample struct {
+		// Body contains the body of the problem response, truncated to RecordingOptions.MaxBodySize.
+		Body []byte
+		// BodySize is the total number of bytes written to the body of the problem response, which may be greater than
+		// the length of Body if it was truncated.
+		BodySize int
+		// BodyTruncated is whether Body was truncated.
+		BodyTruncated bool
+		// ContentType is the content/media type of the problem response.
+		ContentType string
+		// Duration is how long it took to handle the HTTP request.
+		Duration time.Duration
+		// Method is the method of the HTTP request.
+		Method string
+		// RemoteAddr is the network address that sent the HTTP request.
+		RemoteAddr string
+		// RequestHeader contains the headers of the HTTP request, excluding any sensitive headers (e.g. Authorization
+		// and Cookie).
+		RequestHeader http.Header
+		// Status is the status code of the problem response.
+		Status int
+		// Time is the time at which the HTTP request was received.
+		Time time.Time
+		// URL is the URL of the HTTP request.
+		URL string
+	}
+
+	// recordingResponseWriter is an http.ResponseWriter that records the body of a problem response.
+	recordingResponseWriter struct {
+		http.ResponseWriter
+		// body contains the recorded body, up to maxBodySize.
+		body bytes.Buffer
+		// bodySize is the total number of bytes written to the body.
+		bodySize int
+		// isProblem is whether the response has a problem content/media type.
+		isProblem bool
+		// maxBodySize is the maximum number of bytes to be recorded.
+		maxBodySize int
+		// status is the status code written, if any.
+		status int
+		// truncated is whether more than maxBodySize bytes have been written.
+		truncated bool
+	}
+)
+
+const (
+	// DefaultRecordingMaxBodySize is the default maximum number of bytes of the body of a problem response to be recorded
+	// within a Sample by RecordingMiddleware.
+	DefaultRecordingMaxBodySize = 64 << 10
+	// DefaultRecordingRate is the default fraction of problem responses to be sampled by RecordingMiddleware.
+	DefaultRecordingRate = 0.01
+)
+
+// sensitiveRequestHeaders contains the canonical names of headers that are never recorded within a Sample.
+var sensitiveRequestHeaders = []string{"Authorization", "Cookie", "Proxy-Authorization"}
+
+// RecordingMiddleware returns a middleware function that samples a fraction of problem responses (i.e. those with a
+// content/media type of ContentTypeJSON or ContentTypeXML), passing a Sample of each, containing its body along with
+// metadata about the HTTP request, to the given Auditor. This allows real production problem responses to be analyzed
+// offline.
+//
+// Whether an HTTP request is to be sampled is decided before it is handled, and an HTTP request that is not sampled
+// incurs no additional overhead. Regardless, a problem response is recorded no matter how it is written.
+//
+// For example;
+//
+//	auditor := func(ctx context.Context, sample Sample) {
+//		samples <- sample
+//	}
+//	handler = RecordingMiddleware(auditor, RecordingOptions{Rate: 0.05})(handler)
+func RecordingMiddleware(auditor Auditor, opts ...RecordingOptions) func(http.Handler) http.Handler {
+	var _opts RecordingOptions
+	if len(opts) > 0 {
+		_opts = opts[0]
+	}
+	if _opts.MaxBodySize <= 0 {
+		_opts.MaxBodySize = DefaultRecordingMaxBodySize
+	}
+	if _opts.Rate <= 0 {
+		_opts.Rate = DefaultRecordingRate
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if _opts.Rate < 1 && rand.Float64() >= _opts.Rate {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			start := time.Now()
+			rw := &recordingResponseWriter{ResponseWriter: w, maxBodySize: _opts.MaxBodySize}
+			next.ServeHTTP(rw, req)
+			if !rw.isProblem {
+				return
+			}
+
+			header := req.Header.Clone()
+			for _, name := range sensitiveRequestHeaders {
+				header.Del(name)
+			}
+			auditor(req.Context(), Sample{
+				Body:          rw.body.Bytes(),
+				BodySize:      rw.bodySize,
+				BodyTruncated: rw.truncated,
+				ContentType:   rw.Header().Get(contentTypeHeader),
+				Duration:      time.Since(start),
+				Method:        req.Method,
+				RemoteAddr:    req.RemoteAddr,
+				RequestHeader: header,
+				Status:        rw.status,
+				Time:          start,
+				URL:           req.URL.String(),
+			})
+		})
+	}
+}
+
+// Flush flushes any buffered data to the client using the underlying http.ResponseWriter, where supported, allowing
+// streamed responses (e.g. server-sent events) to pass through unaffected.
+func (rw *recordingResponseWriter) Flush() {
+	if rw.status == 0 {
+		rw.WriteHeader(http.StatusOK)
+	}
+	_ = http.NewResponseController(rw.ResponseWriter).Flush()
+}
+
+// Hijack lets the caller take over the connection using the underlying http.ResponseWriter, where supported, allowing
+// protocol upgrades (e.g. websockets) to pass through unaffected. Otherwise, an error wrapping http.ErrNotSupported is
+// returned.
+func (rw *recordingResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(rw.ResponseWriter).Hijack()
+}
+
+// Unwrap returns the underlying http.ResponseWriter, allowing http.ResponseController to access its features.
+func (rw *recordingResponseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// Write writes the given data to the underlying http.ResponseWriter, recording it if the response is a problem.
+func (rw *recordingResponseWriter) Write(data []byte) (int, error) {
+	if rw.status == 0 {
+		rw.WriteHeader(http.StatusOK)
+	}
+	if rw.isProblem {
+		if remaining := rw.maxBodySize - rw.body.Len(); remaining < len(data) {
+			rw.body.Write(data[:max(remaining, 0)])
+			rw.truncated = true
+		} else {
+			rw.body.Write(data)
+		}
+	}
+	n, err := rw.ResponseWriter.Write(data)
+	rw.bodySize += n
+	return n, err
+}
+
+// WriteHeader writes the given status code to the underlying http.ResponseWriter, determining whether the response is a
+// problem based on its content/media type, ignoring any informational (1xx) status code.
+func (rw *recordingResponseWriter) WriteHeader(status int) {
+	if rw.status == 0 && status >= http.StatusOK {
+		rw.status = status
+		mediaType, _, _ := mime.ParseMediaType(rw.Header().Get(contentTypeHeader))
+		rw.isProblem = mediaType == ContentTypeJSON || mediaType == ContentTypeXML
+	}
+	rw.ResponseWriter.WriteHeader(status)
+}
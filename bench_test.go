@@ -0,0 +1,115 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package problem
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+)
+
+// discardLogger is a Logger that discards every log.
+func discardLogger(context.Context, LogLevel, string, ...any) {}
+
+// discardResponseWriter is an http.ResponseWriter that discards everything written to it.
+type discardResponseWriter struct {
+	header http.Header
+}
+
+func (w *discardResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *discardResponseWriter) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (w *discardResponseWriter) WriteHeader(int) {}
+
+var benchDefinition = Definition{
+	Code:   "GATEWAY-429",
+	Detail: "Rate limit exceeded",
+	Type:   Type{LogLevel: LogLevelWarn, Status: http.StatusTooManyRequests, Title: "Too Many Requests"},
+}
+
+func BenchmarkGenerator_New(b *testing.B) {
+	g := &Generator{}
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		enc := json.NewEncoder(io.Discard)
+		for pb.Next() {
+			prob := g.New(FromDefinition(benchDefinition))
+			_ = enc.Encode(prob)
+		}
+	})
+}
+
+func BenchmarkGenerator_New_PoolProblems(b *testing.B) {
+	g := &Generator{PoolProblems: true}
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		enc := json.NewEncoder(io.Discard)
+		for pb.Next() {
+			prob := g.New(FromDefinition(benchDefinition))
+			_ = enc.Encode(prob)
+			prob.Release()
+		}
+	})
+}
+
+func BenchmarkGenerator_WriteProblem(b *testing.B) {
+	benchmarkWriteProblem(b, &Generator{Logger: discardLogger}, false)
+}
+
+func BenchmarkGenerator_WriteProblem_PoolProblems(b *testing.B) {
+	benchmarkWriteProblem(b, &Generator{Logger: discardLogger, PoolProblems: true}, true)
+}
+
+// benchmarkWriteProblem benchmarks building a Problem using the given Generator and writing it as an HTTP response,
+// releasing it afterwards if required, while also reporting the number of garbage collections and the total GC pause
+// time per operation.
+func benchmarkWriteProblem(b *testing.B, g *Generator, release bool) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		w := &discardResponseWriter{header: make(http.Header)}
+		for pb.Next() {
+			prob := g.New(FromDefinition(benchDefinition))
+			_ = g.WriteProblem(prob, w, req)
+			if release {
+				prob.Release()
+			}
+			clear(w.header)
+		}
+	})
+	b.StopTimer()
+	runtime.ReadMemStats(&after)
+	b.ReportMetric(float64(after.NumGC-before.NumGC)/float64(b.N), "gcs/op")
+	b.ReportMetric(float64(after.PauseTotalNs-before.PauseTotalNs)/float64(b.N), "gc-pause-ns/op")
+}
@@ -25,9 +25,11 @@ import (
 	"errors"
 	"fmt"
 	"github.com/neocotic/go-optional"
-	"github.com/neocotic/go-problem/internal/stack"
 	"maps"
 	"net/http"
+	"reflect"
+	"time"
+	"unicode/utf8"
 )
 
 // Flag provides control over the generation of specific data and its visibility on their respective fields on a
@@ -60,6 +62,23 @@ const (
 	FlagLog
 )
 
+// MergeStrategy provides control over how extensions from multiple sources (i.e. explicitly defined, unwrapped from a
+// Problem, and derived from a Definition) are combined when building a Problem.
+type MergeStrategy uint8
+
+const (
+	// MergeStrategyReplace uses only the extensions from the source with the highest precedence that has extensions,
+	// ignoring the extensions of all other sources.
+	MergeStrategyReplace MergeStrategy = iota
+	// MergeStrategyShallow combines the top-level extensions from all sources, where an extension from a source with a
+	// higher precedence replaces one with the same key from a source with a lower precedence.
+	MergeStrategyShallow
+	// MergeStrategyDeep combines the extensions from all sources, recursively combining any values that are maps with
+	// string keys, where any other value from a source with a higher precedence replaces one with the same key from a
+	// source with a lower precedence.
+	MergeStrategyDeep
+)
+
 // Builder is used to construct a Problem using methods to define fields and/or override fields derived from a
 // Definition and/or Type.
 type Builder struct {
@@ -71,6 +90,9 @@ type Builder struct {
 	code Code
 	// ctx is the context to be used when building a Problem.
 	ctx optional.Optional[context.Context]
+	// deadlineStart is the time from which the elapsed time and timeout budget are computed when wrapping
+	// context.DeadlineExceeded, if present. See Builder.DeadlineExtensions for more information.
+	deadlineStart optional.Optional[time.Time]
 	// def is the Definition whose fields are to be treated as defaults when a field is not explicitly defined. See
 	// Builder.Definition and Builder.DefinitionType for more information.
 	def Definition
@@ -91,6 +113,9 @@ type Builder struct {
 	logLevel LogLevel
 	// problem contains any fields unwrapped from err using an Unwrapper. See Builder.Wrap for more information.
 	problem Problem
+	// routed is whether the Problem being built has already been evaluated by Generator.Router, which is the case when
+	// it is built by MapDefinitionAction.
+	routed bool
 	// stack is the captured stack trace to be used. See Builder.Stack for more information.
 	//
 	// stack is captured lazily and priority is given to any existing stack contained within problem. getStack must be
@@ -103,11 +128,23 @@ type Builder struct {
 	stackFramesSkipped int
 	// status is the explicitly defined status to be used. See Builder.Status for more information.
 	status int
+	// subProblems contains any problems found within a joined error that were not selected as the primary Problem. See
+	// Builder.Wrap for more information.
+	subProblems []*Problem
+	// timestamp is the generated timestamp to be used. See Builder.Timestamp for more information.
+	//
+	// timestamp is generated lazily and priority is given to any existing timestamp contained within problem.
+	// getTimestamp must be used to access the timestamp.
+	timestamp time.Time
+	// timestampFlag contains the timestamp flags to be used. See Builder.Timestamp for more information.
+	timestampFlag optional.Optional[Flag]
 	// title is the explicitly defined title to be used. See Builder.Title for more information.
 	title string
 	// titleKey is the explicitly defined translation key to be used to resolve a localized title. See Builder.TitleKey
 	// for more information.
 	titleKey any
+	// traceContextFlag contains the trace context flags to be used. See Builder.TraceContext for more information.
+	traceContextFlag optional.Optional[Flag]
 	// typeURI is the explicitly defined type URI reference to be used. See Builder.Type for more information.
 	typeURI string
 	// uuid is the generated "UUID" to be used. See Builder.UUID for more information.
@@ -147,6 +184,59 @@ func (b *Builder) Code(code Code) *Builder {
 	return b
 }
 
+// Context sets the given context.Context to be used when building a Problem (e.g. for resolving localized values or
+// generating a "UUID"), allowing an existing Builder (e.g. one created from a Definition at initialization) to be
+// bound to the context.Context of a request instead of only when the Builder is created (e.g. via
+// Generator.BuildContext).
+//
+// If ctx contains a Generator (see UsingGenerator), it also replaces Builder.Generator. Otherwise, Builder.Generator is
+// unchanged. If ctx is nil, context.Background is used when building a Problem.
+//
+// For example;
+//
+//	var notFound = http.NotFoundDefinition.Build()
+//
+//	func handler(w http.ResponseWriter, req *http.Request) {
+//		prob := notFound.Clone().Context(req.Context()).Problem()
+//		// ...
+//	}
+func (b *Builder) Context(ctx context.Context) *Builder {
+	if ctx == nil {
+		b.ctx = optional.Empty[context.Context]()
+		return b
+	}
+	b.ctx = optional.Of(ctx)
+	if gen, ok := ctx.Value(contextKeyGenerator).(*Generator); ok && gen != nil {
+		b.Generator = gen
+	}
+	return b
+}
+
+// DeadlineExtensions enables the inclusion of extensions describing the deadline of the context.Context used when
+// building a Problem, but only if the wrapped error's tree contains context.DeadlineExceeded (see Builder.Wrap). This
+// makes timeout problems actionable without extra plumbing.
+//
+// The following extensions are included, where possible:
+//
+//   - ExtensionDeadline contains the deadline of the context.Context
+//   - ExtensionElapsed contains the duration between start and the time at which the Problem was built
+//   - ExtensionTimeoutBudget contains the duration between start and the deadline of the context.Context
+//
+// If start is zero, only ExtensionDeadline is included. No extensions are included if the context.Context has no
+// deadline (e.g. if not built using Generator.BuildContext or Generator.NewContext). Any explicitly defined extension
+// will take precedence over these extensions.
+//
+// For example;
+//
+//	start := time.Now()
+//	if err := s.repo.Save(ctx, user); err != nil {
+//		return BuildContext(ctx).Wrap(err).DeadlineExtensions(start).Problem()
+//	}
+func (b *Builder) DeadlineExtensions(start time.Time) *Builder {
+	b.deadlineStart = optional.Of(start)
+	return b
+}
+
 // Definition sets the given Definition to be used when building a Problem.
 //
 // The fields of def are treated as defaults when a field is not explicitly defined. This method can conflict with
@@ -244,6 +334,32 @@ func (b *Builder) Extensions(extensions Extensions) *Builder {
 	return b
 }
 
+// FieldErrors appends the given field errors to the ExtensionFieldErrors extension used when building a Problem. See
+// FieldError for more information.
+//
+// Field errors accumulate across multiple calls and are contained within the extension in the order in which they were
+// provided. Since it is an explicitly defined extension, it will take precedence over any ExtensionFieldErrors
+// extension provided using Builder.Definition or Builder.Wrap.
+//
+// For example;
+//
+//	prob := Build().
+//		Status(http.StatusUnprocessableEntity).
+//		FieldErrors(
+//			FieldError{Code: "required", Detail: "must not be empty", Field: "name", Pointer: "/name"},
+//			FieldError{Code: "format", Detail: "must be a valid email", Field: "email", Pointer: "/email"},
+//		).
+//		Problem()
+func (b *Builder) FieldErrors(errs ...FieldError) *Builder {
+	if len(errs) == 0 {
+		return b
+	}
+	existing, _ := b.extensions[ExtensionFieldErrors].([]FieldError)
+	merged := make([]FieldError, 0, len(existing)+len(errs))
+	merged = append(merged, existing...)
+	return b.Extension(ExtensionFieldErrors, append(merged, errs...))
+}
+
 // Instance sets the instance URI reference to be used when building a Problem. See Problem.Instance for more
 // information.
 //
@@ -274,15 +390,143 @@ func (b *Builder) LogLevel(level LogLevel) *Builder {
 	return b
 }
 
+// Merge applies all non-zero information from the given Builder to the Builder, enabling layered construction where,
+// for example, a base Builder per module is combined with a Builder per request. Any information within other takes
+// precedence over that within the Builder, however, any extensions are combined with those within other replacing any
+// with the same key. Any error wrapped using Builder.Wrap within other is applied along with any information
+// unwrapped from it.
+//
+// If other is nil, nothing is applied.
+//
+// For example;
+//
+//	base := Build().Code(MustBuildCode(500, "USER")).Extension("service", "users")
+//	prob := base.Clone().Merge(Build().Status(http.StatusNotFound).Extension("userId", id)).Problem()
+func (b *Builder) Merge(other *Builder) *Builder {
+	if other == nil {
+		return b
+	}
+	if other.Generator != nil {
+		b.Generator = other.Generator
+	}
+	if other.code != "" {
+		b.code = other.code
+	}
+	if other.ctx.IsPresent() {
+		b.ctx = other.ctx
+	}
+	if other.deadlineStart.IsPresent() {
+		b.deadlineStart = other.deadlineStart
+	}
+	if !reflect.ValueOf(other.def).IsZero() {
+		b.def = other.def
+	}
+	if other.detail != "" {
+		b.detail = other.detail
+	}
+	if other.detailKey != nil {
+		b.detailKey = other.detailKey
+	}
+	if other.err != nil {
+		b.err = other.err
+		b.problem = other.problem
+		b.subProblems = other.subProblems
+	}
+	if len(other.extensions) > 0 {
+		if b.extensions == nil {
+			b.extensions = make(Extensions, len(other.extensions))
+		}
+		for k, v := range other.extensions {
+			b.extensions[k] = v
+		}
+	}
+	if other.instanceURI != "" {
+		b.instanceURI = other.instanceURI
+	}
+	if other.logLevel != 0 {
+		b.logLevel = other.logLevel
+	}
+	if other.stack != "" {
+		b.stack = other.stack
+	}
+	if other.stackFlag.IsPresent() {
+		b.stackFlag = other.stackFlag
+	}
+	if other.stackFramesSkipped != 0 {
+		b.stackFramesSkipped = other.stackFramesSkipped
+	}
+	if other.status != 0 {
+		b.status = other.status
+	}
+	if !other.timestamp.IsZero() {
+		b.timestamp = other.timestamp
+	}
+	if other.timestampFlag.IsPresent() {
+		b.timestampFlag = other.timestampFlag
+	}
+	if other.title != "" {
+		b.title = other.title
+	}
+	if other.titleKey != nil {
+		b.titleKey = other.titleKey
+	}
+	if other.traceContextFlag.IsPresent() {
+		b.traceContextFlag = other.traceContextFlag
+	}
+	if other.typeURI != "" {
+		b.typeURI = other.typeURI
+	}
+	if other.uuid != "" {
+		b.uuid = other.uuid
+	}
+	if other.uuidFlag.IsPresent() {
+		b.uuidFlag = other.uuidFlag
+	}
+	return b
+}
+
 // Problem returns a constructed Problem.
+//
+// Panics if Generator.StrictMode is enabled and the Problem is missing any of the Type.RequiredFields of the Type
+// provided using Builder.Definition or Builder.DefinitionType, has a type URI that is not allowed by
+// Generator.AllowedTypeURIs, or if Generator.RFC7807Mode is also enabled and the Problem does not conform to RFC 7807
+// (see ValidateRFC7807).
 func (b *Builder) Problem() *Problem {
 	return b.build(1)
 }
 
+// RateLimit sets the rate limit that was applied to the request that resulted in the Problem being built, which is
+// included as extensions using ExtensionRateLimit, ExtensionRateLimitRemaining, and ExtensionRateLimitReset as the
+// keys, where limit is the maximum number of requests allowed within the current window, remaining is the number of
+// requests still allowed within it, and reset is the time until it resets, rounded up to the nearest second. Any
+// negative number or duration is treated as zero.
+//
+// When a Problem containing the rate limit is written as an HTTP response (e.g. via Generator.WriteProblem), the
+// "RateLimit-Limit", "RateLimit-Remaining", and "RateLimit-Reset" headers are also added along with their legacy
+// "X-RateLimit-*" equivalents, unless already present. "RateLimit-Reset" contains the number of seconds until the
+// reset while "X-RateLimit-Reset" contains the time of the reset as a Unix timestamp in seconds, as is conventional.
+// Builder.RetryAfter may also be used to add a Retry-After header.
+//
+// This is a convenient shorthand for calling Builder.Extension with each key and so the same precedence rules apply.
+//
+// For example;
+//
+//	Build().
+//		Definition(problemhttp.TooManyRequestsDefinition).
+//		RateLimit(100, 0, 30*time.Second).
+//		RetryAfter(30*time.Second).
+//		Problem()
+func (b *Builder) RateLimit(limit, remaining int, reset time.Duration) *Builder {
+	return b.Extension(ExtensionRateLimit, max(limit, 0)).
+		Extension(ExtensionRateLimitRemaining, max(remaining, 0)).
+		Extension(ExtensionRateLimitReset, retryAfterSeconds(reset))
+}
+
 // Reset clears all information used to build a Problem.
 func (b *Builder) Reset() *Builder {
 	// Retain Generator and ctx
 	b.code = ""
+	b.deadlineStart = optional.Empty[time.Time]()
 	b.def = Definition{}
 	b.detail = ""
 	b.detailKey = nil
@@ -295,18 +539,36 @@ func (b *Builder) Reset() *Builder {
 	b.stackFlag = optional.Empty[Flag]()
 	b.stackFramesSkipped = 0
 	b.status = 0
+	b.subProblems = nil
+	b.timestamp = time.Time{}
+	b.timestampFlag = optional.Empty[Flag]()
 	b.title = ""
 	b.titleKey = nil
+	b.traceContextFlag = optional.Empty[Flag]()
 	b.typeURI = ""
 	b.uuid = ""
 	b.uuidFlag = optional.Empty[Flag]()
 	return b
 }
 
+// RetryAfter sets a hint for how long a client should wait before retrying a request that resulted in the Problem being
+// built, which is included as an extension using ExtensionRetryAfter as the key, rounded up to the nearest second. Any
+// negative duration is treated as zero.
+//
+// When a Problem containing the hint is written as an HTTP response (e.g. via Generator.WriteProblem), a Retry-After
+// header is also added, unless already present. See Problem.IsRetryable for how the hint affects retryability.
+//
+// This is a convenient shorthand for calling Builder.Extension with ExtensionRetryAfter and so the same precedence
+// rules apply.
+func (b *Builder) RetryAfter(d time.Duration) *Builder {
+	return b.Extension(ExtensionRetryAfter, retryAfterSeconds(d))
+}
+
 // Stack sets the flags to be used to control if/how a captured stack trace is visible when building a Problem. See
 // Problem.Stack for more information.
 //
-// By default, Generator.StackFlag is used to control visibility of a stack trace.
+// By default, Definition.StackFlag, Type.StackFlag, or Generator.StackFlag is used to control visibility of a stack
+// trace, in that order of precedence.
 //
 // If no flags are provided, this is considered equal to passing FlagField and FlagLog. If FlagDisable is given, all
 // other flags are ignored. No stack trace is captured if FlagDisable is provided.
@@ -342,6 +604,22 @@ func (b *Builder) String() string {
 	return b.build(1).String()
 }
 
+// Timestamp sets the flags to be used to control if/how a generated timestamp is visible when building a Problem. See
+// Problem.Timestamp for more information.
+//
+// By default, Generator.TimestampFlag is used to control visibility of a timestamp.
+//
+// If no flags are provided, this is considered equal to passing FlagField and FlagLog. If FlagDisable is given, all
+// other flags are ignored. No timestamp is generated if FlagDisable is provided.
+//
+// If a timestamp needs to be generated and Builder.Wrap is used and a Problem is unwrapped that already has a
+// timestamp, its timestamp will be used instead of generating a timestamp to ensure that the time at which the problem
+// originally occurred is retained.
+func (b *Builder) Timestamp(flags ...Flag) *Builder {
+	b.timestampFlag = resolveFlag(flags)
+	return b
+}
+
 // Title sets the given title to be used when building a Problem. See Problem.Title for more information.
 //
 // If title is not empty, it will take precedence over anything provided using Builder.Definition or Builder.Wrap.
@@ -372,6 +650,77 @@ func (b *Builder) TitleKey(key any) *Builder {
 	return b
 }
 
+// ToDefinition returns a Definition containing the defaults of the Builder (i.e. anything provided using
+// Builder.Definition or Builder.DefinitionType) with any fields that have been explicitly defined applied on top. This
+// allows a configured Builder to be converted back into a reusable Definition (e.g. when creating a catalog from
+// runtime configuration).
+//
+// Anything that is specific to a single occurrence of a problem is not included (e.g. any wrapped error, along with any
+// fields unwrapped from it, captured stack trace, or generated "UUID"). Explicitly defined extensions are merged on top
+// of the extensions of the Definition, taking precedence over any with the same key.
+//
+// For example;
+//
+//	def := Build().Definition(http.NotFoundDefinition).Code("USER-404").Detail("User not found").ToDefinition()
+func (b *Builder) ToDefinition() Definition {
+	def := b.def
+	if b.code != "" {
+		def.Code = b.code
+	}
+	if b.detail != "" {
+		def.Detail = b.detail
+	}
+	if b.detailKey != nil {
+		def.DetailKey = b.detailKey
+	}
+	if b.extensions != nil {
+		def.Extensions = mergeExtensions(false, b.def.Extensions, b.extensions)
+	} else {
+		def.Extensions = maps.Clone(b.def.Extensions)
+	}
+	if b.instanceURI != "" {
+		def.Instance = b.instanceURI
+	}
+	if b.stackFlag.IsPresent() {
+		def.StackFlag = b.stackFlag
+	}
+	if b.uuidFlag.IsPresent() {
+		def.UUIDFlag = b.uuidFlag
+	}
+	if b.logLevel != 0 {
+		def.Type.LogLevel = b.logLevel
+	}
+	if b.status != 0 {
+		def.Type.Status = b.status
+	}
+	if b.title != "" {
+		def.Type.Title = b.title
+	}
+	if b.titleKey != nil {
+		def.Type.TitleKey = b.titleKey
+	}
+	if b.typeURI != "" {
+		def.Type.URI = b.typeURI
+	}
+	return def
+}
+
+// TraceContext sets the flags to be used to control if/how the trace context (i.e. the trace and span IDs) of the span
+// that is active within the context used to build a Problem (see Builder.Context), as read by
+// Generator.TraceContextReader, is visible. See Problem.TraceID and Problem.SpanID for more information.
+//
+// By default, Generator.TraceContextFlag is used to control visibility of the trace context.
+//
+// If no flags are provided, this is considered equal to passing FlagField and FlagLog. If FlagDisable is given, all
+// other flags are ignored. The trace context is not read if FlagDisable is provided.
+//
+// If Builder.Wrap is used and a Problem is unwrapped that already has a trace context, its trace context will be used
+// instead of that of the active span to ensure that the span in which the problem originally occurred is retained.
+func (b *Builder) TraceContext(flags ...Flag) *Builder {
+	b.traceContextFlag = resolveFlag(flags)
+	return b
+}
+
 // Type sets the type URI reference to be used when building a Problem. See Problem.Type for more information.
 //
 // An uri.Builder can be used to aid building the URI reference.
@@ -396,7 +745,8 @@ func (b *Builder) Typef(format string, args ...any) *Builder {
 // UUID sets the flags to be used to control if/how a generated "UUID" is visible when building a Problem. See
 // Problem.UUID for more information.
 //
-// By default, Generator.UUIDFlag is used to control visibility of a "UUID".
+// By default, Definition.UUIDFlag, Type.UUIDFlag, or Generator.UUIDFlag is used to control visibility of a "UUID", in
+// that order of precedence.
 //
 // If no flags are provided, this is considered equal to passing FlagField and FlagLog. If FlagDisable is given, all
 // other flags are ignored. No "UUID" is generated if FlagDisable is provided.
@@ -419,23 +769,72 @@ func (b *Builder) UUID(flags ...Flag) *Builder {
 //
 // If no Unwrapper is provided, Generator.Unwrapper is used from Builder.Generator if not nil, otherwise from
 // DefaultGenerator. If an Unwrapper could still not be resolved, it defaults to PropagatedFieldUnwrapper.
+//
+// Regardless of the Unwrapper, any extensions of a wrapped Problem whose keys are within Generator.PropagatedExtensions
+// (resolved in the same way) are also treated as if unwrapped.
+//
+// If err is, or wraps, a joined error (i.e. an error implementing Unwrap() []error, such as those returned by
+// errors.Join) containing multiple problems and Generator.ProblemSelector (resolved in the same way) is not nil, the
+// Problem it selects is unwrapped instead of the first and all others are recorded within the extensions of the Problem
+// using ExtensionSubProblems as the key, unless an extension already exists with that key.
+//
+// All errors within a joined error are retained as they are wrapped as-is, so errors.Is and errors.As continue to check
+// every branch when passed the Problem (see Problem.Unwrap), and the Unwrapper is passed every branch of the joined error,
+// with that containing the selected Problem first, so that it can extract fields from any Problem within its tree (e.g.
+// PropagatedFieldUnwrapper).
+//
+// If err's tree contains no Problem and no Definition has been provided using Builder.Definition or
+// Builder.DefinitionType, err may instead be mapped to a Definition using Generator.ErrorMappers (resolved in the same
+// way), which is then used as if it had been provided.
 func (b *Builder) Wrap(err error, unwrapper ...Unwrapper) *Builder {
+	gen := b.Generator
+	if gen == nil {
+		gen = DefaultGenerator
+	}
 	var _unwrapper Unwrapper
 	if len(unwrapper) > 0 {
 		_unwrapper = unwrapper[0]
-	} else if g := b.Generator; g != nil {
-		_unwrapper = g.Unwrapper
 	} else {
-		_unwrapper = DefaultGenerator.Unwrapper
+		_unwrapper = gen.Unwrapper
 	}
 	if _unwrapper == nil {
 		_unwrapper = unwrapPropagatedFields
 	}
 	b.err = err
-	b.problem = _unwrapper(err)
+	source := err
+	primary, others, branches := selectJoinedProblems(err, gen.ProblemSelector)
+	if primary != nil {
+		// Ensure that the primary Problem is found first while still exposing every other branch exactly once
+		source = errors.Join(branches...)
+	}
+	b.subProblems = others
+	b.problem = _unwrapper(source)
+	b.problem.Extensions = propagateExtensions(source, b.problem.Extensions, gen.PropagatedExtensions)
 	return b
 }
 
+// WrapValue sets the value to be wrapped when building a Problem, which is useful when the value is not guaranteed to be
+// an error (e.g. a value recovered from a panic).
+//
+// If v is an error, this is the equivalent of calling Builder.Wrap. Otherwise, v is represented by a ValueError, which
+// preserves the original value while its string representation is used as the error message. Additionally, if v is
+// structured (i.e. a map, slice, array, or struct, or a pointer to one), it is preserved within the extensions of the
+// Problem using ExtensionValue as the key, unless an extension already exists with that key. As such, any structured
+// value should be marshalable if the Problem is to be written as an HTTP response.
+//
+// For example;
+//
+//	defer func() {
+//		if r := recover(); r != nil {
+//			err = Build().WrapValue(r).Problem()
+//		}
+//	}()
+//
+// See Builder.Wrap for more information, including the use of unwrapper.
+func (b *Builder) WrapValue(v any, unwrapper ...Unwrapper) *Builder {
+	return b.Wrap(valueAsError(v), unwrapper...)
+}
+
 // build effectively does the heavy lifting for Builder.Problem but allows control over the number of stack frames to be
 // skipped, which is useful for other internal calls.
 //
@@ -447,46 +846,222 @@ func (b *Builder) build(skipStackFrames int) *Problem {
 	if g == nil {
 		g = GetGenerator(ctx)
 	}
-	return &Problem{
-		Code:       b.buildCode(),
-		Detail:     b.buildDetail(ctx, g),
-		Extensions: b.buildExtensions(),
-		Instance:   b.buildInstance(),
+	if !b.routed {
+		b = g.beforeBuild(b)
+		ctx = b.ctx.OrElseGet(context.Background)
+	}
+	if reflect.ValueOf(b.def).IsZero() {
+		if def, ok := g.mapError(b.err); ok {
+			b = b.Clone()
+			b.def = def
+		}
+	}
+	code, codeSource := b.buildCode()
+	detail, detailSource := b.buildDetail(ctx, g, true)
+	extensions, extensionsSource := b.buildExtensions(ctx, g)
+	instance, instanceSource := b.buildInstance()
+	status, statusSource := b.buildStatus()
+	title, titleSource := b.buildTitle(ctx, g, true)
+	typeURI, typeSource := b.buildType(g)
+	traceID, spanID := b.buildTraceContext(ctx, g)
+	detail, detailSource = b.composeDetail(g, detail, detailSource)
+	detail = truncate(detail, g.DetailMaxLen)
+	title = truncate(title, g.TitleMaxLen)
+	if g.I18NOutputMode == I18NOutputDual {
+		if detail != "" && !g.Visibility.LogOnlyDetail {
+			extensions = putExtensionIfAbsent(extensions, ExtensionLocalizedDetail, detail)
+		}
+		extensions = putExtensionIfAbsent(extensions, ExtensionLocalizedTitle, title)
+		if extensionsSource == "" {
+			extensionsSource = BuildSourceGenerator
+		}
+		detail, detailSource = b.buildDetail(ctx, g, false)
+		detail, detailSource = b.composeDetail(g, detail, detailSource)
+		detail = truncate(detail, g.DetailMaxLen)
+		title, titleSource = b.buildTitle(ctx, g, false)
+		title = truncate(title, g.TitleMaxLen)
+	}
+	var logDetail string
+	if g.Visibility.LogOnlyDetail {
+		logDetail, detail = detail, ""
+	}
+	extensions = sanitizeExtensions(extensions, g.ExtensionSanitizer)
+	extensions, logExtensions := g.Visibility.splitExtensions(extensions)
+	prob := g.newProblem()
+	*prob = Problem{
+		Code:       code,
+		Detail:     detail,
+		Extensions: extensions,
+		Instance:   instance,
+		SpanID:     spanID,
 		Stack:      b.buildStack(g, skipStackFrames),
-		Status:     b.buildStatus(),
-		Title:      b.buildTitle(ctx, g),
-		Type:       b.buildType(g),
+		Status:     status,
+		Timestamp:  b.buildTimestamp(g),
+		Title:      title,
+		TraceID:    traceID,
+		Type:       typeURI,
 		UUID:       b.buildUUID(ctx, g),
 		err:        b.err,
 		logInfo:    b.buildLogInfo(ctx, g, skipStackFrames),
+		pooled:     prob.pooled,
+		sanitizer:  g.ExtensionSanitizer,
 	}
+	prob.logInfo.Detail = logDetail
+	prob.logInfo.Extensions = logExtensions
+	prob.logInfo.SLOClass = g.classifySLO(prob)
+	if g.TraceBuild != nil || g.RecordProvenance {
+		spanID = firstNonZeroValue(prob.SpanID, prob.logInfo.SpanID)
+		stack := firstNonZeroValue(prob.Stack, prob.logInfo.stack())
+		timestamp := firstNonZeroValue(prob.Timestamp, prob.logInfo.Timestamp)
+		traceID = firstNonZeroValue(prob.TraceID, prob.logInfo.TraceID)
+		uuid := firstNonZeroValue(prob.UUID, prob.logInfo.UUID)
+		g.traceBuild(prob, []BuildStep{
+			{Field: FieldCode, Source: codeSource, Value: prob.Code},
+			{Field: FieldDetail, Source: detailSource, Value: prob.Detail},
+			{Field: FieldExtensions, Source: extensionsSource, Value: prob.Extensions},
+			{Field: FieldInstance, Source: instanceSource, Value: prob.Instance},
+			{
+				Field:  FieldSpanID,
+				Source: propagatedSource(spanID, b.problem.SpanID, b.problem.logInfo.SpanID),
+				Value:  spanID,
+			},
+			{
+				Field:  FieldStack,
+				Source: propagatedSource(stack, b.problem.Stack, b.problem.logInfo.stack()),
+				Value:  stack,
+			},
+			{Field: FieldStatus, Source: statusSource, Value: prob.Status},
+			{
+				Field:  FieldTimestamp,
+				Source: propagatedSource(timestamp, b.problem.Timestamp, b.problem.logInfo.Timestamp),
+				Value:  timestamp,
+			},
+			{Field: FieldTitle, Source: titleSource, Value: prob.Title},
+			{
+				Field:  FieldTraceID,
+				Source: propagatedSource(traceID, b.problem.TraceID, b.problem.logInfo.TraceID),
+				Value:  traceID,
+			},
+			{Field: FieldType, Source: typeSource, Value: prob.Type},
+			{Field: FieldUUID, Source: propagatedSource(uuid, b.problem.UUID, b.problem.logInfo.UUID), Value: uuid},
+		})
+	}
+	if g.StrictMode {
+		if err := checkRequiredFields(prob, b.def.Type); err != nil {
+			panic(err)
+		}
+		if err := g.checkTypeURI(prob.Type); err != nil {
+			panic(err)
+		}
+		if g.RFC7807Mode {
+			if err := ValidateRFC7807(prob); err != nil {
+				panic(err)
+			}
+		}
+	}
+	if !b.routed {
+		prob = g.Router.Route(ctx, g, prob)
+		g.afterBuild(ctx, prob)
+		g.recordSpanEvent(ctx, prob)
+	}
+	return prob
 }
 
-// buildCode returns the most suitable Code for building a Problem.
-func (b *Builder) buildCode() Code {
-	return firstNonZeroValue(b.code, b.problem.Code, b.def.Code)
+// buildCode returns the most suitable Code for building a Problem, along with its BuildSource.
+func (b *Builder) buildCode() (Code, BuildSource) {
+	switch {
+	case b.code != "":
+		return b.code, BuildSourceExplicit
+	case b.problem.Code != "":
+		return b.problem.Code, BuildSourceWrapped
+	case b.def.Code != "":
+		return b.def.Code, BuildSourceDefinition
+	default:
+		return "", ""
+	}
 }
 
-// buildDetail returns the most suitable detail for building a Problem.
-func (b *Builder) buildDetail(ctx context.Context, gen *Generator) string {
+// buildDetail returns the most suitable detail for building a Problem, along with its BuildSource.
+//
+// If localize is false, any translation keys are ignored.
+func (b *Builder) buildDetail(ctx context.Context, gen *Generator, localize bool) (string, BuildSource) {
 	var v string
-	if v = gen.translateOrElse(ctx, b.detailKey, b.detail); v != "" {
-		return v
+	if v = gen.translateOrElse(ctx, localize, b.detailKey, b.detail); v != "" {
+		return v, BuildSourceExplicit
 	}
 	if v = b.problem.Detail; v != "" {
-		return v
+		return v, BuildSourceWrapped
 	}
-	return gen.translateOrElse(ctx, b.def.DetailKey, b.def.Detail)
+	if v = gen.translateOrElse(ctx, localize, b.def.DetailKey, b.def.Detail); v != "" {
+		return v, BuildSourceDefinition
+	}
+	return "", ""
 }
 
-// buildExtensions returns a shallow clone of the most suitable extensions for building a Problem.
-func (b *Builder) buildExtensions() map[string]any {
-	return maps.Clone(firstNonNilMap(b.extensions, b.problem.Extensions, b.def.Extensions))
+// buildExtensions returns a clone of the most suitable extensions for building a Problem based on
+// Generator.ExtensionMergeStrategy, along with the BuildSource with the highest precedence that contributed to them.
+//
+// If a structured value was wrapped using Builder.WrapValue, it is also included using ExtensionValue as the key. The
+// same applies to any deadline extensions enabled using Builder.DeadlineExtensions. However, neither will replace an
+// existing extension with the same key.
+func (b *Builder) buildExtensions(ctx context.Context, gen *Generator) (map[string]any, BuildSource) {
+	var extensions map[string]any
+	var source BuildSource
+	switch {
+	case b.extensions != nil:
+		source = BuildSourceExplicit
+	case b.problem.Extensions != nil:
+		source = BuildSourceWrapped
+	case b.def.Extensions != nil:
+		source = BuildSourceDefinition
+	}
+	switch gen.ExtensionMergeStrategy {
+	case MergeStrategyShallow:
+		extensions = mergeExtensions(false, b.def.Extensions, b.problem.Extensions, b.extensions)
+	case MergeStrategyDeep:
+		extensions = mergeExtensions(true, b.def.Extensions, b.problem.Extensions, b.extensions)
+	default:
+		extensions = maps.Clone(firstNonNilMap(b.extensions, b.problem.Extensions, b.def.Extensions))
+	}
+	if v, ok := structuredValue(b.err); ok {
+		extensions = putExtensionIfAbsent(extensions, ExtensionValue, v)
+	}
+	if len(b.subProblems) > 0 {
+		extensions = putExtensionIfAbsent(extensions, ExtensionSubProblems, b.subProblems)
+	}
+	if u := firstNonZeroValue(b.def.HelpURL, b.def.Type.HelpURL); u != "" {
+		extensions = putExtensionIfAbsent(extensions, ExtensionHelpURL, u)
+	}
+	if u := firstNonZeroValue(b.def.DocsURL, b.def.Type.DocsURL); u != "" {
+		extensions = putExtensionIfAbsent(extensions, ExtensionDocsURL, u)
+	}
+	if start, ok := b.deadlineStart.Get(); ok && errors.Is(b.err, context.DeadlineExceeded) {
+		if deadline, hasDeadline := ctx.Deadline(); hasDeadline {
+			extensions = putExtensionIfAbsent(extensions, ExtensionDeadline, deadline)
+			if !start.IsZero() {
+				extensions = putExtensionIfAbsent(extensions, ExtensionElapsed, time.Since(start).String())
+				extensions = putExtensionIfAbsent(extensions, ExtensionTimeoutBudget, deadline.Sub(start).String())
+			}
+		}
+	}
+	if source == "" && len(extensions) > 0 {
+		source = BuildSourceGenerator
+	}
+	return extensions, source
 }
 
-// buildInstance returns the most suitable instance URI reference for building a Problem.
-func (b *Builder) buildInstance() string {
-	return firstNonZeroValue(b.instanceURI, b.problem.Instance, b.def.Instance)
+// buildInstance returns the most suitable instance URI reference for building a Problem, along with its BuildSource.
+func (b *Builder) buildInstance() (string, BuildSource) {
+	switch {
+	case b.instanceURI != "":
+		return b.instanceURI, BuildSourceExplicit
+	case b.problem.Instance != "":
+		return b.problem.Instance, BuildSourceWrapped
+	case b.def.Instance != "":
+		return b.def.Instance, BuildSourceDefinition
+	default:
+		return "", ""
+	}
 }
 
 // buildLogInfo returns the most suitable log information for building a Problem.
@@ -498,10 +1073,22 @@ func (b *Builder) buildInstance() string {
 // buildLogInfo.
 func (b *Builder) buildLogInfo(ctx context.Context, gen *Generator, skipStackFrames int) (info LogInfo) {
 	info.Level = firstNonZeroValue(b.logLevel, b.problem.logInfo.Level, gen.logLevel(b.def.Type))
-	if checkFlag(b.stackFlag.OrElse(gen.StackFlag), FlagLog) {
-		info.Stack = b.getStack(skipStackFrames + 1)
+	info.Owner = firstNonZeroValue(b.problem.logInfo.Owner, b.def.Owner)
+	info.RunbookURL = firstNonZeroValue(b.problem.logInfo.RunbookURL, b.def.RunbookURL)
+	if stackFlag := b.resolveStackFlag(gen); checkFlag(stackFlag, FlagLog) {
+		if lazy := b.getLazyStack(gen, stackFlag, skipStackFrames+1); lazy != nil {
+			info.lazyStack = lazy
+		} else {
+			info.Stack = b.getStack(gen, skipStackFrames+1)
+		}
+	}
+	if checkFlag(b.resolveTimestampFlag(gen), FlagLog) {
+		info.Timestamp = b.getTimestamp(gen)
 	}
-	if checkFlag(b.uuidFlag.OrElse(gen.UUIDFlag), FlagLog) {
+	if checkFlag(b.resolveTraceContextFlag(gen), FlagLog) {
+		info.TraceID, info.SpanID = b.getTraceContext(ctx, gen)
+	}
+	if checkFlag(b.resolveUUIDFlag(gen), FlagLog) {
 		info.UUID = b.getUUID(ctx, gen)
 	}
 	return
@@ -514,71 +1101,186 @@ func (b *Builder) buildLogInfo(ctx context.Context, gen *Generator, skipStackFra
 // skipStackFrames is the number of frames before recording the stack trace with zero identifying the caller of
 // buildStack.
 func (b *Builder) buildStack(gen *Generator, skipStackFrames int) string {
-	if checkFlag(b.stackFlag.OrElse(gen.StackFlag), FlagField) {
-		return b.getStack(skipStackFrames + 1)
+	if checkFlag(b.resolveStackFlag(gen), FlagField) {
+		return b.getStack(gen, skipStackFrames+1)
 	}
 	return ""
 }
 
-// buildStatus returns the most suitable status for building a Problem. 500 is returned if no suitable status could be
-// derived.
-func (b *Builder) buildStatus() int {
-	return firstNonZeroValue(b.status, b.problem.Status, b.def.Type.Status, http.StatusInternalServerError)
+// buildStatus returns the most suitable status for building a Problem, along with its BuildSource. 500 is returned if no
+// suitable status could be derived.
+func (b *Builder) buildStatus() (int, BuildSource) {
+	switch {
+	case b.status != 0:
+		return b.status, BuildSourceExplicit
+	case b.problem.Status != 0:
+		return b.problem.Status, BuildSourceWrapped
+	case b.def.Type.Status != 0:
+		return b.def.Type.Status, BuildSourceDefinition
+	default:
+		return http.StatusInternalServerError, BuildSourceDefault
+	}
 }
 
-// buildTitle returns the most suitable title for building a Problem.
-func (b *Builder) buildTitle(ctx context.Context, gen *Generator) string {
+// buildTimestamp returns the most suitable timestamp for building a Problem.
+//
+// A zero time.Time is returned if timestampFlag does not contain FlagField.
+func (b *Builder) buildTimestamp(gen *Generator) time.Time {
+	if checkFlag(b.resolveTimestampFlag(gen), FlagField) {
+		return b.getTimestamp(gen)
+	}
+	return time.Time{}
+}
+
+// buildTitle returns the most suitable title for building a Problem, along with its BuildSource.
+//
+// Any title within Generator.StatusTitles for the status of the Problem, localized where possible, takes precedence over
+// Type.Title, but not over a localized title resolved from Type.TitleKey.
+func (b *Builder) buildTitle(ctx context.Context, gen *Generator, localize bool) (string, BuildSource) {
 	var v string
-	if v = gen.translateOrElse(ctx, b.titleKey, b.title); v != "" {
-		return v
+	if v = gen.translateOrElse(ctx, localize, b.titleKey, b.title); v != "" {
+		return v, BuildSourceExplicit
 	}
 	if v = b.problem.Title; v != "" {
-		return v
+		return v, BuildSourceWrapped
 	}
-	if v = gen.translateOrElse(ctx, b.def.Type.TitleKey, b.def.Type.Title); v != "" {
-		return v
+	if v = gen.translateOrElse(ctx, localize, b.def.Type.TitleKey, ""); v != "" {
+		return v, BuildSourceDefinition
 	}
-	return DefaultTitle
+	status, _ := b.buildStatus()
+	if v = gen.StatusTitles[status]; v != "" {
+		return gen.translateOrElse(ctx, localize, v, v), BuildSourceGenerator
+	}
+	if v = b.def.Type.Title; v != "" {
+		return v, BuildSourceDefinition
+	}
+	return DefaultTitle, BuildSourceDefault
 }
 
-// buildType returns the most suitable type URI reference for building a Problem. DefaultTypeURI is returned if no
-// suitable type URI reference could be derived.
-func (b *Builder) buildType(gen *Generator) string {
-	return firstNonZeroValue(b.typeURI, b.problem.Type, gen.typeURI(b.def.Type), DefaultTypeURI)
+// buildTraceContext returns the most suitable trace and span IDs for building a Problem.
+//
+// Empty strings are returned if traceContextFlag does not contain FlagField.
+func (b *Builder) buildTraceContext(ctx context.Context, gen *Generator) (traceID, spanID string) {
+	if checkFlag(b.resolveTraceContextFlag(gen), FlagField) {
+		return b.getTraceContext(ctx, gen)
+	}
+	return "", ""
+}
+
+// buildType returns the most suitable type URI reference for building a Problem, along with its BuildSource.
+// DefaultTypeURI is returned if no suitable type URI reference could be derived.
+func (b *Builder) buildType(gen *Generator) (string, BuildSource) {
+	if b.typeURI != "" {
+		return b.typeURI, BuildSourceExplicit
+	}
+	if b.problem.Type != "" {
+		return b.problem.Type, BuildSourceWrapped
+	}
+	if v := gen.typeURI(b.def.Type); v != "" {
+		return v, BuildSourceDefinition
+	}
+	return DefaultTypeURI, BuildSourceDefault
 }
 
 // buildUUID returns the most suitable "UUID" for building a Problem.
 //
 // An empty string is returned if uuidFlag does not contain FlagField.
 func (b *Builder) buildUUID(ctx context.Context, gen *Generator) string {
-	if checkFlag(b.uuidFlag.OrElse(gen.UUIDFlag), FlagField) {
+	if checkFlag(b.resolveUUIDFlag(gen), FlagField) {
 		return b.getUUID(ctx, gen)
 	}
 	return ""
 }
 
+// composeDetail returns the given detail composed with any wrapped error using Generator.DetailComposer, along with
+// its BuildSource, where the BuildSource is only changed if the detail was otherwise empty.
+//
+// If Generator.DetailComposer is nil, Visibility.LogOnlyErrorMessages is enabled, or no error is wrapped, detail and
+// source are returned as-is.
+func (b *Builder) composeDetail(gen *Generator, detail string, source BuildSource) (string, BuildSource) {
+	if gen.DetailComposer == nil || gen.Visibility.LogOnlyErrorMessages || b.err == nil {
+		return detail, source
+	}
+	composed := gen.DetailComposer(detail, b.err)
+	if source == "" && composed != "" {
+		source = BuildSourceGenerator
+	}
+	return composed, source
+}
+
 // getStack returns a lazily captured stack trace to be used for building a Problem. Priority is given to any existing
-// stack contained within problem.
+// stack contained within problem, otherwise the stack trace is formatted using Generator.StackFormatter.
 //
 // skip is the number of frames before recording the stack trace with zero identifying the caller of getStack.
-func (b *Builder) getStack(skip int) string {
+func (b *Builder) getStack(gen *Generator, skip int) string {
 	if b.stack != "" {
 		return b.stack
 	}
 	switch {
 	case b.problem.Stack != "":
 		b.stack = b.problem.Stack
-	case b.problem.logInfo.Stack != "":
-		b.stack = b.problem.logInfo.Stack
+	case b.problem.logInfo.Stack != "", b.problem.logInfo.lazyStack != nil:
+		b.stack = b.problem.logInfo.stack()
 	default:
 		if b.stackFramesSkipped > 0 {
 			skip += b.stackFramesSkipped
 		}
-		b.stack = stack.Take(skip + 1)
+		b.stack = gen.takeStack(skip + 1)
 	}
 	return b.stack
 }
 
+// getLazyStack returns a stack trace to be used only for logging purposes when building a Problem that is captured but
+// not yet formatted, but only if Generator.StackCaptureMode is StackCaptureLazy and stackFlag does not contain
+// FlagField. Priority is given to any existing stack contained within problem and nil is returned if a formatted stack
+// trace is already available, in which case getStack should be used instead.
+//
+// skip is the number of frames before recording the stack trace with zero identifying the caller of getLazyStack.
+func (b *Builder) getLazyStack(gen *Generator, stackFlag Flag, skip int) *lazyStack {
+	switch {
+	case b.stack != "", b.problem.Stack != "", b.problem.logInfo.Stack != "":
+		return nil
+	case b.problem.logInfo.lazyStack != nil:
+		return b.problem.logInfo.lazyStack
+	case gen.StackCaptureMode != StackCaptureLazy, checkFlag(stackFlag, FlagField):
+		return nil
+	}
+	if b.stackFramesSkipped > 0 {
+		skip += b.stackFramesSkipped
+	}
+	return gen.captureStack(skip + 1)
+}
+
+// getTimestamp returns a lazily generated timestamp to be used for building a Problem. Priority is given to any
+// existing timestamp contained within problem.
+func (b *Builder) getTimestamp(gen *Generator) time.Time {
+	if !b.timestamp.IsZero() {
+		return b.timestamp
+	}
+	switch {
+	case !b.problem.Timestamp.IsZero():
+		b.timestamp = b.problem.Timestamp
+	case !b.problem.logInfo.Timestamp.IsZero():
+		b.timestamp = b.problem.logInfo.Timestamp
+	default:
+		b.timestamp = gen.now()
+	}
+	return b.timestamp
+}
+
+// getTraceContext returns the trace and span IDs to be used for building a Problem. Priority is given to any existing
+// trace context contained within problem, falling back to the span within ctx.
+func (b *Builder) getTraceContext(ctx context.Context, gen *Generator) (traceID, spanID string) {
+	switch {
+	case b.problem.TraceID != "":
+		return b.problem.TraceID, b.problem.SpanID
+	case b.problem.logInfo.TraceID != "":
+		return b.problem.logInfo.TraceID, b.problem.logInfo.SpanID
+	default:
+		return gen.readTraceContext(ctx)
+	}
+}
+
 // getUUID returns a lazily generated "UUID" to be used for building a Problem. Priority is given to any existing uuid
 // contained within problem.
 func (b *Builder) getUUID(ctx context.Context, gen *Generator) string {
@@ -596,6 +1298,41 @@ func (b *Builder) getUUID(ctx context.Context, gen *Generator) string {
 	return b.uuid
 }
 
+// resolveStackFlag returns the most suitable Flag to control if/how a captured stack trace is visible when building a
+// Problem.
+//
+// Priority is given to any explicitly defined Flag, followed by Definition.StackFlag, Type.StackFlag, and finally
+// Generator.StackFlag. Either way, Visibility.LogOnlyStack is applied to the resolved Flag.
+func (b *Builder) resolveStackFlag(gen *Generator) Flag {
+	flag := optional.Find(b.stackFlag, b.def.StackFlag, b.def.Type.StackFlag).OrElse(gen.StackFlag)
+	return gen.Visibility.visibleStackFlag(flag)
+}
+
+// resolveTimestampFlag returns the most suitable Flag to control if/how a generated timestamp is visible when building a
+// Problem.
+//
+// Priority is given to any explicitly defined Flag, followed by Generator.TimestampFlag.
+func (b *Builder) resolveTimestampFlag(gen *Generator) Flag {
+	return b.timestampFlag.OrElse(gen.TimestampFlag)
+}
+
+// resolveTraceContextFlag returns the most suitable Flag to control if/how the trace context is visible when building a
+// Problem.
+//
+// Priority is given to any explicitly defined Flag, followed by Generator.TraceContextFlag.
+func (b *Builder) resolveTraceContextFlag(gen *Generator) Flag {
+	return b.traceContextFlag.OrElse(gen.TraceContextFlag)
+}
+
+// resolveUUIDFlag returns the most suitable Flag to control if/how a generated "UUID" is visible when building a
+// Problem.
+//
+// Priority is given to any explicitly defined Flag, followed by Definition.UUIDFlag, Type.UUIDFlag, and finally
+// Generator.UUIDFlag.
+func (b *Builder) resolveUUIDFlag(gen *Generator) Flag {
+	return optional.Find(b.uuidFlag, b.def.UUIDFlag, b.def.Type.UUIDFlag).OrElse(gen.UUIDFlag)
+}
+
 // Build returns a Builder for the Generator with context.Background which can be used to construct problems.
 func (g *Generator) Build() *Builder {
 	return &Builder{
@@ -655,6 +1392,85 @@ func firstNonZeroValue[T comparable](values ...T) T {
 	return zero
 }
 
+// mergeExtensions returns a map containing the entries of all given extensions, in order of ascending precedence, where
+// entries with the same key are replaced.
+//
+// If deep is true, values that are maps with string keys are combined recursively. Maps are cloned rather than
+// modified, however, all other values are only shallow copies.
+//
+// nil is returned if all extensions are nil.
+func mergeExtensions(deep bool, extensions ...map[string]any) map[string]any {
+	var res map[string]any
+	for _, ext := range extensions {
+		if ext == nil {
+			continue
+		}
+		if res == nil {
+			res = make(map[string]any, len(ext))
+		}
+		for k, v := range ext {
+			if deep {
+				if src, isMap := asStringMap(v); isMap {
+					dst, _ := asStringMap(res[k])
+					v = mergeExtensions(true, dst, src)
+				}
+			}
+			res[k] = v
+		}
+	}
+	return res
+}
+
+// asStringMap returns the given value as a map with string keys, where possible.
+func asStringMap(v any) (map[string]any, bool) {
+	switch m := v.(type) {
+	case map[string]any:
+		return m, m != nil
+	case Extensions:
+		return m, m != nil
+	default:
+		return nil, false
+	}
+}
+
+// propagateExtensions returns a clone of the given extensions containing any extensions of the wrapped problems in
+// err's tree whose keys are within the allowlist provided, without replacing any existing extensions. Where multiple
+// problems contain the same extension (e.g. within the branches of a joined error), the first found is used.
+//
+// extensions is returned as-is if allowlist is empty or no Problem is present in err's tree.
+func propagateExtensions(err error, extensions map[string]any, allowlist []string) map[string]any {
+	if len(allowlist) == 0 {
+		return extensions
+	}
+	cloned := false
+	walkProblems(err, func(p *Problem) bool {
+		for _, key := range allowlist {
+			if v, found := p.Extensions[key]; found {
+				if !cloned {
+					extensions = maps.Clone(extensions)
+					cloned = true
+				}
+				extensions = putExtensionIfAbsent(extensions, key, v)
+			}
+		}
+		return true
+	})
+	return extensions
+}
+
+// putExtensionIfAbsent puts the given value into extensions using the key provided, but only if extensions does not
+// already contain key, returning extensions. If extensions is nil, a new map is created and returned.
+func putExtensionIfAbsent(extensions map[string]any, key string, value any) map[string]any {
+	if _, found := extensions[key]; found {
+		return extensions
+	}
+	if extensions == nil {
+		extensions = make(map[string]any, 1)
+	}
+	extensions[key] = value
+	return extensions
+}
+
 // resolveFlag returns an optional Flag based on the given flags.
 //
 // If flags is empty, this is considered equal to passing FlagField and FlagLog. If FlagDisable is given, all other
@@ -676,6 +1492,16 @@ func resolveFlag(flags []Flag) optional.Optional[Flag] {
 	return optional.Of(res)
 }
 
+// truncate returns the given string truncated to maxLen runes, where the last rune is replaced with an ellipsis, but
+// only if s contains more than maxLen runes. If maxLen is zero or less, s is returned as-is.
+func truncate(s string, maxLen int) string {
+	if maxLen <= 0 || utf8.RuneCountInString(s) <= maxLen {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:maxLen-1]) + "…"
+}
+
 // validationExtensionKey returns an error if the extension key provided is either empty or reserved.
 func validationExtensionKey(key string) error {
 	if key == "" {
@@ -0,0 +1,188 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package problem
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// errCanonicalNumber is returned by CanonicalJSON if a number cannot be represented in canonical form.
+var errCanonicalNumber = errors.New("number cannot be represented in canonical JSON")
+
+// CanonicalJSON returns a canonical JSON representation of the given Problem based on the JSON Canonicalization Scheme
+// (JCS) defined in RFC 8785; https://datatracker.ietf.org/doc/html/rfc8785.
+//
+// The output is deterministic for any given Problem, regardless of the order in which extensions were added, which
+// makes it suitable for signing problem payloads (e.g. computing an HMAC) so that they can be verified as being
+// tamper-free by a consumer that canonicalizes the payload in the same way. In canonical form, all object properties
+// are sorted by their UTF-16 code units, all whitespace is removed, strings contain only the minimum escaping required
+// and numbers are serialized in the same way as ECMAScript (i.e. as IEEE 754 double precision values).
+//
+// An error is returned if unable to marshal the Problem (see Problem.MarshalJSON) or if it contains a number that
+// cannot be represented in canonical form (e.g. one that overflows a float64).
+//
+// If p is nil, its canonical JSON representation is null.
+func CanonicalJSON(p *Problem) ([]byte, error) {
+	if p == nil {
+		return []byte("null"), nil
+	}
+	b, err := p.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var v any
+	if err = dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return appendCanonicalJSON(make([]byte, 0, len(b)), v)
+}
+
+// appendCanonicalJSON appends the canonical JSON representation of the given value, which is expected to have been
+// decoded from JSON using json.Decoder.UseNumber, to buf.
+func appendCanonicalJSON(buf []byte, v any) ([]byte, error) {
+	switch v := v.(type) {
+	case nil:
+		return append(buf, "null"...), nil
+	case bool:
+		return strconv.AppendBool(buf, v), nil
+	case json.Number:
+		return appendCanonicalNumber(buf, v)
+	case string:
+		return appendCanonicalString(buf, v), nil
+	case []any:
+		buf = append(buf, '[')
+		for i, e := range v {
+			if i > 0 {
+				buf = append(buf, ',')
+			}
+			var err error
+			if buf, err = appendCanonicalJSON(buf, e); err != nil {
+				return nil, err
+			}
+		}
+		return append(buf, ']'), nil
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		slices.SortFunc(keys, compareUTF16)
+		buf = append(buf, '{')
+		for i, k := range keys {
+			if i > 0 {
+				buf = append(buf, ',')
+			}
+			buf = appendCanonicalString(buf, k)
+			buf = append(buf, ':')
+			var err error
+			if buf, err = appendCanonicalJSON(buf, v[k]); err != nil {
+				return nil, err
+			}
+		}
+		return append(buf, '}'), nil
+	default:
+		return nil, fmt.Errorf("unexpected JSON value type: %T", v)
+	}
+}
+
+// appendCanonicalNumber appends the canonical representation of the given number to buf, which is consistent with
+// ECMAScript's Number.prototype.toString.
+//
+// An error is returned if n cannot be parsed as a finite float64.
+func appendCanonicalNumber(buf []byte, n json.Number) ([]byte, error) {
+	f, err := strconv.ParseFloat(string(n), 64)
+	if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+		return nil, fmt.Errorf("%w: %s", errCanonicalNumber, n)
+	}
+	if f == 0 {
+		// Also normalizes negative zero
+		return append(buf, '0'), nil
+	}
+	abs := math.Abs(f)
+	format := byte('f')
+	if abs < 1e-6 || abs >= 1e21 {
+		format = 'e'
+	}
+	start := len(buf)
+	buf = strconv.AppendFloat(buf, f, format, -1, 64)
+	if format == 'e' {
+		// Convert e-09 to e-9 as exponents are not zero-padded
+		if l := len(buf) - start; l >= 4 && buf[start+l-4] == 'e' && buf[start+l-3] == '-' && buf[start+l-2] == '0' {
+			buf[len(buf)-2] = buf[len(buf)-1]
+			buf = buf[:len(buf)-1]
+		}
+	}
+	return buf, nil
+}
+
+// appendCanonicalString appends the canonical representation of the given string to buf, where only quotation marks,
+// reverse solidi, and control characters are escaped.
+func appendCanonicalString(buf []byte, s string) []byte {
+	const hex = "0123456789abcdef"
+	buf = append(buf, '"')
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c >= utf8.RuneSelf {
+			r, size := utf8.DecodeRuneInString(s[i:])
+			buf = utf8.AppendRune(buf, r)
+			i += size
+			continue
+		}
+		switch c {
+		case '"', '\\':
+			buf = append(buf, '\\', c)
+		case '\b':
+			buf = append(buf, '\\', 'b')
+		case '\f':
+			buf = append(buf, '\\', 'f')
+		case '\n':
+			buf = append(buf, '\\', 'n')
+		case '\r':
+			buf = append(buf, '\\', 'r')
+		case '\t':
+			buf = append(buf, '\\', 't')
+		default:
+			if c < 0x20 {
+				buf = append(buf, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xf])
+			} else {
+				buf = append(buf, c)
+			}
+		}
+		i++
+	}
+	return append(buf, '"')
+}
+
+// compareUTF16 compares the given strings based on their UTF-16 code units, as required when sorting object properties
+// in canonical JSON.
+func compareUTF16(a, b string) int {
+	return slices.Compare(utf16.Encode([]rune(a)), utf16.Encode([]rune(b)))
+}
@@ -0,0 +1,66 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package problem
+
+import "context"
+
+// Check is a convenient helper that reduces the repetitive pattern of wrapping an error in a Problem generated from a
+// Definition. If err is nil, v is returned as-is along with a nil error. Otherwise, the zero value of T is returned
+// along with a Problem generated from the Definition wrapping err, along with any specified options.
+//
+// The Problem is generated using DefaultGenerator, the same as Definition.New.
+//
+// For example;
+//
+//	func (s *UserService) Find(ctx context.Context, id string) (*User, error) {
+//		user, err := s.repo.FindByID(ctx, id)
+//		return Check(user, err, http.NotFoundDefinition, WithDetailf("user %q not found", id))
+//	}
+func Check[T any](v T, err error, def Definition, opts ...Option) (T, error) {
+	if err == nil {
+		return v, nil
+	}
+	var zero T
+	opts = append([]Option{FromDefinition(def), Wrap(err)}, opts...)
+	return zero, DefaultGenerator.new(context.Background(), opts, 1)
+}
+
+// Require is a convenient helper that reduces the repetitive pattern of returning a Problem generated from a Definition
+// when a condition is not met. If cond is true, a nil error is returned. Otherwise, a Problem generated from the
+// Definition, along with any specified options, is returned.
+//
+// The Problem is generated using DefaultGenerator, the same as Definition.New.
+//
+// For example;
+//
+//	func (s *UserService) Update(ctx context.Context, user *User) error {
+//		if err := Require(user.ID != "", http.BadRequestDefinition, WithDetail("user ID is required")); err != nil {
+//			return err
+//		}
+//		// ...
+//	}
+func Require(cond bool, def Definition, opts ...Option) error {
+	if cond {
+		return nil
+	}
+	opts = append([]Option{FromDefinition(def)}, opts...)
+	return DefaultGenerator.new(context.Background(), opts, 1)
+}
@@ -0,0 +1,175 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package clientproblem provides support for consuming problems from HTTP responses when acting as a client (e.g. a
+// gateway calling an upstream service).
+package clientproblem
+
+import (
+	"errors"
+	"github.com/neocotic/go-problem"
+	"net/http"
+)
+
+// MaxBodySize is the maximum number of bytes permitted within the body of an HTTP response when decoding a problem.
+const MaxBodySize = 1 << 20
+
+// Client is an HTTP client that returns any problem contained within the body of an HTTP response (i.e. its
+// Content-Type is either problem.ContentTypeJSON or problem.ContentTypeXML, regardless of any parameters) as an error,
+// so that callers can use problem.As on the errors it returns.
+//
+// Unlike Transport, which must always return an HTTP response as required by http.RoundTripper, no HTTP response is
+// returned by Client when it contains a problem body, and its body is closed.
+//
+// For example;
+//
+//	client := &clientproblem.Client{}
+//	res, err := client.Get("https://api.example.com/users/123")
+//	if upstream, ok := problem.As(err); ok {
+//		return problem.New(problem.WithStatus(http.StatusBadGateway), problem.Wrap(upstream))
+//	} else if err != nil {
+//		return err
+//	}
+//	defer res.Body.Close()
+type Client struct {
+	// HTTPClient is the http.Client used to execute each HTTP request. It may use Transport, in which case the problem
+	// it already decoded is returned.
+	//
+	// If nil, http.DefaultClient is used.
+	HTTPClient *http.Client
+}
+
+// Do executes the given HTTP request using Client.HTTPClient and returns the HTTP response.
+//
+// If the HTTP response contains a problem body, the decoded *problem.Problem is returned as the error instead, and the
+// body is closed. If the decoded problem has no status, the status code of the HTTP response is used. See
+// problem.ParseResponse for more information.
+//
+// An error is also returned if the request fails or if the HTTP response contains a problem body that cannot be decoded
+// or exceeds MaxBodySize bytes, in which case the body is also closed.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	prob, err := ResponseProblem(res)
+	if err != nil {
+		_ = res.Body.Close()
+		return nil, err
+	}
+	if prob != nil {
+		_ = res.Body.Close()
+		return nil, prob
+	}
+	return res, nil
+}
+
+// Get issues a GET request to the given URL using Client.Do. See Client.Do for more information.
+func (c *Client) Get(url string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.Do(req)
+}
+
+// Do executes the given HTTP request using the client provided and, if the response contains a problem body (i.e. its
+// Content-Type is either problem.ContentTypeJSON or problem.ContentTypeXML, regardless of any parameters), decodes the
+// problem and returns it separately from the response.
+//
+// If client is nil, http.DefaultClient is used.
+//
+// When a problem is decoded, the body of the returned response is replaced so that it can still be read by the caller.
+// If the decoded problem has no status, the status code of the response is used. See problem.ParseResponse for more
+// information.
+//
+// An error is returned if the request fails or if the response contains a problem body that cannot be decoded or
+// exceeds MaxBodySize bytes. In the latter cases, the response is still returned. Use Client instead for the problem
+// itself to be returned as the error.
+//
+// For example;
+//
+//	res, prob, err := clientproblem.Do(client, req)
+//	if err != nil {
+//		return err
+//	}
+//	defer res.Body.Close()
+//	if prob != nil {
+//		return problem.New(problem.WithStatus(http.StatusBadGateway), problem.Wrap(prob))
+//	}
+func Do(client *http.Client, req *http.Request) (*http.Response, *problem.Problem, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	prob, err := decode(res)
+	return res, prob, err
+}
+
+// ResponseProblem returns the problem contained within the body of the given HTTP response (i.e. its Content-Type is
+// either problem.ContentTypeJSON or problem.ContentTypeXML, regardless of any parameters), if any, otherwise nil.
+//
+// If the HTTP response was returned by Transport, the problem it already decoded is returned. Otherwise, the problem is
+// decoded and the body of the HTTP response is replaced so that it can still be read by the caller. Either way, if the
+// decoded problem has no status, the status code of the HTTP response is used. See problem.ParseResponse for more
+// information.
+//
+// An error is returned if the HTTP response contains a problem body that cannot be decoded or exceeds MaxBodySize bytes.
+//
+// For example;
+//
+//	client := &http.Client{Transport: &clientproblem.Transport{}}
+//	res, err := client.Get("https://api.example.com/users/123")
+//	if err != nil {
+//		return err
+//	}
+//	defer res.Body.Close()
+//	prob, err := clientproblem.ResponseProblem(res)
+//	if err != nil {
+//		return err
+//	}
+//	if prob != nil {
+//		return problem.New(problem.WithStatus(http.StatusBadGateway), problem.Wrap(prob))
+//	}
+func ResponseProblem(res *http.Response) (*problem.Problem, error) {
+	if body, ok := res.Body.(*problemBody); ok {
+		return body.prob, body.err
+	}
+	return decode(res)
+}
+
+// decode decodes a problem from the body of the given HTTP response, where present, replacing the body so that it can
+// still be read.
+//
+// nil is returned if the response does not contain a problem body.
+func decode(res *http.Response) (*problem.Problem, error) {
+	prob, err := problem.ParseResponse(res, problem.ParseOptions{MaxSize: MaxBodySize})
+	if errors.Is(err, problem.ErrNotProblem) {
+		return nil, nil
+	}
+	return prob, err
+}
@@ -0,0 +1,82 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package clientproblem
+
+import (
+	"github.com/neocotic/go-problem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/ok" {
+			_, _ = io.WriteString(w, "ok")
+			return
+		}
+		prob := problem.New(problem.WithStatus(http.StatusNotFound), problem.WithDetail("missing"))
+		_ = problem.WriteProblemJSON(prob, w, req)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func Test_Client_Do(t *testing.T) {
+	srv := newTestServer(t)
+
+	for name, client := range map[string]*Client{
+		"default":   {},
+		"transport": {HTTPClient: &http.Client{Transport: &Transport{}}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			res, err := client.Get(srv.URL + "/missing")
+			assert.Nil(t, res)
+			prob, ok := problem.As(err)
+			require.True(t, ok, "expected problem error")
+			assert.Equal(t, http.StatusNotFound, prob.Status)
+			assert.Equal(t, "missing", prob.Detail)
+
+			res, err = client.Get(srv.URL + "/ok")
+			require.NoError(t, err)
+			defer res.Body.Close()
+			body, err := io.ReadAll(res.Body)
+			require.NoError(t, err)
+			assert.Equal(t, "ok", string(body))
+		})
+	}
+}
+
+func Test_Transport_RoundTrip(t *testing.T) {
+	srv := newTestServer(t)
+	client := &http.Client{Transport: &Transport{}}
+
+	res, err := client.Get(srv.URL + "/missing")
+	require.NoError(t, err)
+	defer res.Body.Close()
+	prob, err := ResponseProblem(res)
+	require.NoError(t, err)
+	require.NotNil(t, prob)
+	assert.Equal(t, http.StatusNotFound, prob.Status)
+}
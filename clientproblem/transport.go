@@ -0,0 +1,88 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package clientproblem
+
+import (
+	"github.com/neocotic/go-problem"
+	"io"
+	"net/http"
+)
+
+// Transport is an http.RoundTripper that eagerly decodes any HTTP response containing a problem body (i.e. its
+// Content-Type is either problem.ContentTypeJSON or problem.ContentTypeXML, regardless of any parameters) and records
+// the decoded problem against the HTTP response, so that callers of http.Client can obtain it using ResponseProblem
+// without decoding it again.
+//
+// As required by http.RoundTripper, the HTTP response is always returned as long as one was obtained, regardless of
+// whether it contains a problem body, and its body can still be read by the caller. If the decoded problem has no
+// status, the status code of the HTTP response is used. As such, errors returned by http.Client never contain the
+// problem. Use Client instead for the problem to be returned as the error, so that problem.As can be used.
+//
+// For example;
+//
+//	client := &http.Client{Transport: &clientproblem.Transport{}}
+//	res, err := client.Get("https://api.example.com/users/123")
+//	if err != nil {
+//		return err
+//	}
+//	defer res.Body.Close()
+//	if prob, err := clientproblem.ResponseProblem(res); prob != nil || err != nil {
+//		// Handle problem or error
+//	}
+type Transport struct {
+	// Base is the http.RoundTripper used to execute each HTTP request.
+	//
+	// If nil, http.DefaultTransport is used.
+	Base http.RoundTripper
+}
+
+// problemBody is the body of an HTTP response returned by Transport, which records the result of decoding a problem
+// from the original body so that it can be obtained using ResponseProblem.
+type problemBody struct {
+	io.ReadCloser
+	err  error
+	prob *problem.Problem
+}
+
+var _ http.RoundTripper = (*Transport)(nil)
+
+// RoundTrip executes the given HTTP request using Transport.Base and returns the HTTP response. If it contains a problem
+// body, the problem is decoded and recorded against the HTTP response so that it can be obtained using
+// ResponseProblem, along with any error that occurred while decoding it (e.g. if the problem body cannot be decoded or
+// exceeds MaxBodySize bytes).
+//
+// An error is only returned if an HTTP response could not be obtained from Transport.Base.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	res, err := base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	prob, err := decode(res)
+	if prob == nil && err == nil {
+		return res, nil
+	}
+	res.Body = &problemBody{ReadCloser: res.Body, err: err, prob: prob}
+	return res, nil
+}
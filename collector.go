@@ -0,0 +1,136 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package problem
+
+import (
+	"strings"
+	"sync"
+)
+
+type (
+	// BatchProblem is an error aggregating multiple problems, typically produced concurrently and gathered by a
+	// Collector.
+	//
+	// Since BatchProblem implements Unwrap, errors.Is and errors.As (incl. As) can be used against every aggregated
+	// Problem.
+	BatchProblem struct {
+		// Problems contains each aggregated Problem, in the order in which they were added.
+		Problems []*Problem
+	}
+
+	// Collector is used to gather problems that are produced concurrently (e.g. by goroutines managed by an errgroup or
+	// a fan-out handler) so that they can be resolved into a single error once all work has completed.
+	//
+	// The zero value of Collector is ready to use and is safe for concurrent use. A Collector must not be copied after
+	// first use.
+	Collector struct {
+		// mu is the mutex used to guard problems.
+		mu sync.Mutex
+		// problems contains each Problem that has been added.
+		problems []*Problem
+	}
+)
+
+var _ error = (*BatchProblem)(nil)
+
+// Error returns the error messages of each aggregated Problem, separated by a newline.
+func (bp *BatchProblem) Error() string {
+	var sb strings.Builder
+	for i, prob := range bp.Problems {
+		if i > 0 {
+			sb.WriteRune('\n')
+		}
+		sb.WriteString(prob.Error())
+	}
+	return sb.String()
+}
+
+// Unwrap returns each aggregated Problem as an error.
+func (bp *BatchProblem) Unwrap() []error {
+	errs := make([]error, len(bp.Problems))
+	for i, prob := range bp.Problems {
+		errs[i] = prob
+	}
+	return errs
+}
+
+// Add adds each of the given problems to the Collector, ignoring any that are nil.
+//
+// Add is safe to be called concurrently.
+func (c *Collector) Add(probs ...*Problem) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, prob := range probs {
+		if prob != nil {
+			c.problems = append(c.problems, prob)
+		}
+	}
+}
+
+// Len returns the number of problems that have been added to the Collector.
+func (c *Collector) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.problems)
+}
+
+// Problems returns a copy of each Problem that has been added to the Collector, in the order in which they were added.
+func (c *Collector) Problems() []*Problem {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*Problem(nil), c.problems...)
+}
+
+// Resolve returns an error representing all problems that have been added to the Collector.
+//
+// If no problems have been added, nil is returned. If only a single Problem has been added, it is returned as-is.
+// Otherwise, a BatchProblem aggregating all problems is returned.
+//
+// For example;
+//
+//	var (
+//		c Collector
+//		g errgroup.Group
+//	)
+//	for _, id := range ids {
+//		id := id
+//		g.Go(func() error {
+//			if err := process(id); err != nil {
+//				c.Add(def.New(Wrap(err)))
+//			}
+//			return nil
+//		})
+//	}
+//	_ = g.Wait()
+//	if err := c.Resolve(); err != nil {
+//		// Handle error
+//	}
+func (c *Collector) Resolve() error {
+	probs := c.Problems()
+	switch len(probs) {
+	case 0:
+		return nil
+	case 1:
+		return probs[0]
+	default:
+		return &BatchProblem{Problems: probs}
+	}
+}
@@ -20,14 +20,29 @@
 
 package problem
 
-import "context"
+import (
+	"context"
+	"golang.org/x/text/language"
+)
 
 // contextKey is an internal type for managing key/value pairs within a context.Context without conflicting with other
 // packages.
 type contextKey uint
 
-// contextKeyGenerator is the key associated with a Generator within a context.Context.
-const contextKeyGenerator contextKey = 0
+const (
+	// contextKeyGenerator is the key associated with a Generator within a context.Context.
+	contextKeyGenerator contextKey = iota
+	// contextKeyLanguage is the key associated with a language.Tag within a context.Context.
+	contextKeyLanguage
+	// contextKeyProfile is the key associated with the name of a Profile within a context.Context.
+	contextKeyProfile
+	// contextKeyRouteValidation is the key associated with a routeValidation within a context.Context.
+	contextKeyRouteValidation
+	// contextKeyWriteGuard is the key associated with a write guard (i.e. *atomic.Bool) within a context.Context.
+	contextKeyWriteGuard
+	// contextKeyWriteOptions is the key associated with WriteOptions within a context.Context.
+	contextKeyWriteOptions
+)
 
 // GetGenerator returns the Generator within the given context.Context, otherwise DefaultGenerator.
 func GetGenerator(ctx context.Context) *Generator {
@@ -37,6 +52,33 @@ func GetGenerator(ctx context.Context) *Generator {
 	return DefaultGenerator
 }
 
+// GetLanguage returns the language.Tag within the given context.Context, if any.
+//
+// A Translator may use GetLanguage to determine the language in which a value is to be localized. See
+// Generator.LanguageFallbacks for more information.
+func GetLanguage(ctx context.Context) (language.Tag, bool) {
+	tag, ok := ctx.Value(contextKeyLanguage).(language.Tag)
+	return tag, ok
+}
+
+// GetProfile returns the name of the Profile within the given context.Context, if any.
+//
+// The name is used to resolve a Profile within Generator.Profiles whenever a Problem is written as an HTTP response
+// without WriteOptions.Profile being passed.
+func GetProfile(ctx context.Context) (string, bool) {
+	name, ok := ctx.Value(contextKeyProfile).(string)
+	return name, ok
+}
+
+// GetWriteOptions returns the WriteOptions within the given context.Context, if any.
+//
+// The WriteOptions are applied whenever a Problem is written as an HTTP response for an HTTP request bound to the
+// context.Context, before any WriteOptions passed explicitly. See RouteMiddleware for more information.
+func GetWriteOptions(ctx context.Context) (WriteOptions, bool) {
+	opts, ok := ctx.Value(contextKeyWriteOptions).(WriteOptions)
+	return opts, ok
+}
+
 // UsingGenerator returns a copy of the given parent context.Context containing the Generator provided.
 //
 // If gen is nil, DefaultGenerator is used.
@@ -46,3 +88,35 @@ func UsingGenerator(parent context.Context, gen *Generator) context.Context {
 	}
 	return context.WithValue(parent, contextKeyGenerator, gen)
 }
+
+// UsingLanguage returns a copy of the given parent context.Context containing the language.Tag provided, which can be
+// retrieved using GetLanguage.
+func UsingLanguage(parent context.Context, tag language.Tag) context.Context {
+	return context.WithValue(parent, contextKeyLanguage, tag)
+}
+
+// UsingProfile returns a copy of the given parent context.Context containing the name of the Profile provided, which
+// can be retrieved using GetProfile.
+//
+// For example;
+//
+//	func adminMiddleware(next http.Handler) http.Handler {
+//		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+//			next.ServeHTTP(w, req.WithContext(UsingProfile(req.Context(), "internal")))
+//		})
+//	}
+func UsingProfile(parent context.Context, name string) context.Context {
+	return context.WithValue(parent, contextKeyProfile, name)
+}
+
+// UsingWriteOptions returns a copy of the given parent context.Context containing the WriteOptions provided, which can
+// be retrieved using GetWriteOptions.
+//
+// If parent already contains WriteOptions, opts are merged into them, allowing WriteOptions to be layered (e.g. per
+// group of routes and then per route), where any non-zero field within opts takes precedence.
+func UsingWriteOptions(parent context.Context, opts WriteOptions) context.Context {
+	if existing, ok := GetWriteOptions(parent); ok {
+		opts = existing.merge(opts, nil)
+	}
+	return context.WithValue(parent, contextKeyWriteOptions, opts)
+}
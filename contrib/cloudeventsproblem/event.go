@@ -0,0 +1,102 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package cloudeventsproblem provides support for embedding a problem.Problem as the data of a CloudEvents event, and
+// extracting it, so that event-driven systems can publish failures uniformly.
+package cloudeventsproblem
+
+import (
+	"encoding/json"
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/neocotic/go-problem"
+)
+
+// Data returns the problem.Problem embedded as the data of the given event, if any.
+//
+// A nil problem.Problem and error are returned if the data content type of e is not problem.ContentTypeJSON (ignoring
+// any parameters) or e has no data. An error is only returned if the data could not be decoded.
+//
+// For example;
+//
+//	func receive(ctx context.Context, e event.Event) {
+//		prob, err := Data(e)
+//		if err != nil {
+//			// Handle malformed problem
+//		} else if prob != nil {
+//			// Handle problem
+//		}
+//	}
+func Data(e event.Event) (*problem.Problem, error) {
+	if e.DataMediaType() != problem.ContentTypeJSON {
+		return nil, nil
+	}
+	data := e.Data()
+	if len(data) == 0 {
+		return nil, nil
+	}
+	var prob problem.Problem
+	if err := json.Unmarshal(data, &prob); err != nil {
+		return nil, err
+	}
+	return &prob, nil
+}
+
+// NewEvent returns a new event with the given source and type containing the problem.Problem provided as its data. See
+// SetData for more information.
+//
+// For example;
+//
+//	e, err := NewEvent("/orders", "com.example.order.failed", prob)
+//	if err != nil {
+//		// Handle error
+//	}
+//	client.Send(ctx, e)
+func NewEvent(source, eventType string, prob *problem.Problem) (event.Event, error) {
+	e := event.New()
+	e.SetSource(source)
+	e.SetType(eventType)
+	if err := SetData(&e, prob); err != nil {
+		return event.Event{}, err
+	}
+	return e, nil
+}
+
+// SetData encodes the given problem.Problem as JSON and sets it as the data of the event provided, along with a data
+// content type of problem.ContentTypeJSON.
+//
+// Unlike event.Event.SetData when given bytes, the encoded problem.Problem is never marked as base64-encoded, ensuring
+// that it is sent as-is (e.g. as the body of a binary mode HTTP message) and remains readable by any consumer.
+//
+// If prob is nil, any existing data on e is removed.
+func SetData(e *event.Event, prob *problem.Problem) error {
+	if prob == nil {
+		e.DataEncoded = nil
+		e.DataBase64 = false
+		return nil
+	}
+	data, err := json.Marshal(prob)
+	if err != nil {
+		return err
+	}
+	e.SetDataContentType(problem.ContentTypeJSON)
+	e.DataEncoded = data
+	e.DataBase64 = false
+	return nil
+}
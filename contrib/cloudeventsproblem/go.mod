@@ -0,0 +1,22 @@
+module github.com/neocotic/go-problem/contrib/cloudeventsproblem
+
+go 1.21
+
+require (
+	github.com/cloudevents/sdk-go/v2 v2.15.2
+	github.com/neocotic/go-problem v0.0.0
+)
+
+require (
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/json-iterator/go v1.1.12 // indirect
+	github.com/modern-go/concurrent v0.0.0-20180306012644-bacd9c7ef1dd // indirect
+	github.com/modern-go/reflect2 v1.0.2 // indirect
+	github.com/neocotic/go-optional v0.1.2 // indirect
+	go.uber.org/multierr v1.10.0 // indirect
+	go.uber.org/zap v1.27.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/neocotic/go-problem => ../..
@@ -0,0 +1,127 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package echoproblem provides support for writing a problem.Problem as the HTTP response for any error returned by an
+// Echo handler, so that Echo applications need not translate errors (including echo.HTTPError) themselves.
+package echoproblem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"github.com/neocotic/go-problem"
+	problemhttp "github.com/neocotic/go-problem/http"
+	"net/http"
+)
+
+// HTTPErrorHandler returns an echo.HTTPErrorHandler that writes a problem.Problem for any error returned by an Echo
+// handler using problem.Generator.WriteProblem, optionally using problem.WriteOptions for more granular control.
+//
+// If gen is nil, the problem.Generator within the HTTP request's context.Context is used, if any, otherwise
+// problem.DefaultGenerator.
+//
+// The problem.Problem written for err is resolved as follows:
+//
+//   - A problem.Problem within err's tree is written as-is
+//   - An echo.HTTPError (preferring any echo.HTTPError within echo.HTTPError.Internal, as Echo does) is mapped to the
+//     problem.Definition for its status code using problemhttp.StatusDefinition, where known, with any message that
+//     differs from the status text used as the detail
+//   - Any other error is handled by problem.Generator.WriteError (e.g. using
+//     problem.Generator.DefaultProblemFactory)
+//
+// Nothing is written if the HTTP response has already been committed. Any error that occurs while writing the HTTP
+// response is logged using echo.Context.Logger.
+//
+// For example;
+//
+//	e := echo.New()
+//	e.HTTPErrorHandler = HTTPErrorHandler(nil)
+func HTTPErrorHandler(gen *problem.Generator, opts ...problem.WriteOptions) echo.HTTPErrorHandler {
+	return func(err error, c echo.Context) {
+		res := c.Response()
+		if res.Committed {
+			return
+		}
+		req := c.Request()
+		g := gen
+		if g == nil {
+			g = problem.GetGenerator(req.Context())
+		}
+		var writeErr error
+		if prob := FromHTTPError(req.Context(), g, err); prob != nil {
+			writeErr = g.WriteProblem(prob, res, req, opts...)
+		} else {
+			writeErr = g.WriteError(err, res, req, nil, opts...)
+		}
+		if writeErr != nil {
+			c.Logger().Error(writeErr)
+		}
+	}
+}
+
+// FromHTTPError returns a problem.Problem generated by the given problem.Generator for the echo.HTTPError within err's
+// tree, if any, otherwise nil. If err's tree contains a problem.Problem before any echo.HTTPError, it is returned
+// instead.
+//
+// If the echo.HTTPError contains another echo.HTTPError within echo.HTTPError.Internal, the latter is used, as Echo
+// does. The problem.Problem is generated from the problem.Definition for its status code using
+// problemhttp.StatusDefinition, where known, and wraps err. Any message of the echo.HTTPError that differs from the
+// status text is used as the detail.
+//
+// For example;
+//
+//	FromHTTPError(ctx, gen, echo.NewHTTPError(http.StatusNotFound, "user not found"))  // &problem.Problem{Detail: "user not found", Status: 404, Title: "Not Found", ...}
+func FromHTTPError(ctx context.Context, gen *problem.Generator, err error) *problem.Problem {
+	if prob, isProblem := problem.As(err); isProblem {
+		return prob
+	}
+	var he *echo.HTTPError
+	if !errors.As(err, &he) {
+		return nil
+	}
+	if internal, ok := he.Internal.(*echo.HTTPError); ok {
+		he = internal
+	}
+	code := he.Code
+	if code == 0 {
+		code = http.StatusInternalServerError
+	}
+	def := problemhttp.StatusDefinitionOrElse(code, problem.Definition{Type: problem.Type{Status: code}})
+	opts := []problem.Option{problem.FromDefinition(def), problem.Wrap(err)}
+	if detail := message(he); detail != "" && detail != http.StatusText(code) {
+		opts = append(opts, problem.WithDetail(detail))
+	}
+	return gen.NewContext(ctx, opts...)
+}
+
+// message returns the message of the given echo.HTTPError as a string, if any.
+func message(he *echo.HTTPError) string {
+	switch m := he.Message.(type) {
+	case nil:
+		return ""
+	case string:
+		return m
+	case error:
+		return m.Error()
+	default:
+		return fmt.Sprint(m)
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package fiberproblem provides support for writing a problem.Problem as the HTTP response for any error returned by,
+// or panic recovered from, a Fiber handler. Since Fiber is not built on net/http, the middleware functions provided by
+// the problem package cannot be used with Fiber directly.
+//
+// As with all other integrations, fiberproblem is a separate module so that its dependencies are only required by those
+// using Fiber.
+package fiberproblem
+
+import (
+	"context"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/neocotic/go-problem"
+	problemhttp "github.com/neocotic/go-problem/http"
+	"net/http"
+)
+
+// panicLogMessage is the log message used when writing a problem.Problem for a value recovered from a panic, unless
+// another is passed within problem.MiddlewareOptions.
+const panicLogMessage = "A panic recovery has occurred"
+
+// ErrorHandler returns a fiber.ErrorHandler that writes a problem.Problem for any error returned by a Fiber handler
+// using problem.Generator.WriteProblem, optionally using problem.WriteOptions for more granular control. As such, the
+// Content-Type of the HTTP response is determined in the same way as it would be for net/http (e.g. using
+// problem.Generator.ContentType or problem.Generator.NegotiateContentType).
+//
+// If gen is nil, the problem.Generator within the user context of the fiber.Ctx is used, if any (see Middleware),
+// otherwise problem.DefaultGenerator.
+//
+// The problem.Problem written for err is resolved as follows:
+//
+//   - A problem.Problem within err's tree is written as-is
+//   - A fiber.Error is mapped to the problem.Definition for its status code using problemhttp.StatusDefinition, where
+//     known, with any message that differs from the status text used as the detail
+//   - Any other error is handled by problem.Generator.WriteError (e.g. using
+//     problem.Generator.DefaultProblemFactory)
+//
+// For example;
+//
+//	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler(nil)})
+//	app.Use(Middleware(nil, nil))
+func ErrorHandler(gen *problem.Generator, opts ...problem.WriteOptions) fiber.ErrorHandler {
+	return func(c *fiber.Ctx, err error) error {
+		g := gen
+		if g == nil {
+			g = problem.GetGenerator(c.UserContext())
+		}
+		prob := FromError(c.UserContext(), g, err)
+		return write(c, func(w http.ResponseWriter, req *http.Request) error {
+			if prob != nil {
+				return g.WriteProblem(prob, w, req, opts...)
+			}
+			return g.WriteError(err, w, req, nil, opts...)
+		})
+	}
+}
+
+// FromError returns a problem.Problem generated by the given problem.Generator for the fiber.Error within err's tree,
+// if any, otherwise nil. If err's tree contains a problem.Problem before any fiber.Error, it is returned instead.
+//
+// The problem.Problem is generated from the problem.Definition for the status code of the fiber.Error using
+// problemhttp.StatusDefinition, where known, and wraps err. Any message of the fiber.Error that differs from the status
+// text is used as the detail.
+//
+// For example;
+//
+//	FromError(ctx, gen, fiber.NewError(fiber.StatusNotFound, "user not found"))  // &problem.Problem{Detail: "user not found", Status: 404, Title: "Not Found", ...}
+func FromError(ctx context.Context, gen *problem.Generator, err error) *problem.Problem {
+	if prob, isProblem := problem.As(err); isProblem {
+		return prob
+	}
+	var fe *fiber.Error
+	if !errors.As(err, &fe) {
+		return nil
+	}
+	code := fe.Code
+	if code == 0 {
+		code = http.StatusInternalServerError
+	}
+	def := problemhttp.StatusDefinitionOrElse(code, problem.Definition{Type: problem.Type{Status: code}})
+	opts := []problem.Option{problem.FromDefinition(def), problem.Wrap(err)}
+	if fe.Message != "" && fe.Message != http.StatusText(code) {
+		opts = append(opts, problem.WithDetail(fe.Message))
+	}
+	return gen.NewContext(ctx, opts...)
+}
+
+// Middleware returns a Fiber middleware handler that is responsible for populating the user context of the fiber.Ctx
+// with the given problem.Generator (which can be retrieved using problem.GetGenerator) and also provides panic
+// recovery, allowing a problem.Problem to be written for any value recovered from a panic.
+//
+// If gen is nil, problem.DefaultGenerator is used.
+//
+// If a value recovered from a panic is not a problem.Problem, probFunc is called with an error representation of that
+// value (i.e. a problem.ValueError if not already an error) to be used to construct a problem.Problem. If probFunc is
+// nil, problem.Generator.DefaultProblemFactory is used instead. Any recovered value matched by
+// problem.MiddlewareOptions.Repanic is propagated instead.
+//
+// The user context is also guarded so that an HTTP response is only ever written for a single problem.Problem per
+// HTTP request (see problem.GuardWrites). This is the Fiber equivalent of problem.MiddlewareUsing.
+func Middleware(gen *problem.Generator, probFunc func(err error) *problem.Problem, opts ...problem.MiddlewareOptions) fiber.Handler {
+	if gen == nil {
+		gen = problem.DefaultGenerator
+	}
+	var mwOpts problem.MiddlewareOptions
+	if len(opts) > 0 {
+		mwOpts = opts[0]
+	}
+	if mwOpts.LogMessage == "" {
+		mwOpts.LogMessage = panicLogMessage
+	}
+	return func(c *fiber.Ctx) (err error) {
+		c.SetUserContext(problem.GuardWrites(problem.UsingGenerator(c.UserContext(), gen)))
+
+		defer func() {
+			if r := recover(); r != nil {
+				if repanic := mwOpts.Repanic; repanic != nil && repanic(r) {
+					panic(r)
+				}
+				err = write(c, func(w http.ResponseWriter, req *http.Request) error {
+					return gen.WriteError(valueAsError(r), w, req, probFunc, mwOpts.WriteOptions)
+				})
+			}
+		}()
+
+		return c.Next()
+	}
+}
+
+// valueAsError returns the given value as an error, using a problem.ValueError if v is not already an error.
+func valueAsError(v any) error {
+	if err, ok := v.(error); ok {
+		return err
+	}
+	return &problem.ValueError{Value: v}
+}
+
+// write calls the given function with an http.ResponseWriter and http.Request adapted from the fiber.Ctx provided,
+// using its user context, so that a problem.Problem can be written using the net/http support of problem.Generator,
+// returning any error returned by fn.
+func write(c *fiber.Ctx, fn func(w http.ResponseWriter, req *http.Request) error) error {
+	var err error
+	handler := adaptor.HTTPHandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		err = fn(w, req.WithContext(c.UserContext()))
+	})
+	if handlerErr := handler(c); handlerErr != nil {
+		return handlerErr
+	}
+	return err
+}
@@ -0,0 +1,19 @@
+module github.com/neocotic/go-problem/contrib/gqlgenproblem
+
+go 1.21
+
+require (
+	github.com/neocotic/go-problem v0.0.0
+	github.com/vektah/gqlparser/v2 v2.5.16
+)
+
+require (
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/neocotic/go-optional v0.1.2 // indirect
+	go.uber.org/multierr v1.10.0 // indirect
+	go.uber.org/zap v1.27.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/neocotic/go-problem => ../..
@@ -0,0 +1,170 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package gqlgenproblem provides support for presenting a problem.Problem as a GraphQL error within gqlgen, and for
+// recovering from panics within resolvers using a problem.Problem, so that GraphQL APIs can report failures
+// consistently with any HTTP APIs.
+//
+// The functions returned by this package are compatible with graphql.ErrorPresenterFunc and graphql.RecoverFunc, and so
+// can be passed directly to handler.Server.SetErrorPresenter and handler.Server.SetRecoverFunc respectively, without
+// this package depending on gqlgen itself.
+package gqlgenproblem
+
+import (
+	"context"
+	"github.com/neocotic/go-problem"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const (
+	// ExtensionCode is the key of the GraphQL error extension containing problem.Problem.Code, where present.
+	ExtensionCode = "code"
+	// ExtensionStatus is the key of the GraphQL error extension containing problem.Problem.Status.
+	ExtensionStatus = "status"
+	// ExtensionType is the key of the GraphQL error extension containing problem.Problem.Type.
+	ExtensionType = "type"
+	// ExtensionUUID is the key of the GraphQL error extension containing problem.Problem.UUID, where present.
+	ExtensionUUID = "uuid"
+)
+
+// defaultLogMessage is the message passed to problem.Generator.LogContext along with a problem.Problem presented as a
+// GraphQL error, unless overridden by PresenterOptions.LogMessage.
+const defaultLogMessage = "Problem occurred during GraphQL operation"
+
+// PresenterOptions contains options that can be used to customize the behaviour of ErrorPresenter.
+//
+// All fields are optional with default behaviour clearly documented.
+type PresenterOptions struct {
+	// LogArgs contains arguments to be passed to problem.Generator.LogContext along with the problem.Problem.
+	//
+	// If empty, no additional arguments will be passed.
+	LogArgs []any
+	// LogDisabled is whether the problem.Problem should be logged via problem.Generator.LogContext.
+	//
+	// By default, the problem.Problem will be logged unless the resolved problem.LogLevel for the problem.Problem is
+	// disabled for problem.Generator.Logger.
+	LogDisabled bool
+	// LogMessage is the message to be passed to problem.Generator.LogContext along with the problem.Problem.
+	//
+	// If empty, a basic message will be passed.
+	LogMessage string
+}
+
+// ErrorPresenter returns a function, compatible with graphql.ErrorPresenterFunc, that presents any problem.Problem
+// within the tree of an error as a GraphQL error, optionally using PresenterOptions for more granular control.
+//
+// next is called first to present err as a GraphQL error (e.g. graphql.DefaultErrorPresenter, which also populates the
+// path of the GraphQL error), falling back on gqlerror.WrapIfUnwrapped if nil. If err's tree contains a
+// problem.Problem, the message of the GraphQL error is replaced with problem.Problem.Detail, falling back on
+// problem.Problem.Title, and its extensions are populated with problem.Problem.Type, problem.Problem.Status,
+// problem.Problem.Code, and problem.Problem.UUID (see ExtensionType, ExtensionStatus, ExtensionCode, and
+// ExtensionUUID), where present. Otherwise, the GraphQL error is returned as-is.
+//
+// Only fields visible on the problem.Problem are included within the GraphQL error, while the problem.Problem itself is
+// logged using problem.Generator.LogContext, so that information that is only visible within logs (e.g. a stack trace
+// or UUID captured with problem.FlagLog) remains server-side.
+//
+// If gen is nil, the problem.Generator within the context.Context is used, if any, otherwise
+// problem.DefaultGenerator.
+//
+// For example;
+//
+//	srv := handler.NewDefaultServer(graph.NewExecutableSchema(cfg))
+//	srv.SetErrorPresenter(ErrorPresenter(nil, graphql.DefaultErrorPresenter))
+//	srv.SetRecoverFunc(RecoverFunc(nil, nil))
+func ErrorPresenter(gen *problem.Generator, next func(ctx context.Context, err error) *gqlerror.Error, opts ...PresenterOptions) func(ctx context.Context, err error) *gqlerror.Error {
+	var pOpts PresenterOptions
+	if len(opts) > 0 {
+		pOpts = opts[0]
+	}
+	if pOpts.LogMessage == "" {
+		pOpts.LogMessage = defaultLogMessage
+	}
+	return func(ctx context.Context, err error) *gqlerror.Error {
+		var gqlErr *gqlerror.Error
+		if next != nil {
+			gqlErr = next(ctx, err)
+		} else {
+			gqlErr = gqlerror.WrapIfUnwrapped(err)
+		}
+		prob, isProblem := problem.As(err)
+		if !isProblem || prob == nil || gqlErr == nil {
+			return gqlErr
+		}
+		g := gen
+		if g == nil {
+			g = problem.GetGenerator(ctx)
+		}
+		if !pOpts.LogDisabled {
+			g.LogContext(ctx, pOpts.LogMessage, prob, pOpts.LogArgs...)
+		}
+		gqlErr.Message = prob.Detail
+		if gqlErr.Message == "" {
+			gqlErr.Message = prob.Title
+		}
+		if gqlErr.Extensions == nil {
+			gqlErr.Extensions = make(map[string]any, 4)
+		}
+		gqlErr.Extensions[ExtensionType] = prob.Type
+		gqlErr.Extensions[ExtensionStatus] = prob.Status
+		if prob.Code != "" {
+			gqlErr.Extensions[ExtensionCode] = string(prob.Code)
+		}
+		if prob.UUID != "" {
+			gqlErr.Extensions[ExtensionUUID] = prob.UUID
+		}
+		return gqlErr
+	}
+}
+
+// RecoverFunc returns a function, compatible with graphql.RecoverFunc, that returns a problem.Problem for any value
+// recovered from a panic within a resolver, which gqlgen then passes to its error presenter (e.g. ErrorPresenter).
+//
+// If a value recovered from a panic is not a problem.Problem (which is highly likely), probFunc is called with an error
+// representation of that value (i.e. a problem.ValueError if not already an error) to be used to construct a
+// problem.Problem. If probFunc is nil or returns nil, problem.Generator.DefaultProblemFactory is used instead, where
+// present, otherwise the error is simply wrapped by a problem.Problem.
+//
+// If gen is nil, the problem.Generator within the context.Context is used, if any, otherwise
+// problem.DefaultGenerator.
+func RecoverFunc(gen *problem.Generator, probFunc func(err error) *problem.Problem) func(ctx context.Context, v any) error {
+	return func(ctx context.Context, v any) error {
+		err, ok := v.(error)
+		if !ok {
+			err = &problem.ValueError{Value: v}
+		}
+		if prob, isProblem := problem.As(err); isProblem && prob != nil {
+			return prob
+		}
+		if probFunc != nil {
+			if prob := probFunc(err); prob != nil {
+				return prob
+			}
+		}
+		g := gen
+		if g == nil {
+			g = problem.GetGenerator(ctx)
+		}
+		if f := g.DefaultProblemFactory; f != nil {
+			return f(err)
+		}
+		return g.NewContext(ctx, problem.Wrap(err))
+	}
+}
@@ -0,0 +1,374 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package grpcproblem provides support for converting between a problem.Problem and a gRPC status.Status, which can be
+// useful for services that expose both HTTP and gRPC APIs and want to report failures consistently across both.
+package grpcproblem
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/neocotic/go-problem"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"net/http"
+	"strconv"
+)
+
+// ExtensionFieldViolations is the key of the extension containing the field violations of a bad request (i.e.
+// errdetails.BadRequest.FieldViolations).
+//
+// When converting a problem.Problem to a status.Status, its value can be either a []FieldViolation or a slice of maps
+// (e.g. when decoded from JSON) containing "field" and "description" keys.
+const ExtensionFieldViolations = "fieldViolations"
+
+const (
+	// metadataInstance is the key of the errdetails.ErrorInfo metadata containing problem.Problem.Instance.
+	metadataInstance = "instance"
+	// metadataStatus is the key of the errdetails.ErrorInfo metadata containing problem.Problem.Status.
+	metadataStatus = "status"
+	// metadataTitle is the key of the errdetails.ErrorInfo metadata containing problem.Problem.Title.
+	metadataTitle = "title"
+	// metadataUUID is the key of the errdetails.ErrorInfo metadata containing problem.Problem.UUID.
+	metadataUUID = "uuid"
+)
+
+// FieldViolation describes a single field within a bad request that failed validation.
+type FieldViolation struct {
+	// Description is a human-readable description of why the field is invalid.
+	Description string `json:"description" xml:"description"`
+	// Field is the path to the invalid field (e.g. "user.email").
+	Field string `json:"field" xml:"field"`
+}
+
+// CodeForStatus returns the gRPC codes.Code that is most appropriate for the given HTTP status code, mirroring the
+// mapping used by Google APIs, or codes.Unknown if there is none.
+//
+// For example;
+//
+//	CodeForStatus(http.StatusNotFound)            // codes.NotFound
+//	CodeForStatus(http.StatusServiceUnavailable)  // codes.Unavailable
+//	CodeForStatus(http.StatusTeapot)              // codes.Unknown
+func CodeForStatus(status int) codes.Code {
+	switch status {
+	case http.StatusOK:
+		return codes.OK
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusPreconditionFailed:
+		return codes.FailedPrecondition
+	case http.StatusRequestedRangeNotSatisfiable:
+		return codes.OutOfRange
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case problem.StatusClientClosedRequest:
+		return codes.Canceled
+	case http.StatusInternalServerError:
+		return codes.Internal
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	default:
+		return codes.Unknown
+	}
+}
+
+// FromStatus returns a problem.Problem converted from the given status.Status, or nil if st is nil or represents
+// success (i.e. codes.OK).
+//
+// Any errdetails.ErrorInfo within the details of st is used to populate problem.Problem.Code (from
+// errdetails.ErrorInfo.Reason), problem.Problem.Type (from errdetails.ErrorInfo.Domain), and, from its metadata,
+// problem.Problem.Instance, problem.Problem.Status, problem.Problem.Title, problem.Problem.UUID, and any extensions.
+// Extension values are always contained as strings since that is how they are carried by the metadata. Any
+// errdetails.BadRequest within the details of st is contained within the extensions of the problem.Problem (see
+// ExtensionFieldViolations).
+//
+// Where not otherwise provided, problem.Problem.Status is derived from the gRPC code of st using StatusForCode,
+// problem.Problem.Title is derived from the status code, and problem.Problem.Type is problem.DefaultTypeURI. The
+// message of st is used as problem.Problem.Detail unless it's the same as problem.Problem.Title.
+//
+// Since the problem.Problem is converted rather than generated, it is not logged and contains no stack trace.
+//
+// For example;
+//
+//	st := status.New(codes.NotFound, "user not found")
+//	FromStatus(st)  // &problem.Problem{Detail: "user not found", Status: 404, Title: "Not Found", Type: "about:blank"}
+func FromStatus(st *status.Status) *problem.Problem {
+	if st == nil || st.Code() == codes.OK {
+		return nil
+	}
+	prob := &problem.Problem{
+		Detail: st.Message(),
+		Status: StatusForCode(st.Code()),
+		Type:   problem.DefaultTypeURI,
+	}
+	extensions := make(problem.Extensions)
+	for _, detail := range st.Details() {
+		switch d := detail.(type) {
+		case *errdetails.BadRequest:
+			violations := make([]FieldViolation, 0, len(d.GetFieldViolations()))
+			for _, v := range d.GetFieldViolations() {
+				violations = append(violations, FieldViolation{Description: v.GetDescription(), Field: v.GetField()})
+			}
+			if len(violations) > 0 {
+				extensions[ExtensionFieldViolations] = violations
+			}
+		case *errdetails.ErrorInfo:
+			prob.Code = problem.Code(d.GetReason())
+			if d.GetDomain() != "" {
+				prob.Type = d.GetDomain()
+			}
+			for k, v := range d.GetMetadata() {
+				switch k {
+				case metadataInstance:
+					prob.Instance = v
+				case metadataStatus:
+					if code, err := strconv.Atoi(v); err == nil && code > 0 {
+						prob.Status = code
+					}
+				case metadataTitle:
+					prob.Title = v
+				case metadataUUID:
+					prob.UUID = v
+				default:
+					if k != "" && !isReserved(k) {
+						extensions[k] = v
+					}
+				}
+			}
+		}
+	}
+	if prob.Title == "" {
+		prob.Title = http.StatusText(prob.Status)
+	}
+	if prob.Title == "" {
+		prob.Title = problem.DefaultTitle
+	}
+	if prob.Detail == prob.Title {
+		prob.Detail = ""
+	}
+	if len(extensions) > 0 {
+		prob.Extensions = extensions
+	}
+	return prob
+}
+
+// StatusForCode returns the HTTP status code that is most appropriate for the given gRPC codes.Code, mirroring the
+// mapping used by Google APIs, or http.StatusInternalServerError if there is none.
+//
+// For example;
+//
+//	StatusForCode(codes.NotFound)          // http.StatusNotFound
+//	StatusForCode(codes.ResourceExhausted) // http.StatusTooManyRequests
+//	StatusForCode(codes.DataLoss)          // http.StatusInternalServerError
+func StatusForCode(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return problem.StatusClientClosedRequest
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// ToStatus returns a status.Status converted from the given problem.Problem, or nil if prob is nil.
+//
+// The gRPC code of the returned status.Status is derived from problem.Problem.Status using CodeForStatus, falling back
+// on codes.Unknown where it would otherwise represent success, and its message is populated using
+// problem.Problem.Detail, falling back on problem.Problem.Title.
+//
+// The details of the returned status.Status contain an errdetails.ErrorInfo whose reason and domain are populated using
+// problem.Problem.Code and problem.Problem.Type respectively, and whose metadata contains problem.Problem.Instance,
+// problem.Problem.Status, problem.Problem.Title, problem.Problem.UUID, and all extensions of prob, where present. Any
+// extension whose key conflicts with the metadata of one of those fields is ignored so that it can never override it.
+// String extension values are used as-is while all others are encoded as JSON. Any field violations contained within
+// the extensions of prob (see ExtensionFieldViolations) are instead used to populate an errdetails.BadRequest.
+//
+// For example;
+//
+//	prob := problemhttp.NotFoundDefinition.New(problem.WithDetail("user not found"))
+//	st := ToStatus(prob)
+//	st.Code()     // codes.NotFound
+//	st.Message()  // "user not found"
+//	return st.Err()
+func ToStatus(prob *problem.Problem) *status.Status {
+	if prob == nil {
+		return nil
+	}
+	code := CodeForStatus(prob.Status)
+	if code == codes.OK {
+		code = codes.Unknown
+	}
+	message := prob.Detail
+	if message == "" {
+		message = prob.Title
+	}
+	st := status.New(code, message)
+	info := &errdetails.ErrorInfo{
+		Domain:   prob.Type,
+		Metadata: make(map[string]string),
+		Reason:   string(prob.Code),
+	}
+	putMetadata(info.Metadata, metadataInstance, prob.Instance)
+	if prob.Status > 0 {
+		info.Metadata[metadataStatus] = strconv.Itoa(prob.Status)
+	}
+	putMetadata(info.Metadata, metadataTitle, prob.Title)
+	putMetadata(info.Metadata, metadataUUID, prob.UUID)
+	var badRequest *errdetails.BadRequest
+	prob.RangeExtensions(func(key string, value any) bool {
+		if key == ExtensionFieldViolations {
+			if violations := fieldViolations(value); len(violations) > 0 {
+				badRequest = &errdetails.BadRequest{FieldViolations: violations}
+				return true
+			}
+		}
+		if !isReservedMetadata(key) {
+			putMetadata(info.Metadata, key, metadataValue(value))
+		}
+		return true
+	})
+	var err error
+	var withDetails *status.Status
+	if badRequest != nil {
+		withDetails, err = st.WithDetails(info, badRequest)
+	} else {
+		withDetails, err = st.WithDetails(info)
+	}
+	if err != nil {
+		return st
+	}
+	return withDetails
+}
+
+// fieldViolation returns a errdetails.BadRequest_FieldViolation populated using the "field" and "description" keys
+// within the given map, where present.
+func fieldViolation(m map[string]any) *errdetails.BadRequest_FieldViolation {
+	var violation errdetails.BadRequest_FieldViolation
+	violation.Field, _ = m["field"].(string)
+	violation.Description, _ = m["description"].(string)
+	return &violation
+}
+
+// fieldViolations returns the field violations contained within the given extension value (see
+// ExtensionFieldViolations), if any.
+func fieldViolations(value any) []*errdetails.BadRequest_FieldViolation {
+	switch values := value.(type) {
+	case []FieldViolation:
+		violations := make([]*errdetails.BadRequest_FieldViolation, 0, len(values))
+		for _, v := range values {
+			violations = append(violations, &errdetails.BadRequest_FieldViolation{
+				Description: v.Description,
+				Field:       v.Field,
+			})
+		}
+		return violations
+	case []map[string]any:
+		violations := make([]*errdetails.BadRequest_FieldViolation, 0, len(values))
+		for _, m := range values {
+			violations = append(violations, fieldViolation(m))
+		}
+		return violations
+	case []any:
+		violations := make([]*errdetails.BadRequest_FieldViolation, 0, len(values))
+		for _, v := range values {
+			if m, ok := v.(map[string]any); ok {
+				violations = append(violations, fieldViolation(m))
+			}
+		}
+		return violations
+	default:
+		return nil
+	}
+}
+
+// isReserved returns whether the given metadata key conflicts with a problem.Problem-level field and therefore cannot
+// be used as an extension key.
+func isReserved(key string) bool {
+	switch key {
+	case "code", "detail", "extensions", "stack", "type":
+		return true
+	default:
+		return false
+	}
+}
+
+// isReservedMetadata returns whether the given metadata key is used to contain a problem.Problem-level field within the
+// metadata of an errdetails.ErrorInfo and therefore cannot be used to contain an extension.
+func isReservedMetadata(key string) bool {
+	switch key {
+	case metadataInstance, metadataStatus, metadataTitle, metadataUUID:
+		return true
+	default:
+		return false
+	}
+}
+
+// metadataValue returns the string representation of the given extension value for use within the metadata of an
+// errdetails.ErrorInfo.
+//
+// Strings are returned as-is while all other values are encoded as JSON, falling back on fmt.Sprint if that fails.
+func metadataValue(value any) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	if b, err := json.Marshal(value); err == nil {
+		return string(b)
+	}
+	return fmt.Sprint(value)
+}
+
+// putMetadata puts the given value into metadata using the key provided, but only if value is not empty.
+func putMetadata(metadata map[string]string, key, value string) {
+	if value != "" {
+		metadata[key] = value
+	}
+}
@@ -0,0 +1,263 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package k8sproblem provides support for converting between a problem.Problem and a Kubernetes metav1.Status, which
+// can be useful for operators and aggregated API servers that must speak both formats.
+package k8sproblem
+
+import (
+	"github.com/neocotic/go-problem"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"net/http"
+	"time"
+)
+
+const (
+	// ExtensionCauses is the key of the extension containing the causes of a metav1.Status (i.e.
+	// metav1.StatusDetails.Causes).
+	//
+	// When converting a problem.Problem to a metav1.Status, its value can be either a []metav1.StatusCause or a slice of
+	// maps (e.g. when decoded from JSON) containing "reason", "message", and "field" keys.
+	ExtensionCauses = "causes"
+	// ExtensionGroup is the key of the extension containing the group of the resource associated with a metav1.Status
+	// (i.e. metav1.StatusDetails.Group).
+	ExtensionGroup = "group"
+	// ExtensionKind is the key of the extension containing the kind of the resource associated with a metav1.Status
+	// (i.e. metav1.StatusDetails.Kind).
+	ExtensionKind = "kind"
+	// ExtensionName is the key of the extension containing the name of the resource associated with a metav1.Status
+	// (i.e. metav1.StatusDetails.Name).
+	ExtensionName = "name"
+	// ExtensionReason is the key of the extension containing the machine-readable reason of a metav1.Status (i.e.
+	// metav1.Status.Reason).
+	ExtensionReason = "reason"
+)
+
+// FromStatus returns a problem.Problem converted from the given metav1.Status, or nil if status is nil.
+//
+// metav1.Status.Code and metav1.Status.Message are used to populate problem.Problem.Status and problem.Problem.Detail
+// respectively, falling back on http.StatusInternalServerError if metav1.Status.Code is zero, while
+// problem.Problem.Title is derived from the status code. Any reason, resource, causes, and retry advice are contained
+// within the extensions of the problem.Problem (see ExtensionReason, ExtensionGroup, ExtensionKind, ExtensionName,
+// ExtensionCauses, and problem.ExtensionRetryAfter).
+//
+// Since the problem.Problem is converted rather than generated, it is not logged and contains no stack trace or UUID.
+//
+// For example;
+//
+//	status := &metav1.Status{Code: 404, Message: `pods "web" not found`, Reason: metav1.StatusReasonNotFound}
+//	FromStatus(status)  // &problem.Problem{Detail: `pods "web" not found`, Extensions: {"reason": "NotFound"}, Status: 404, Title: "Not Found", Type: "about:blank"}
+func FromStatus(status *metav1.Status) *problem.Problem {
+	if status == nil {
+		return nil
+	}
+	code := int(status.Code)
+	if code == 0 {
+		code = http.StatusInternalServerError
+	}
+	title := http.StatusText(code)
+	if title == "" {
+		title = problem.DefaultTitle
+	}
+	extensions := make(problem.Extensions)
+	if status.Reason != metav1.StatusReasonUnknown {
+		extensions[ExtensionReason] = string(status.Reason)
+	}
+	if d := status.Details; d != nil {
+		putExtension(extensions, ExtensionGroup, d.Group)
+		putExtension(extensions, ExtensionKind, d.Kind)
+		putExtension(extensions, ExtensionName, d.Name)
+		if len(d.Causes) > 0 {
+			extensions[ExtensionCauses] = append([]metav1.StatusCause(nil), d.Causes...)
+		}
+		if d.RetryAfterSeconds > 0 {
+			extensions[problem.ExtensionRetryAfter] = int(d.RetryAfterSeconds)
+		}
+	}
+	prob := &problem.Problem{
+		Detail: status.Message,
+		Status: code,
+		Title:  title,
+		Type:   problem.DefaultTypeURI,
+	}
+	if len(extensions) > 0 {
+		prob.Extensions = extensions
+	}
+	return prob
+}
+
+// ToStatus returns a metav1.Status converted from the given problem.Problem, or nil if prob is nil.
+//
+// The returned metav1.Status always represents a failure. problem.Problem.Status is used to populate
+// metav1.Status.Code and metav1.Status.Message is populated using problem.Problem.Detail, falling back on
+// problem.Problem.Title. metav1.Status.Reason is populated using ExtensionReason, where present, otherwise it is
+// derived from the status code. Any resource, causes, and retry advice contained within the extensions of prob (see
+// ExtensionGroup, ExtensionKind, ExtensionName, ExtensionCauses, and problem.ExtensionRetryAfter) are used to populate
+// metav1.Status.Details.
+//
+// For example;
+//
+//	prob := problemhttp.NotFoundDefinition.New(problem.WithDetail(`pods "web" not found`))
+//	ToStatus(prob)  // &metav1.Status{Code: 404, Message: `pods "web" not found`, Reason: "NotFound", Status: "Failure"}
+func ToStatus(prob *problem.Problem) *metav1.Status {
+	if prob == nil {
+		return nil
+	}
+	message := prob.Detail
+	if message == "" {
+		message = prob.Title
+	}
+	status := &metav1.Status{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Status"},
+		Code:     int32(prob.Status),
+		Message:  message,
+		Reason:   reasonForStatus(prob.Status),
+		Status:   metav1.StatusFailure,
+	}
+	if reason, ok := stringExtension(prob, ExtensionReason); ok {
+		status.Reason = metav1.StatusReason(reason)
+	}
+	details := &metav1.StatusDetails{Causes: causes(prob), RetryAfterSeconds: retryAfterSeconds(prob)}
+	details.Group, _ = stringExtension(prob, ExtensionGroup)
+	details.Kind, _ = stringExtension(prob, ExtensionKind)
+	details.Name, _ = stringExtension(prob, ExtensionName)
+	if details.Group != "" || details.Kind != "" || details.Name != "" || len(details.Causes) > 0 ||
+		details.RetryAfterSeconds > 0 {
+		status.Details = details
+	}
+	return status
+}
+
+// causes returns the causes contained within the given problem.Problem (see ExtensionCauses), if any.
+func causes(prob *problem.Problem) []metav1.StatusCause {
+	v, found := prob.Extension(ExtensionCauses)
+	if !found {
+		return nil
+	}
+	switch values := v.(type) {
+	case []metav1.StatusCause:
+		return append([]metav1.StatusCause(nil), values...)
+	case []map[string]any:
+		causes := make([]metav1.StatusCause, 0, len(values))
+		for _, m := range values {
+			causes = append(causes, causeFromMap(m))
+		}
+		return causes
+	case []any:
+		causes := make([]metav1.StatusCause, 0, len(values))
+		for _, value := range values {
+			if m, ok := value.(map[string]any); ok {
+				causes = append(causes, causeFromMap(m))
+			}
+		}
+		return causes
+	default:
+		return nil
+	}
+}
+
+// causeFromMap returns a metav1.StatusCause populated using the "reason", "message", and "field" keys within the given
+// map, where present.
+func causeFromMap(m map[string]any) metav1.StatusCause {
+	var cause metav1.StatusCause
+	if s, ok := m["reason"].(string); ok {
+		cause.Type = metav1.CauseType(s)
+	}
+	cause.Message, _ = m["message"].(string)
+	cause.Field, _ = m["field"].(string)
+	return cause
+}
+
+// putExtension puts the given value into extensions using the key provided, but only if value is not empty.
+func putExtension(extensions problem.Extensions, key, value string) {
+	if value != "" {
+		extensions[key] = value
+	}
+}
+
+// reasonForStatus returns the metav1.StatusReason that is most appropriate for the given status code, mirroring that
+// used by Kubernetes, or metav1.StatusReasonUnknown if there is none.
+func reasonForStatus(status int) metav1.StatusReason {
+	switch status {
+	case http.StatusBadRequest:
+		return metav1.StatusReasonBadRequest
+	case http.StatusUnauthorized:
+		return metav1.StatusReasonUnauthorized
+	case http.StatusForbidden:
+		return metav1.StatusReasonForbidden
+	case http.StatusNotFound:
+		return metav1.StatusReasonNotFound
+	case http.StatusMethodNotAllowed:
+		return metav1.StatusReasonMethodNotAllowed
+	case http.StatusNotAcceptable:
+		return metav1.StatusReasonNotAcceptable
+	case http.StatusConflict:
+		return metav1.StatusReasonConflict
+	case http.StatusGone:
+		return metav1.StatusReasonGone
+	case http.StatusRequestEntityTooLarge:
+		return metav1.StatusReasonRequestEntityTooLarge
+	case http.StatusUnsupportedMediaType:
+		return metav1.StatusReasonUnsupportedMediaType
+	case http.StatusUnprocessableEntity:
+		return metav1.StatusReasonInvalid
+	case http.StatusTooManyRequests:
+		return metav1.StatusReasonTooManyRequests
+	case http.StatusInternalServerError:
+		return metav1.StatusReasonInternalError
+	case http.StatusServiceUnavailable:
+		return metav1.StatusReasonServiceUnavailable
+	case http.StatusGatewayTimeout:
+		return metav1.StatusReasonTimeout
+	default:
+		return metav1.StatusReasonUnknown
+	}
+}
+
+// retryAfterSeconds returns the number of seconds contained within the retry hint of the given problem.Problem (see
+// problem.ExtensionRetryAfter), if any.
+func retryAfterSeconds(prob *problem.Problem) int32 {
+	v, found := prob.Extension(problem.ExtensionRetryAfter)
+	if !found {
+		return 0
+	}
+	switch hint := v.(type) {
+	case time.Duration:
+		return int32(hint / time.Second)
+	case float64:
+		return int32(hint)
+	case int:
+		return int32(hint)
+	case int32:
+		return hint
+	case int64:
+		return int32(hint)
+	default:
+		return 0
+	}
+}
+
+// stringExtension returns the string value of the extension with the given key within the problem.Problem provided
+// and whether it was found and not empty.
+func stringExtension(prob *problem.Problem, key string) (string, bool) {
+	v, _ := prob.Extension(key)
+	s, ok := v.(string)
+	return s, ok && s != ""
+}
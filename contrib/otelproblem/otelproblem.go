@@ -0,0 +1,88 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package otelproblem provides support for correlating a problem.Problem with the OpenTelemetry span that is active
+// within the context used to build it, by reading its trace context and/or recording the problem.Problem as a span
+// event.
+//
+// otelproblem is a separate module so that OpenTelemetry is only required by those using it.
+//
+// For example;
+//
+//	g := &problem.Generator{
+//		SpanEventRecorder:  otelproblem.RecordSpanEvent,
+//		TraceContextFlag:   problem.FlagLog,
+//		TraceContextReader: otelproblem.TraceContext,
+//	}
+package otelproblem
+
+import (
+	"context"
+	"github.com/neocotic/go-problem"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SpanEventName is the name of the event added to the active span when a problem.Problem is recorded by
+// RecordSpanEvent.
+const SpanEventName = "problem"
+
+const (
+	// spanAttrCode is the key of the span event attribute containing the problem.Code of a problem.Problem.
+	spanAttrCode = attribute.Key("problem.code")
+	// spanAttrStatus is the key of the span event attribute containing the status of a problem.Problem.
+	spanAttrStatus = attribute.Key("problem.status")
+	// spanAttrType is the key of the span event attribute containing the type URI reference of a problem.Problem.
+	spanAttrType = attribute.Key("problem.type")
+)
+
+var (
+	_ problem.SpanEventRecorder  = RecordSpanEvent
+	_ problem.TraceContextReader = TraceContext
+)
+
+// RecordSpanEvent is a problem.SpanEventRecorder that adds an event (see SpanEventName) to the OpenTelemetry span within
+// ctx, describing the given problem.Problem using its status, type URI reference, and problem.Code as attributes.
+//
+// The event is only added if the span is recording.
+func RecordSpanEvent(ctx context.Context, prob *problem.Problem) {
+	span := trace.SpanFromContext(ctx)
+	if prob == nil || !span.IsRecording() {
+		return
+	}
+	attrs := []attribute.KeyValue{
+		spanAttrStatus.Int(prob.Status),
+		spanAttrType.String(prob.Type),
+	}
+	if prob.Code != "" {
+		attrs = append(attrs, spanAttrCode.String(string(prob.Code)))
+	}
+	span.AddEvent(SpanEventName, trace.WithAttributes(attrs...))
+}
+
+// TraceContext is a problem.TraceContextReader that returns the hex-encoded trace and span IDs of the OpenTelemetry
+// span within ctx, if valid. Otherwise, empty strings are returned.
+func TraceContext(ctx context.Context) (traceID, spanID string) {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return "", ""
+	}
+	return sc.TraceID().String(), sc.SpanID().String()
+}
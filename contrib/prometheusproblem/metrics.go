@@ -0,0 +1,219 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package prometheusproblem provides ready-made Prometheus metrics for each problem.Problem built and/or written as an
+// HTTP response by a problem.Generator, labelled by status, type URI reference, code namespace, and SLO class, so that
+// error rates can be monitored per problem type without wrapping every write call.
+package prometheusproblem
+
+import (
+	"context"
+	"github.com/neocotic/go-problem"
+	"github.com/prometheus/client_golang/prometheus"
+	"strconv"
+)
+
+type (
+	// Metrics contains the Prometheus metrics for problems and implements prometheus.Collector so that it can be
+	// registered with a prometheus.Registerer.
+	//
+	// Metrics must be constructed using NewMetrics and is safe for concurrent use.
+	Metrics struct {
+		// built is the counter of problems built.
+		built *prometheus.CounterVec
+		// size is the histogram of the size of the body of problems written as HTTP responses.
+		size *prometheus.HistogramVec
+		// written is the counter of problems written as HTTP responses.
+		written *prometheus.CounterVec
+	}
+
+	// Options contains options that can be used when constructing Metrics.
+	//
+	// All fields are optional with default behaviour clearly documented.
+	Options struct {
+		// ConstLabels contains any labels with constant values to be added to every metric.
+		//
+		// If empty, no constant labels are added.
+		ConstLabels prometheus.Labels
+		// Namespace is the namespace used to prefix the name of every metric.
+		//
+		// If empty, DefaultNamespace is used.
+		Namespace string
+		// SizeBuckets contains the buckets used by the histogram observing the size of the body of problems written as
+		// HTTP responses, in bytes.
+		//
+		// If empty, DefaultSizeBuckets is used.
+		SizeBuckets []float64
+		// Subsystem is the subsystem used to prefix the name of every metric, after Namespace.
+		//
+		// If empty, no subsystem is used.
+		Subsystem string
+	}
+)
+
+const (
+	// DefaultNamespace is the namespace used to prefix the name of every metric if Options.Namespace is empty.
+	DefaultNamespace = "problem"
+
+	// LabelCodeNS is the label containing the namespace of the problem.Code of a problem.Problem, which is empty if the
+	// problem.Problem has no problem.Code or it could not be parsed.
+	LabelCodeNS = "code_ns"
+	// LabelSLOClass is the label containing the problem.SLOClass of a problem.Problem, which is empty if the
+	// problem.Problem is unclassified (see problem.Generator.SLOClassifier).
+	LabelSLOClass = "slo_class"
+	// LabelStatus is the label containing the status of a problem.Problem.
+	LabelStatus = "status"
+	// LabelType is the label containing the type URI reference of a problem.Problem.
+	LabelType = "type"
+)
+
+// DefaultSizeBuckets is the buckets used by the histogram observing the size of the body of problems written as HTTP
+// responses if Options.SizeBuckets is empty.
+var DefaultSizeBuckets = prometheus.ExponentialBuckets(64, 2, 10)
+
+var _ prometheus.Collector = (*Metrics)(nil)
+
+// labelNames contains the names of the labels applied to every metric, in the order in which their values are passed.
+var labelNames = []string{LabelStatus, LabelType, LabelCodeNS, LabelSLOClass}
+
+// NewMetrics returns new Metrics, optionally using Options for more granular control.
+//
+// The returned Metrics must be registered with a prometheus.Registerer before any metrics are exported and can be
+// observed by passing Metrics.RouterAction to a problem.Generator (via problem.Generator.Router) and Metrics.BodyObserver
+// to problem.WriteOptions.
+//
+// For example;
+//
+//	metrics := NewMetrics()
+//	prometheus.MustRegister(metrics)
+//	g := &problem.Generator{Router: &problem.Router{Rules: []problem.RouterRule{
+//		{Actions: []problem.RouterAction{metrics.RouterAction()}},
+//	}}}
+//	g.WriteProblem(prob, w, req, problem.WriteOptions{BodyObserver: metrics.BodyObserver()})
+func NewMetrics(opts ...Options) *Metrics {
+	var _opts Options
+	if len(opts) > 0 {
+		_opts = opts[0]
+	}
+	namespace := _opts.Namespace
+	if namespace == "" {
+		namespace = DefaultNamespace
+	}
+	sizeBuckets := _opts.SizeBuckets
+	if len(sizeBuckets) == 0 {
+		sizeBuckets = DefaultSizeBuckets
+	}
+	return &Metrics{
+		built: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Subsystem:   _opts.Subsystem,
+			Name:        "built_total",
+			Help:        "Total number of problems built.",
+			ConstLabels: _opts.ConstLabels,
+		}, labelNames),
+		size: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   namespace,
+			Subsystem:   _opts.Subsystem,
+			Name:        "response_size_bytes",
+			Help:        "Size of the body of problems written as HTTP responses, in bytes.",
+			ConstLabels: _opts.ConstLabels,
+			Buckets:     sizeBuckets,
+		}, labelNames),
+		written: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Subsystem:   _opts.Subsystem,
+			Name:        "written_total",
+			Help:        "Total number of problems written as HTTP responses.",
+			ConstLabels: _opts.ConstLabels,
+		}, labelNames),
+	}
+}
+
+// BodyObserver returns a problem.BodyObserver that observes each problem.Problem written as an HTTP response (see
+// Metrics.ObserveWrite), which can be used via problem.WriteOptions.BodyObserver.
+func (m *Metrics) BodyObserver() problem.BodyObserver {
+	return func(ctx context.Context, prob *problem.Problem, body []byte) {
+		m.ObserveWrite(problem.GetGenerator(ctx), prob, len(body))
+	}
+}
+
+// Collect sends each of the metrics to the given channel.
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.built.Collect(ch)
+	m.size.Collect(ch)
+	m.written.Collect(ch)
+}
+
+// Describe sends the descriptors of each of the metrics to the given channel.
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.built.Describe(ch)
+	m.size.Describe(ch)
+	m.written.Describe(ch)
+}
+
+// ObserveBuild increments the counter of problems built for the given problem.Problem.
+//
+// If gen is nil, problem.DefaultGenerator is used to parse the namespace of the problem.Code of prob. Nothing happens if
+// prob is nil.
+func (m *Metrics) ObserveBuild(gen *problem.Generator, prob *problem.Problem) {
+	if prob == nil {
+		return
+	}
+	m.built.WithLabelValues(labelValues(gen, prob)...).Inc()
+}
+
+// ObserveWrite increments the counter of problems written as HTTP responses for the given problem.Problem and observes
+// the size of its body, in bytes.
+//
+// If gen is nil, problem.DefaultGenerator is used to parse the namespace of the problem.Code of prob. Nothing happens if
+// prob is nil.
+func (m *Metrics) ObserveWrite(gen *problem.Generator, prob *problem.Problem, size int) {
+	if prob == nil {
+		return
+	}
+	values := labelValues(gen, prob)
+	m.written.WithLabelValues(values...).Inc()
+	m.size.WithLabelValues(values...).Observe(float64(size))
+}
+
+// RouterAction returns a problem.RouterAction that observes each problem.Problem built (see Metrics.ObserveBuild),
+// which can be used within a problem.RouterRule without any problem.Matcher so that it observes every problem.Problem.
+//
+// The problem.Problem is always returned as-is.
+func (m *Metrics) RouterAction() problem.RouterAction {
+	return func(_ context.Context, gen *problem.Generator, prob *problem.Problem) *problem.Problem {
+		m.ObserveBuild(gen, prob)
+		return prob
+	}
+}
+
+// labelValues returns the values of each label within labelNames for the given problem.Problem.
+func labelValues(gen *problem.Generator, prob *problem.Problem) []string {
+	var ns string
+	if prob.Code != "" {
+		if gen == nil {
+			gen = problem.DefaultGenerator
+		}
+		if parsed, err := gen.Coder().Parse(prob.Code); err == nil {
+			ns = string(parsed.NS)
+		}
+	}
+	return []string{strconv.Itoa(prob.Status), prob.Type, ns, string(prob.SLOClass())}
+}
@@ -0,0 +1,181 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package sqlproblem provides support for mapping common database errors (e.g. from database/sql, pgx, or the MySQL
+// driver) to a problem.Definition so that repository layers need not leak driver-specific errors to clients.
+package sqlproblem
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/neocotic/go-problem"
+	problemhttp "github.com/neocotic/go-problem/http"
+	"regexp"
+)
+
+// ExtensionConstraint is the key of the extension containing the name of the constraint that was violated by a database
+// error that has been mapped to a problem.Definition, where available. Unlike the value that violated the constraint,
+// its name is not considered sensitive and allows a client to identify which field conflicted.
+const ExtensionConstraint = "constraint"
+
+// ExtensionSQLState is the key of the extension containing the SQLSTATE code of a database error that has been mapped
+// to a problem.Definition, where available.
+//
+// The SQLSTATE code is exposed as it is a sanitized classification of the error, unlike its message and detail which
+// may contain sensitive data (e.g. the value that violated a unique constraint).
+const ExtensionSQLState = "sqlState"
+
+const (
+	// mysqlErrDupEntry is the MySQL error number for a duplicate entry for a unique key.
+	mysqlErrDupEntry = 1062
+	// mysqlErrDupEntryWithKeyName is the MySQL error number for a duplicate entry for a named unique key.
+	mysqlErrDupEntryWithKeyName = 1586
+	// mysqlErrLockDeadlock is the MySQL error number for a deadlock found when trying to get a lock.
+	mysqlErrLockDeadlock = 1213
+	// mysqlErrLockWaitTimeout is the MySQL error number for a lock wait timeout being exceeded.
+	mysqlErrLockWaitTimeout = 1205
+	// pgDeadlockDetected is the PostgreSQL SQLSTATE code for a detected deadlock.
+	pgDeadlockDetected = "40P01"
+	// pgLockNotAvailable is the PostgreSQL SQLSTATE code for a lock that is not available.
+	pgLockNotAvailable = "55P03"
+	// pgSerializationFailure is the PostgreSQL SQLSTATE code for a serialization failure.
+	pgSerializationFailure = "40001"
+	// pgUniqueViolation is the PostgreSQL SQLSTATE code for a unique constraint violation.
+	pgUniqueViolation = "23505"
+)
+
+// mysqlKeyPattern is used to extract the name of the unique key from the message of a MySQL duplicate entry error.
+var mysqlKeyPattern = regexp.MustCompile(`for key '([^']+)'`)
+
+// Map returns a problem.Definition that is most appropriate for the given database error and whether err was
+// recognized. Since its signature matches problem.ErrorMapper, it can be used within problem.Generator.ErrorMappers
+// (see Mapper) so that repository layers can simply wrap the raw error.
+//
+// The following errors are recognized:
+//
+//   - sql.ErrNoRows and pgx.ErrNoRows map to http.NotFoundDefinition
+//   - A unique constraint violation, identified by driver-specific codes for pgx and MySQL, maps to
+//     http.ConflictDefinition
+//   - A serialization failure or deadlock, identified by driver-specific codes for pgx and MySQL, maps to
+//     http.ConflictDefinition as the transaction was aborted and the request can typically be retried
+//   - A lock that is not available (incl. a lock wait timeout), identified by driver-specific codes for pgx and MySQL,
+//     maps to http.ServiceUnavailableDefinition
+//
+// Where available, the SQLSTATE code of err and the name of the violated constraint are included within the extensions
+// of the problem.Definition (see ExtensionSQLState and ExtensionConstraint). No other information from err is included
+// to avoid leaking sensitive data.
+//
+// For example;
+//
+//	user, err := repo.FindUser(ctx, id)
+//	if err != nil {
+//		def, ok := Map(err)
+//		if !ok {
+//			def = problemhttp.InternalServerDefinition
+//		}
+//		problem.WriteProblem(def.New(problem.Wrap(err)), w, req)
+//		return
+//	}
+func Map(err error) (problem.Definition, bool) {
+	if err == nil {
+		return problem.Definition{}, false
+	}
+	if errors.Is(err, sql.ErrNoRows) || errors.Is(err, pgx.ErrNoRows) {
+		return problemhttp.NotFoundDefinition, true
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case pgUniqueViolation:
+			return withExtensions(problemhttp.ConflictDefinition, pgErr.Code, pgErr.ConstraintName), true
+		case pgSerializationFailure, pgDeadlockDetected:
+			return withExtensions(problemhttp.ConflictDefinition, pgErr.Code, ""), true
+		case pgLockNotAvailable:
+			return withExtensions(problemhttp.ServiceUnavailableDefinition, pgErr.Code, ""), true
+		}
+		return problem.Definition{}, false
+	}
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		sqlState := string(mysqlErr.SQLState[:])
+		switch mysqlErr.Number {
+		case mysqlErrDupEntry, mysqlErrDupEntryWithKeyName:
+			var constraint string
+			if m := mysqlKeyPattern.FindStringSubmatch(mysqlErr.Message); m != nil {
+				constraint = m[1]
+			}
+			return withExtensions(problemhttp.ConflictDefinition, sqlState, constraint), true
+		case mysqlErrLockDeadlock:
+			return withExtensions(problemhttp.ConflictDefinition, sqlState, ""), true
+		case mysqlErrLockWaitTimeout:
+			return withExtensions(problemhttp.ServiceUnavailableDefinition, sqlState, ""), true
+		}
+	}
+	return problem.Definition{}, false
+}
+
+// MapOrElse returns a problem.Definition that is most appropriate for the given database error or defaultDefinition
+// if err is not recognized. See Map for more information.
+//
+// For example;
+//
+//	def := MapOrElse(err, problemhttp.InternalServerDefinition)
+func MapOrElse(err error, defaultDefinition problem.Definition) problem.Definition {
+	if def, ok := Map(err); ok {
+		return def
+	}
+	return defaultDefinition
+}
+
+// Mapper returns a problem.ErrorMapper that maps database errors using Map.
+//
+// For example;
+//
+//	g := &problem.Generator{ErrorMappers: []problem.ErrorMapper{Mapper()}}
+//	user, err := repo.FindUser(ctx, id)
+//	if err != nil {
+//		return g.New(problem.Wrap(err))
+//	}
+func Mapper() problem.ErrorMapper {
+	return Map
+}
+
+// withExtensions returns a copy of the given problem.Definition with the SQLSTATE code and constraint name provided
+// added to its extensions, where not empty.
+func withExtensions(def problem.Definition, sqlState, constraint string) problem.Definition {
+	if sqlState == "" && constraint == "" {
+		return def
+	}
+	extensions := make(map[string]any, len(def.Extensions)+2)
+	for k, v := range def.Extensions {
+		extensions[k] = v
+	}
+	if sqlState != "" {
+		extensions[ExtensionSQLState] = sqlState
+	}
+	if constraint != "" {
+		extensions[ExtensionConstraint] = constraint
+	}
+	def.Extensions = extensions
+	return def
+}
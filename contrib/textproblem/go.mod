@@ -0,0 +1,18 @@
+module github.com/neocotic/go-problem/contrib/textproblem
+
+go 1.21
+
+require (
+	github.com/neocotic/go-problem v0.0.0
+	golang.org/x/text v0.14.0
+)
+
+require (
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/neocotic/go-optional v0.1.2 // indirect
+	go.uber.org/multierr v1.10.0 // indirect
+	go.uber.org/zap v1.27.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/neocotic/go-problem => ../..
@@ -0,0 +1,77 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package textproblem provides support for localizing problems using a golang.org/x/text/message/catalog.Catalog,
+// for those standardized on golang.org/x/text rather than third-party i18n libraries.
+package textproblem
+
+import (
+	"context"
+	"fmt"
+	"github.com/neocotic/go-problem"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/message/catalog"
+	"sync"
+)
+
+// Translator returns a problem.Translator that looks up localized values within the given catalog.Catalog using a
+// message.Printer for the language within the context.Context (see problem.GetLanguage and problem.UsingLanguage),
+// otherwise the fallback language.Tag provided. The language is matched against those supported by cat in the same way
+// as message.NewPrinter.
+//
+// A translation key must be either a string or a fmt.Stringer, otherwise it is ignored. An empty string is returned if
+// no message could be found for a translation key, allowing a Problem to be constructed using a fallback value for the
+// associated field.
+//
+// For example;
+//
+//	builder := catalog.NewBuilder()
+//	_ = builder.SetString(language.French, "problem.user.notFound.title", "Utilisateur introuvable")
+//	g := &problem.Generator{Translator: textproblem.Translator(builder, language.English)}
+//	ctx := problem.UsingLanguage(req.Context(), language.French)
+//	g.NewContext(ctx, problem.WithTitleKey("problem.user.notFound.title")).Title  // "Utilisateur introuvable"
+func Translator(cat catalog.Catalog, fallback language.Tag) problem.Translator {
+	var printers sync.Map
+	return func(ctx context.Context, key any) string {
+		var id string
+		switch k := key.(type) {
+		case string:
+			id = k
+		case fmt.Stringer:
+			id = k.String()
+		default:
+			return ""
+		}
+		if id == "" {
+			return ""
+		}
+		tag, ok := problem.GetLanguage(ctx)
+		if !ok {
+			tag = fallback
+		}
+		p, loaded := printers.Load(tag)
+		if !loaded {
+			p, _ = printers.LoadOrStore(tag, message.NewPrinter(tag, message.Catalog(cat)))
+		}
+		// An empty fallback message ensures that an empty string is returned if id could not be found
+		return p.(*message.Printer).Sprintf(message.Key(id, ""))
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package validatorproblem provides support for converting validator.ValidationErrors from the go-playground/validator
+// package into a problem.Problem containing a problem.FieldError for each field that failed validation.
+package validatorproblem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	"github.com/neocotic/go-problem"
+	problemhttp "github.com/neocotic/go-problem/http"
+	"net/http"
+	"strings"
+)
+
+// DefaultKeyPrefix is the default prefix of the translation key used to localize the detail of a problem.FieldError
+// using problem.Generator.Translator.
+const DefaultKeyPrefix = "validator."
+
+// Options contains options that can be used by FromValidationErrors to control how a problem.Problem is generated.
+//
+// All fields are optional with default behaviour clearly documented.
+type Options struct {
+	// KeyPrefix is the prefix of the ID of the problem.Key passed to problem.Generator.Translator in order to localize
+	// the detail of a problem.FieldError, which is followed by the validation tag (e.g. "validator.required"). The
+	// problem.Key contains the "field", "param", and "value" of the validator.FieldError as named arguments.
+	//
+	// If empty, DefaultKeyPrefix will be used.
+	KeyPrefix string
+	// Status is the status of the problem.Problem, which is typically either http.StatusUnprocessableEntity or
+	// http.StatusBadRequest.
+	//
+	// If zero or less, http.StatusUnprocessableEntity will be used.
+	Status int
+	// Translator is used to localize the detail of a problem.FieldError using the translations registered with the
+	// validator.Validate (e.g. via the translations subpackages of validator), but only where it could not be localized
+	// using problem.Generator.Translator. The ut.Translator for the language within the context.Context (see
+	// problem.GetLanguage) is used, where found, otherwise the fallback ut.Translator.
+	//
+	// If nil, only problem.Generator.Translator is used to localize the detail of a problem.FieldError.
+	Translator *ut.UniversalTranslator
+}
+
+// FromValidationErrors returns a problem.Problem generated by the given problem.Generator for the
+// validator.ValidationErrors within err's tree, if any, otherwise nil.
+//
+// The problem.Problem is generated from the problem.Definition for Options.Status using
+// problemhttp.StatusDefinition, where known, and wraps err. It contains a problem.FieldError for each
+// validator.FieldError (see problem.Builder.FieldErrors) where:
+//
+//   - problem.FieldError.Code is the validation tag (e.g. "required")
+//   - problem.FieldError.Detail is localized, where possible (see Options.KeyPrefix and Options.Translator), otherwise a
+//     generic message that, unlike validator.FieldError.Error, does not expose the name of the struct
+//   - problem.FieldError.Field is the name of the field, which can be controlled using
+//     validator.Validate.RegisterTagNameFunc (e.g. to use JSON names)
+//   - problem.FieldError.Pointer is a JSON Pointer derived from the namespace of the field, excluding the name of the
+//     top-level struct
+//
+// If gen is nil, the problem.Generator within ctx is used, if any, otherwise problem.DefaultGenerator.
+//
+// For example;
+//
+//	if err := validate.Struct(req); err != nil {
+//		if prob := FromValidationErrors(ctx, nil, err); prob != nil {
+//			problem.WriteProblem(prob, w, r)
+//			return
+//		}
+//	}
+func FromValidationErrors(ctx context.Context, gen *problem.Generator, err error, opts ...Options) *problem.Problem {
+	var ves validator.ValidationErrors
+	if !errors.As(err, &ves) {
+		return nil
+	}
+	if gen == nil {
+		gen = problem.GetGenerator(ctx)
+	}
+	var _opts Options
+	if len(opts) > 0 {
+		_opts = opts[0]
+	}
+	status := _opts.Status
+	if status <= 0 {
+		status = http.StatusUnprocessableEntity
+	}
+	fieldErrs := make([]problem.FieldError, len(ves))
+	for i, fe := range ves {
+		fieldErrs[i] = problem.FieldError{
+			Code:    fe.Tag(),
+			Detail:  detail(ctx, gen, fe, _opts),
+			Field:   fe.Field(),
+			Pointer: pointer(fe.Namespace()),
+		}
+	}
+	def := problemhttp.StatusDefinitionOrElse(status, problem.Definition{Type: problem.Type{Status: status}})
+	return gen.NewContext(ctx, problem.FromDefinition(def), problem.WithFieldErrors(fieldErrs...), problem.Wrap(err))
+}
+
+// detail returns the most suitable detail for the given validator.FieldError, localized where possible using
+// problem.Generator.Translator with a fallback to Options.Translator.
+func detail(ctx context.Context, gen *problem.Generator, fe validator.FieldError, opts Options) string {
+	prefix := opts.KeyPrefix
+	if prefix == "" {
+		prefix = DefaultKeyPrefix
+	}
+	key := problem.Key{
+		Args: map[string]any{"field": fe.Field(), "param": fe.Param(), "value": fe.Value()},
+		ID:   prefix + fe.Tag(),
+	}
+	if d := gen.Translate(ctx, key); d != "" {
+		return d
+	}
+	if uni := opts.Translator; uni != nil {
+		trans := uni.GetFallback()
+		if tag, ok := problem.GetLanguage(ctx); ok {
+			if found, ok := uni.GetTranslator(tag.String()); ok {
+				trans = found
+			}
+		}
+		// validator.FieldError.Translate falls back to validator.FieldError.Error if no translation is registered
+		if d := fe.Translate(trans); d != "" && d != fe.Error() {
+			return d
+		}
+	}
+	return fmt.Sprintf("%s failed on the %q validation", fe.Field(), fe.Tag())
+}
+
+// pointerEscaper escapes the reference tokens of a JSON Pointer in accordance with RFC 6901.
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// pointer returns a JSON Pointer derived from the given namespace of a validator.FieldError (e.g.
+// "User.Addresses[0].Postcode" becomes "/Addresses/0/Postcode"), excluding the name of the top-level struct.
+func pointer(ns string) string {
+	_, ns, ok := strings.Cut(ns, ".")
+	if !ok {
+		return ""
+	}
+	var sb, token strings.Builder
+	flush := func() {
+		sb.WriteByte('/')
+		sb.WriteString(pointerEscaper.Replace(token.String()))
+		token.Reset()
+	}
+	var inIndex bool
+	for _, r := range ns {
+		switch {
+		case inIndex && r == ']':
+			flush()
+			inIndex = false
+		case !inIndex && (r == '.' || r == '['):
+			if token.Len() > 0 {
+				flush()
+			}
+			inIndex = r == '['
+		default:
+			token.WriteRune(r)
+		}
+	}
+	if token.Len() > 0 {
+		flush()
+	}
+	return sb.String()
+}
@@ -0,0 +1,58 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package problem
+
+// ExtensionDebugError is the key of the extension containing the message of the error wrapped by a Problem when it is
+// written as an HTTP response while debugging. See Generator.DebugMode for more information.
+const ExtensionDebugError = "error"
+
+// debugProblem returns a shallow clone of the given Problem that also contains information that is ordinarily only
+// visible within logs, but only if debugging is enabled (see Generator.DebugMode). Otherwise, prob is returned as-is.
+//
+// The returned Problem contains the message of any wrapped error within its extensions (see ExtensionDebugError) along
+// with any stack trace, timestamp, trace context, and "UUID" that would otherwise only be visible within logs. Nothing
+// that is already present on prob is replaced. However, any wrapped error message or stack trace that
+// Generator.Visibility restricts to logs is never included.
+func (g *Generator) debugProblem(prob *Problem) *Problem {
+	if !debugBuild || !g.DebugMode || prob == nil {
+		return prob
+	}
+	clone := prob.clone()
+	if err := prob.err; err != nil && !g.Visibility.LogOnlyErrorMessages {
+		clone.Extensions = putExtensionIfAbsent(clone.Extensions, ExtensionDebugError, err.Error())
+	}
+	if clone.SpanID == "" {
+		clone.SpanID = prob.logInfo.SpanID
+	}
+	if clone.Stack == "" && !g.Visibility.LogOnlyStack {
+		clone.Stack = prob.logInfo.stack()
+	}
+	if clone.Timestamp.IsZero() {
+		clone.Timestamp = prob.logInfo.Timestamp
+	}
+	if clone.TraceID == "" {
+		clone.TraceID = prob.logInfo.TraceID
+	}
+	if clone.UUID == "" {
+		clone.UUID = prob.logInfo.UUID
+	}
+	return clone
+}
@@ -22,6 +22,7 @@ package problem
 
 import (
 	"context"
+	"encoding/json"
 	"github.com/neocotic/go-optional"
 )
 
@@ -49,13 +50,37 @@ type Definition struct {
 	// The localized detail will be looked up using Generator.Translator, where possible. If resolved, it will take
 	// precedence over Detail.
 	//
+	// DetailKey is typically a string, however, a Key may be used where a structured translation key is required.
+	//
 	// If DetailKey is empty it, it is ignored.
 	DetailKey any `json:"detailKey" xml:"detailKey" yaml:"detailKey"`
+	// DocsURL is the URL of documentation describing a Problem generated from the Definition, which is assigned to the
+	// Problem as an extension using ExtensionDocsURL as the key, unless the extension is already present.
+	//
+	// If present, it takes precedence over Type.DocsURL.
+	//
+	// If DocsURL is empty, no default is used.
+	DocsURL string `json:"docsUrl,omitempty" xml:"docsUrl,omitempty" yaml:"docsUrl,omitempty"`
+	// Examples contains example payloads of a Problem generated from the Definition, keeping them next to the
+	// Definition itself so that they can be used for documentation and as golden values within tests (see
+	// problemtest.MatchExample).
+	//
+	// Examples are never used when generating a Problem.
+	//
+	// If Examples is empty, the Definition has no examples.
+	Examples []ExampleProblem `json:"examples,omitempty" xml:"examples>example,omitempty" yaml:"examples,omitempty"`
 	// Extensions is the default extensions to be assigned to a Problem generated from the Definition. See
 	// Problem.Extensions for more information.
 	//
 	// If Extensions is nil, no default is used.
 	Extensions map[string]any `json:"extensions" xml:"extensions" yaml:"extensions"`
+	// HelpURL is the URL of actionable remediation steps for a Problem generated from the Definition, which is assigned
+	// to the Problem as an extension using ExtensionHelpURL as the key, unless the extension is already present.
+	//
+	// If present, it takes precedence over Type.HelpURL.
+	//
+	// If HelpURL is empty, no default is used.
+	HelpURL string `json:"helpUrl,omitempty" xml:"helpUrl,omitempty" yaml:"helpUrl,omitempty"`
 	// Instance is the default instance URI to be assigned to a Problem generated from the Definition. See
 	// Problem.Instance for more information.
 	//
@@ -63,11 +88,63 @@ type Definition struct {
 	//
 	// If Instance is empty, no default is used.
 	Instance string `json:"instance" xml:"instance" yaml:"instance"`
+	// Owner is the owner of a Problem generated from the Definition (e.g. the team responsible for it), allowing whoever
+	// is triaging the Problem to immediately know who to contact. See LogInfo.Owner for more information.
+	//
+	// Owner is only ever visible within logs, unless the Problem is written as an HTTP response for a Profile with
+	// Profile.Ownership enabled, in which case it is assigned to the Problem as an extension using ExtensionOwner as the
+	// key, unless the extension is already present.
+	//
+	// If Owner is empty, the Problem has no owner.
+	Owner string `json:"owner,omitempty" xml:"owner,omitempty" yaml:"owner,omitempty"`
+	// RunbookURL is the URL of the runbook to be followed by whoever is triaging a Problem generated from the
+	// Definition. See LogInfo.RunbookURL for more information.
+	//
+	// RunbookURL is only ever visible within logs, unless the Problem is written as an HTTP response for a Profile with
+	// Profile.Ownership enabled, in which case it is assigned to the Problem as an extension using ExtensionRunbookURL
+	// as the key, unless the extension is already present.
+	//
+	// If RunbookURL is empty, the Problem has no runbook.
+	RunbookURL string `json:"runbookUrl,omitempty" xml:"runbookUrl,omitempty" yaml:"runbookUrl,omitempty"`
+	// StackFlag is the default Flag used to control if/how a captured stack trace is visible on a Problem generated from
+	// the Definition. See Problem.Stack for more information.
+	//
+	// If present, it takes precedence over Type.StackFlag and Generator.StackFlag, however, Builder.Stack and WithStack
+	// will always take precedence over StackFlag.
+	//
+	// If StackFlag is empty, no default is used.
+	StackFlag optional.Optional[Flag] `json:"stackFlag,omitempty" xml:"stackFlag,omitempty" yaml:"stackFlag,omitempty"`
 	// Type contains fields defining the type of Problem generated from the Definition, typically containing additional
 	// default values.
 	Type Type `json:"type" xml:"type" yaml:"type"`
+	// UUIDFlag is the default Flag used to control if/how a generated UUID is visible on a Problem generated from the
+	// Definition. See Problem.UUID for more information.
+	//
+	// If present, it takes precedence over Type.UUIDFlag and Generator.UUIDFlag, however, Builder.UUID and WithUUID will
+	// always take precedence over UUIDFlag.
+	//
+	// If UUIDFlag is empty, no default is used.
+	UUIDFlag optional.Optional[Flag] `json:"uuidFlag,omitempty" xml:"uuidFlag,omitempty" yaml:"uuidFlag,omitempty"`
+}
+
+// ExampleProblem is a named example payload of a Problem generated from a Definition. See Definition.Examples for more
+// information.
+type ExampleProblem struct {
+	// Name is the name of the example, which should be unique within Definition.Examples.
+	Name string `json:"name" xml:"name" yaml:"name"`
+	// Problem is the example payload.
+	Problem *Problem `json:"problem" xml:"problem" yaml:"problem"`
+	// Summary is a short description of the example.
+	//
+	// If Summary is empty, the example has no description.
+	Summary string `json:"summary,omitempty" xml:"summary,omitempty" yaml:"summary,omitempty"`
 }
 
+// jsonDefinition is used to allow a Definition struct to be marshaled into, and JSON data to be unmarshaled into a
+// Definition struct, without having Definition.MarshalJSON or Definition.UnmarshalJSON invoked, resulting in a stack
+// overflow.
+type jsonDefinition Definition
+
 // Build is a convenient shorthand for calling Generator.Build on DefaultGenerator with the Definition already passed to
 // Builder.Definition.
 func (d Definition) Build() *Builder {
@@ -108,6 +185,37 @@ func (d Definition) BuildUsing(gen *Generator) *Builder {
 	}
 }
 
+// Example returns the ExampleProblem with the given name within Definition.Examples, if present.
+func (d Definition) Example(name string) (ExampleProblem, bool) {
+	for _, example := range d.Examples {
+		if example.Name == name {
+			return example, true
+		}
+	}
+	return ExampleProblem{}, false
+}
+
+// MarshalJSON marshals the Definition into JSON.
+//
+// This is required in order to omit Definition.StackFlag and Definition.UUIDFlag when they are not present, as
+// encoding/json never omits an optional.Optional.
+//
+// An error is returned if unable to marshal the Definition.
+func (d Definition) MarshalJSON() ([]byte, error) {
+	aux := struct {
+		jsonDefinition
+		StackFlag *Flag `json:"stackFlag,omitempty"`
+		UUIDFlag  *Flag `json:"uuidFlag,omitempty"`
+	}{jsonDefinition: jsonDefinition(d)}
+	if flag, present := d.StackFlag.Get(); present {
+		aux.StackFlag = &flag
+	}
+	if flag, present := d.UUIDFlag.Get(); present {
+		aux.UUIDFlag = &flag
+	}
+	return json.Marshal(aux)
+}
+
 // New is a convenient shorthand for calling Generator.New on DefaultGenerator, including FromDefinition with the
 // Definition along with any specified options.
 func (d Definition) New(opts ...Option) *Problem {
@@ -136,3 +244,27 @@ func (d Definition) NewUsing(gen *Generator, opts ...Option) *Problem {
 	opts = append([]Option{FromDefinition(d)}, opts...)
 	return gen.new(context.Background(), opts, 1)
 }
+
+// UnmarshalJSON unmarshals the JSON data provided into the Definition.
+//
+// This is required in order to unmarshal Definition.DetailKey as either a string or a Key, rather than a generic map for
+// the latter.
+//
+// An error is returned if unable to unmarshal data.
+func (d *Definition) UnmarshalJSON(data []byte) error {
+	aux := struct {
+		*jsonDefinition
+		DetailKey json.RawMessage `json:"detailKey"`
+	}{jsonDefinition: (*jsonDefinition)(d)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if aux.DetailKey != nil {
+		key, err := unmarshalKey(aux.DetailKey)
+		if err != nil {
+			return err
+		}
+		d.DetailKey = key
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package problem
+
+import (
+	"context"
+	"errors"
+)
+
+// StatusClientClosedRequest is the non-standard HTTP status code, popularized by nginx, that is used to indicate that
+// the client closed the connection before the server could respond.
+const StatusClientClosedRequest = 499
+
+var (
+	// ClientClosedRequestType is a built-in reusable Type that represents a non-standard HTTP Client Closed Request
+	// error. It is also available as http.ClientClosedRequest.
+	ClientClosedRequestType = Type{
+		LogLevel: LogLevelDebug,
+		Status:   StatusClientClosedRequest,
+		Title:    "Client Closed Request",
+		TitleKey: "problem.http.ClientClosedRequest.title",
+	}
+
+	// ClientClosedRequestDefinition is a built-in reusable Definition that represents a non-standard HTTP Client Closed
+	// Request error. It is also available as http.ClientClosedRequestDefinition.
+	//
+	// It is used whenever an error is written as an HTTP response (e.g. via WriteError or any of the Middleware
+	// functions) after the HTTP request's context.Context has been canceled, which typically means that the client has
+	// closed the connection. This allows client disconnects to be distinguished from server faults (e.g. in logs and
+	// dashboards).
+	ClientClosedRequestDefinition = Definition{
+		DetailKey: "problem.http.ClientClosedRequestDefinition.detail",
+		Type:      ClientClosedRequestType,
+	}
+)
+
+// isClientClosed returns whether the given context.Context of an HTTP request has been canceled, which typically means
+// that the client has closed the connection.
+func isClientClosed(ctx context.Context) bool {
+	return errors.Is(ctx.Err(), context.Canceled)
+}
@@ -0,0 +1,240 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package problem
+
+import (
+	"container/list"
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	// EscalationPolicy contains the rules used by EscalatingNotifier to decide when repeated occurrences of the same
+	// Problem are to be escalated to a Notifier (e.g. email, pager).
+	//
+	// All fields are optional with default behaviour clearly documented.
+	EscalationPolicy struct {
+		// Cooldown is the minimum duration between notifications for the same fingerprint, during which any further
+		// occurrences are ignored.
+		//
+		// If zero or less, Window will be used.
+		Cooldown time.Duration
+		// Fingerprint is the function used to return the fingerprint of a Problem, where problems with the same
+		// fingerprint are considered to be occurrences of the same problem.
+		//
+		// If nil, Fingerprint will be used.
+		Fingerprint func(prob *Problem) string
+		// MaxFingerprints is the maximum number of fingerprints whose occurrences are tracked at any one time. Once
+		// reached, the fingerprint that was least recently seen is no longer tracked in order to make room for a new
+		// one, which bounds the memory used when many distinct problems occur. Fingerprints that are cooling down after
+		// a notification are only evicted when no others remain, starting with the one whose cooldown ends soonest, so
+		// that they are not notified again before Cooldown has elapsed.
+		//
+		// If zero or less, DefaultEscalationMaxFingerprints will be used.
+		MaxFingerprints int
+		// Threshold is the number of occurrences of a Problem with the same fingerprint that must occur within Window
+		// in order to trigger a notification.
+		//
+		// If less than or equal to one, the first occurrence triggers a notification.
+		Threshold int
+		// Window is the duration within which Threshold occurrences of a Problem with the same fingerprint must occur
+		// in order to trigger a notification.
+		//
+		// If zero or less, DefaultEscalationWindow will be used.
+		Window time.Duration
+	}
+
+	// escalation contains the state of occurrences of a Problem with the same fingerprint.
+	escalation struct {
+		// elem is the element containing the escalation within either the list of escalations that are cooling down or
+		// the list of those that are not.
+		elem *list.Element
+		// key is the fingerprint of the Problem.
+		key string
+		// lastSeenAt is the time at which the last occurrence was recorded.
+		lastSeenAt time.Time
+		// notifiedAt is the time at which the last notification was triggered, if any, in which case the escalation is
+		// cooling down.
+		notifiedAt time.Time
+		// occurrences contains the times at which each occurrence was recorded within the current window.
+		occurrences []time.Time
+	}
+)
+
+const (
+	// DefaultEscalationMaxFingerprints is the default maximum number of fingerprints whose occurrences are tracked at
+	// any one time by an EscalationPolicy.
+	DefaultEscalationMaxFingerprints = 10_000
+	// DefaultEscalationWindow is the default duration within which occurrences of a Problem are counted by an
+	// EscalationPolicy.
+	DefaultEscalationWindow = 5 * time.Minute
+)
+
+// EscalatingNotifier returns a Notifier that only passes a Problem to the given Notifier once the number of
+// occurrences of a Problem with the same fingerprint within a time window reaches the threshold defined by the
+// EscalationPolicy provided.
+//
+// Once a notification has been triggered for a fingerprint, further occurrences are ignored until
+// EscalationPolicy.Cooldown has elapsed, which rate-limits notifications to the given Notifier. This makes it suitable
+// for driving lightweight alerting (e.g. email, pager) without a full APM.
+//
+// Fingerprints that have not been seen within the window, and are not cooling down, are pruned as they expire, and no
+// more than EscalationPolicy.MaxFingerprints are ever tracked, so memory usage remains bounded.
+//
+// For example;
+//
+//	pager := EscalatingNotifier(WebhookNotifier("https://pager.example.void/alerts"), EscalationPolicy{
+//		Threshold: 10,
+//		Window:    time.Minute,
+//	})
+//	g := &Generator{Notifiers: []NotifierRoute{{Notifier: pager}}}
+//
+// The returned Notifier is safe for concurrent use.
+func EscalatingNotifier(notifier Notifier, policy EscalationPolicy) Notifier {
+	var (
+		fingerprint     = policy.Fingerprint
+		window          = policy.Window
+		cooldown        = policy.Cooldown
+		maxFingerprints = policy.MaxFingerprints
+		threshold       = policy.Threshold
+		mu              sync.Mutex
+		escalations     = make(map[string]*escalation)
+		// cooling contains each escalation that is cooling down, ordered by when they were notified (most recent first)
+		cooling = list.New()
+		// idle contains each escalation that is not cooling down, ordered by when they were last seen (most recent
+		// first)
+		idle = list.New()
+	)
+	if fingerprint == nil {
+		fingerprint = Fingerprint
+	}
+	if window <= 0 {
+		window = DefaultEscalationWindow
+	}
+	if cooldown <= 0 {
+		cooldown = window
+	}
+	if maxFingerprints <= 0 {
+		maxFingerprints = DefaultEscalationMaxFingerprints
+	}
+	if threshold < 1 {
+		threshold = 1
+	}
+	return func(ctx context.Context, prob *Problem) error {
+		key := fingerprint(prob)
+		now := time.Now()
+
+		mu.Lock()
+		pruneEscalations(escalations, cooling, idle, now, window, cooldown)
+		e, found := escalations[key]
+		if found && !e.notifiedAt.IsZero() {
+			// Any escalation whose cooldown has elapsed has already been pruned
+			mu.Unlock()
+			return nil
+		}
+		if found {
+			idle.MoveToFront(e.elem)
+		} else {
+			if len(escalations) >= maxFingerprints {
+				evictEscalation(escalations, cooling, idle)
+			}
+			e = &escalation{key: key}
+			e.elem = idle.PushFront(e)
+			escalations[key] = e
+		}
+		e.lastSeenAt = now
+		e.occurrences = append(pruneOccurrences(e.occurrences, now.Add(-window)), now)
+		escalate := len(e.occurrences) >= threshold
+		if escalate {
+			e.notifiedAt = now
+			e.occurrences = nil
+			idle.Remove(e.elem)
+			e.elem = cooling.PushFront(e)
+		}
+		mu.Unlock()
+
+		if !escalate {
+			return nil
+		}
+		return notifier(ctx, prob)
+	}
+}
+
+// Fingerprint returns a string that can be used to identify occurrences of the same Problem and is used by an
+// EscalationPolicy by default.
+//
+// Problem.Code is used where present as it uniquely identifies a specific problem, otherwise the fingerprint is
+// derived from Problem.Status, Problem.Type, and Problem.Title.
+func Fingerprint(prob *Problem) string {
+	if prob == nil {
+		return ""
+	}
+	if prob.Code != "" {
+		return string(prob.Code)
+	}
+	var sb strings.Builder
+	sb.WriteString(strconv.FormatInt(int64(prob.Status), 10))
+	sb.WriteRune(' ')
+	sb.WriteString(prob.Type)
+	sb.WriteRune(' ')
+	sb.WriteString(prob.Title)
+	return sb.String()
+}
+
+// evictEscalation deletes the escalation that was least recently seen and is not cooling down from the given
+// escalations, otherwise the escalation whose cooldown ends soonest.
+func evictEscalation(escalations map[string]*escalation, cooling, idle *list.List) {
+	elem := idle.Back()
+	l := idle
+	if elem == nil {
+		elem = cooling.Back()
+		l = cooling
+	}
+	if elem != nil {
+		delete(escalations, l.Remove(elem).(*escalation).key)
+	}
+}
+
+// pruneEscalations deletes each escalation from the given escalations that is cooling down but whose cooldown has
+// elapsed, or is not cooling down but has not been seen within the window, as any state it contains no longer has any
+// effect.
+//
+// Since cooling and idle are ordered, only expired escalations at the back of each are visited.
+func pruneEscalations(escalations map[string]*escalation, cooling, idle *list.List, now time.Time, window, cooldown time.Duration) {
+	for elem := cooling.Back(); elem != nil && now.Sub(elem.Value.(*escalation).notifiedAt) >= cooldown; elem = cooling.Back() {
+		delete(escalations, cooling.Remove(elem).(*escalation).key)
+	}
+	for elem := idle.Back(); elem != nil && now.Sub(elem.Value.(*escalation).lastSeenAt) >= window; elem = idle.Back() {
+		delete(escalations, idle.Remove(elem).(*escalation).key)
+	}
+}
+
+// pruneOccurrences returns a slice containing only the occurrences that occurred after the given time.
+func pruneOccurrences(occurrences []time.Time, after time.Time) []time.Time {
+	i := 0
+	for i < len(occurrences) && !occurrences[i].After(after) {
+		i++
+	}
+	return occurrences[i:]
+}
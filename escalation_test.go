@@ -0,0 +1,57 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package problem
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func Test_EscalatingNotifier(t *testing.T) {
+	var notified []Code
+	notifier := EscalatingNotifier(func(_ context.Context, prob *Problem) error {
+		notified = append(notified, prob.Code)
+		return nil
+	}, EscalationPolicy{Threshold: 2, Window: time.Hour})
+
+	for _, code := range []Code{"a", "b", "a", "a", "b", "a"} {
+		require.NoError(t, notifier(context.Background(), &Problem{Code: code}))
+	}
+
+	assert.Equal(t, []Code{"a", "b"}, notified)
+}
+
+func Test_EscalatingNotifier_MaxFingerprints(t *testing.T) {
+	var notified []Code
+	notifier := EscalatingNotifier(func(_ context.Context, prob *Problem) error {
+		notified = append(notified, prob.Code)
+		return nil
+	}, EscalationPolicy{MaxFingerprints: 2, Threshold: 2, Window: time.Hour})
+
+	for _, code := range []Code{"a", "a", "b", "c", "a", "a", "c"} {
+		require.NoError(t, notifier(context.Background(), &Problem{Code: code}))
+	}
+
+	assert.Equal(t, []Code{"a", "c"}, notified, "expected fingerprint cooling down to not be evicted or notified again")
+}
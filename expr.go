@@ -0,0 +1,479 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package problem
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// MatcherExpr is an expression that can be parsed into a Matcher, allowing matchers to be declared within
+// configuration (e.g. YAML or JSON) rather than code. For example; for routing alerts or notifications.
+//
+// An expression consists of one or more conditions that can be combined using "&&" (and) and "||" (or), negated using
+// "!", and grouped using parentheses, where "&&" takes precedence over "||". Each condition is either the name of a
+// field followed by an operator and a value, or the name of a field that is checked for presence. For example;
+//
+//	status>=500 && type~"https://example.com/problems/payments/*"
+//	(code.ns=="USER" || code=="AUTH-1") && !stack
+//	extension.traceId && detail*="timeout"
+//
+// The following fields are supported along with the type of value expected:
+//   - code (string) — see HasCode
+//   - code.ns (string) — see HasCodeNS
+//   - code.value (unsigned integer) — see HasCodeValue
+//   - detail (string) — see HasDetail
+//   - instance (string) — see HasInstance
+//   - status (integer) — see HasStatus
+//   - title (string) — see HasTitle
+//   - type (string) — see HasType
+//
+// The following fields are supported only as presence checks:
+//   - extension.<key> — see HasExtension
+//   - stack — see HasStack
+//   - uuid — see HasUUID
+//
+// The following operators are supported:
+//   - == (OperatorEquals)
+//   - != (OperatorNotEquals)
+//   - > (OperatorGreaterThan)
+//   - >= (OperatorGreaterThanOrEqual)
+//   - < (OperatorLessThan)
+//   - <= (OperatorLessThanOrEqual)
+//   - ^= (OperatorHasPrefix)
+//   - $= (OperatorHasSuffix)
+//   - *= (OperatorContains)
+//   - ~ (OperatorGlob)
+//   - !~ (negated OperatorGlob)
+//
+// String values must be double-quoted using Go syntax (e.g. "payments/*"), while integer values must not be quoted.
+//
+// MatcherExpr implements encoding.TextMarshaler and encoding.TextUnmarshaler, where the expression is validated when
+// unmarshalled, so that an invalid expression within configuration is reported when it is loaded.
+type MatcherExpr string
+
+// ErrMatcherExpr is returned when a MatcherExpr cannot be parsed.
+var ErrMatcherExpr = errors.New("invalid matcher expression")
+
+var (
+	_ fmt.Stringer = MatcherExpr("")
+	// exprOperators contains the operators supported within a MatcherExpr, mapped to their Operator, ordered such that
+	// longer operators are checked before any shorter operators that they start with.
+	exprOperators = []struct {
+		op     Operator
+		negate bool
+		token  string
+	}{
+		{token: "==", op: OperatorEquals},
+		{token: "!=", op: OperatorNotEquals},
+		{token: ">=", op: OperatorGreaterThanOrEqual},
+		{token: "<=", op: OperatorLessThanOrEqual},
+		{token: "^=", op: OperatorHasPrefix},
+		{token: "$=", op: OperatorHasSuffix},
+		{token: "*=", op: OperatorContains},
+		{token: "!~", op: OperatorGlob, negate: true},
+		{token: ">", op: OperatorGreaterThan},
+		{token: "<", op: OperatorLessThan},
+		{token: "~", op: OperatorGlob},
+	}
+)
+
+// Matcher returns a Matcher parsed from the MatcherExpr using DefaultGenerator.
+//
+// An ErrMatcherExpr is returned if the MatcherExpr cannot be parsed.
+func (me MatcherExpr) Matcher() (Matcher, error) {
+	return ParseMatcherUsing(DefaultGenerator, string(me))
+}
+
+// MatcherUsing returns a Matcher parsed from the MatcherExpr using the given Generator, which is used to parse the Code
+// of a Problem for the "code.ns" and "code.value" fields.
+//
+// An ErrMatcherExpr is returned if the MatcherExpr cannot be parsed.
+func (me MatcherExpr) MatcherUsing(gen *Generator) (Matcher, error) {
+	return ParseMatcherUsing(gen, string(me))
+}
+
+// MarshalText marshals the MatcherExpr into text.
+func (me MatcherExpr) MarshalText() ([]byte, error) {
+	return []byte(me), nil
+}
+
+// String returns the MatcherExpr as a string.
+func (me MatcherExpr) String() string {
+	return string(me)
+}
+
+// UnmarshalText unmarshals the given text into the MatcherExpr.
+//
+// An ErrMatcherExpr is returned if text cannot be parsed into a Matcher.
+func (me *MatcherExpr) UnmarshalText(text []byte) error {
+	if _, err := ParseMatcher(string(text)); err != nil {
+		return err
+	}
+	*me = MatcherExpr(text)
+	return nil
+}
+
+// ParseMatcher returns a Matcher parsed from the given expression using DefaultGenerator. See MatcherExpr for the
+// supported syntax.
+//
+// An ErrMatcherExpr is returned if expr cannot be parsed.
+//
+// For example;
+//
+//	m, err := ParseMatcher(`status>=500 && type~"https://example.com/problems/payments/*"`)
+//	if err != nil {
+//		// ...
+//	}
+//	if IsMatch(err, m) {
+//		// ...
+//	}
+func ParseMatcher(expr string) (Matcher, error) {
+	return ParseMatcherUsing(DefaultGenerator, expr)
+}
+
+// ParseMatcherUsing returns a Matcher parsed from the given expression using the given Generator, which is used to parse
+// the Code of a Problem for the "code.ns" and "code.value" fields. See MatcherExpr for the supported syntax.
+//
+// An ErrMatcherExpr is returned if expr cannot be parsed.
+func ParseMatcherUsing(gen *Generator, expr string) (Matcher, error) {
+	p := &exprParser{gen: gen, input: expr}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	m, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != exprTokenEOF {
+		return nil, p.unexpected()
+	}
+	return m, nil
+}
+
+type (
+	// exprParser is a recursive descent parser for a MatcherExpr.
+	exprParser struct {
+		// gen is the Generator used to parse the Code of a Problem.
+		gen *Generator
+		// input is the expression being parsed.
+		input string
+		// pos is the offset within input from which the next token is to be read.
+		pos int
+		// tok is the current token.
+		tok exprToken
+	}
+
+	// exprToken is a token read from a MatcherExpr.
+	exprToken struct {
+		// kind is the kind of token.
+		kind exprTokenKind
+		// pos is the offset within the expression at which the token starts.
+		pos int
+		// text is the text of the token, where a string token contains its unquoted value.
+		text string
+	}
+
+	// exprTokenKind is the kind of exprToken.
+	exprTokenKind uint8
+)
+
+const (
+	exprTokenEOF exprTokenKind = iota
+	exprTokenIdent
+	exprTokenNumber
+	exprTokenString
+	exprTokenPunct
+)
+
+// next reads the next token into exprParser.tok.
+//
+// An ErrMatcherExpr is returned if the next token is invalid.
+func (p *exprParser) next() error {
+	for p.pos < len(p.input) && unicode.IsSpace(rune(p.input[p.pos])) {
+		p.pos++
+	}
+	start := p.pos
+	if start >= len(p.input) {
+		p.tok = exprToken{kind: exprTokenEOF, pos: start}
+		return nil
+	}
+	rest := p.input[start:]
+	c := rest[0]
+	switch {
+	case c == '"':
+		quoted, err := strconv.QuotedPrefix(rest)
+		if err != nil {
+			return fmt.Errorf("%w: unterminated string at offset %d", ErrMatcherExpr, start)
+		}
+		text, err := strconv.Unquote(quoted)
+		if err != nil {
+			return fmt.Errorf("%w: invalid string at offset %d", ErrMatcherExpr, start)
+		}
+		p.pos += len(quoted)
+		p.tok = exprToken{kind: exprTokenString, pos: start, text: text}
+		return nil
+	case c >= '0' && c <= '9' || c == '-' && len(rest) > 1 && rest[1] >= '0' && rest[1] <= '9':
+		end := 1
+		for end < len(rest) && rest[end] >= '0' && rest[end] <= '9' {
+			end++
+		}
+		p.pos += end
+		p.tok = exprToken{kind: exprTokenNumber, pos: start, text: rest[:end]}
+		return nil
+	case isExprIdentByte(c, true):
+		end := 1
+		for end < len(rest) && isExprIdentByte(rest[end], false) {
+			end++
+		}
+		p.pos += end
+		p.tok = exprToken{kind: exprTokenIdent, pos: start, text: rest[:end]}
+		return nil
+	}
+	for _, punct := range []string{"&&", "||", "(", ")"} {
+		if strings.HasPrefix(rest, punct) {
+			p.pos += len(punct)
+			p.tok = exprToken{kind: exprTokenPunct, pos: start, text: punct}
+			return nil
+		}
+	}
+	for _, eo := range exprOperators {
+		if strings.HasPrefix(rest, eo.token) {
+			p.pos += len(eo.token)
+			p.tok = exprToken{kind: exprTokenPunct, pos: start, text: eo.token}
+			return nil
+		}
+	}
+	if c == '!' {
+		p.pos++
+		p.tok = exprToken{kind: exprTokenPunct, pos: start, text: "!"}
+		return nil
+	}
+	return fmt.Errorf("%w: unexpected character %q at offset %d", ErrMatcherExpr, c, start)
+}
+
+// parseAnd parses one or more unary expressions separated by "&&".
+func (p *exprParser) parseAnd() (Matcher, error) {
+	m, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	matchers := []Matcher{m}
+	for p.isPunct("&&") {
+		if err = p.next(); err != nil {
+			return nil, err
+		}
+		if m, err = p.parseUnary(); err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, m)
+	}
+	if len(matchers) == 1 {
+		return matchers[0], nil
+	}
+	return func(prob *Problem) bool {
+		return Match(prob, matchers...)
+	}, nil
+}
+
+// parseCondition parses a single condition, being a field optionally followed by an operator and a value.
+func (p *exprParser) parseCondition() (Matcher, error) {
+	if p.tok.kind != exprTokenIdent {
+		return nil, p.unexpected()
+	}
+	field := p.tok
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	if key, isExt := strings.CutPrefix(field.text, "extension."); isExt && key != "" {
+		return HasExtension(key), nil
+	}
+	switch field.text {
+	case "stack":
+		return HasStack(), nil
+	case "uuid":
+		return HasUUID(), nil
+	}
+
+	var (
+		negate bool
+		op     Operator
+		found  bool
+	)
+	if p.tok.kind == exprTokenPunct {
+		for _, eo := range exprOperators {
+			if eo.token == p.tok.text {
+				negate, op, found = eo.negate, eo.op, true
+				break
+			}
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: expected operator after %q at offset %d", ErrMatcherExpr, field.text, p.tok.pos)
+	}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	value := p.tok
+
+	var m Matcher
+	switch field.text {
+	case "code", "code.ns", "detail", "instance", "title", "type":
+		if value.kind != exprTokenString {
+			return nil, fmt.Errorf("%w: expected string value for %q at offset %d", ErrMatcherExpr, field.text, value.pos)
+		}
+		switch field.text {
+		case "code":
+			m = HasCode(Code(value.text), op)
+		case "code.ns":
+			m = HasCodeNSUsing(p.gen, NS(value.text), op)
+		case "detail":
+			m = HasDetail(value.text, op)
+		case "instance":
+			m = HasInstance(value.text, op)
+		case "title":
+			m = HasTitle(value.text, op)
+		case "type":
+			m = HasType(value.text, op)
+		}
+	case "code.value":
+		if value.kind != exprTokenNumber {
+			return nil, fmt.Errorf("%w: expected unsigned integer value for %q at offset %d", ErrMatcherExpr, field.text, value.pos)
+		}
+		u, err := strconv.ParseUint(value.text, 10, 0)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid unsigned integer %q at offset %d", ErrMatcherExpr, value.text, value.pos)
+		}
+		m = HasCodeValueUsing(p.gen, uint(u), op)
+	case "status":
+		if value.kind != exprTokenNumber {
+			return nil, fmt.Errorf("%w: expected integer value for %q at offset %d", ErrMatcherExpr, field.text, value.pos)
+		}
+		i, err := strconv.Atoi(value.text)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid integer %q at offset %d", ErrMatcherExpr, value.text, value.pos)
+		}
+		m = HasStatus(i, op)
+	default:
+		return nil, fmt.Errorf("%w: unknown field %q at offset %d", ErrMatcherExpr, field.text, field.pos)
+	}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	if negate {
+		return negateMatcher(m), nil
+	}
+	return m, nil
+}
+
+// parseOr parses one or more "and" expressions separated by "||".
+func (p *exprParser) parseOr() (Matcher, error) {
+	m, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	matchers := []Matcher{m}
+	for p.isPunct("||") {
+		if err = p.next(); err != nil {
+			return nil, err
+		}
+		if m, err = p.parseAnd(); err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, m)
+	}
+	if len(matchers) == 1 {
+		return matchers[0], nil
+	}
+	return Or(matchers...), nil
+}
+
+// parseUnary parses a negated expression, a parenthesized expression, or a single condition.
+func (p *exprParser) parseUnary() (Matcher, error) {
+	switch {
+	case p.isPunct("!"):
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		m, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return negateMatcher(m), nil
+	case p.isPunct("("):
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		m, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.isPunct(")") {
+			return nil, p.unexpected()
+		}
+		if err = p.next(); err != nil {
+			return nil, err
+		}
+		return m, nil
+	default:
+		return p.parseCondition()
+	}
+}
+
+// isPunct returns whether the current token is the given punctuation.
+func (p *exprParser) isPunct(text string) bool {
+	return p.tok.kind == exprTokenPunct && p.tok.text == text
+}
+
+// unexpected returns an ErrMatcherExpr for the current token.
+func (p *exprParser) unexpected() error {
+	if p.tok.kind == exprTokenEOF {
+		return fmt.Errorf("%w: unexpected end of expression", ErrMatcherExpr)
+	}
+	text := p.tok.text
+	if p.tok.kind == exprTokenString {
+		text = strconv.Quote(text)
+	}
+	return fmt.Errorf("%w: unexpected %q at offset %d", ErrMatcherExpr, text, p.tok.pos)
+}
+
+// isExprIdentByte returns whether the given byte can be used within a field name of a MatcherExpr, where first
+// indicates whether it is the first byte of the name.
+func isExprIdentByte(c byte, first bool) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '_':
+		return true
+	case c >= '0' && c <= '9', c == '.', c == '-':
+		return !first
+	default:
+		return false
+	}
+}
+
+// negateMatcher returns a Matcher that matches a Problem only if the given Matcher does not.
+func negateMatcher(m Matcher) Matcher {
+	return func(p *Problem) bool {
+		return !m(p)
+	}
+}
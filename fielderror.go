@@ -0,0 +1,63 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package problem
+
+// ExtensionFieldErrors is the key of the extension containing a FieldError for each field of a request that failed
+// validation, as recommended by RFC 9457 for problems that describe multiple validation errors. See
+// Builder.FieldErrors and WithFieldErrors for more information.
+const ExtensionFieldErrors = "errors"
+
+// FieldError describes a single validation error for a field of a request that resulted in a Problem. It is contained
+// within a Problem using the ExtensionFieldErrors extension.
+//
+// All fields are optional, however, a FieldError is most useful when it contains at least a Detail along with either a
+// Field or Pointer.
+type FieldError struct {
+	// Code is an application-specific code for the validation error (e.g. "required"), which can be used by a client to
+	// react to the error programmatically without having to parse Detail.
+	Code string `json:"code,omitempty" xml:"code,omitempty"`
+	// Detail is a human-readable explanation specific to the validation error.
+	Detail string `json:"detail,omitempty" xml:"detail,omitempty"`
+	// Field is the name of the field that failed validation (e.g. "email" or "address.postcode"), typically as it is
+	// known to users (e.g. within a form).
+	Field string `json:"field,omitempty" xml:"field,omitempty"`
+	// Pointer is a JSON Pointer (RFC 6901) to the field within the request body that failed validation (e.g.
+	// "/address/postcode").
+	Pointer string `json:"pointer,omitempty" xml:"pointer,omitempty"`
+}
+
+// fieldErrorFromValue returns a FieldError derived from the given value, which is typically an object decoded from JSON
+// or XML, if possible.
+func fieldErrorFromValue(value any) (FieldError, bool) {
+	switch v := value.(type) {
+	case FieldError:
+		return v, true
+	case map[string]any:
+		var fe FieldError
+		fe.Code, _ = v["code"].(string)
+		fe.Detail, _ = v["detail"].(string)
+		fe.Field, _ = v["field"].(string)
+		fe.Pointer, _ = v["pointer"].(string)
+		return fe, true
+	default:
+		return FieldError{}, false
+	}
+}
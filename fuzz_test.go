@@ -0,0 +1,97 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package problem
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func FuzzDecodeXML(f *testing.F) {
+	f.Add([]byte(`<problem xmlns="urn:ietf:rfc:9457"><status>404</status><title>Not Found</title><type>about:blank</type></problem>`))
+	f.Add([]byte(`<problem><code>USER-404</code><detail>User not found</detail><instance>/users/123</instance></problem>`))
+	f.Add([]byte(`<problem><status>abc</status></problem>`))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var p Problem
+		if err := xml.Unmarshal(data, &p); err != nil {
+			return
+		}
+		b, err := xml.Marshal(&p)
+		require.NoError(t, err, "expected decoded problem to be marshaled")
+		var p2 Problem
+		require.NoError(t, xml.Unmarshal(b, &p2), "expected marshaled problem to be decoded")
+		assert.Equal(t, p.Status, p2.Status, "expected status to survive round-trip")
+	})
+}
+
+func FuzzParseCode(f *testing.F) {
+	f.Add("USER-404")
+	f.Add("USER-")
+	f.Add("-404")
+	f.Add("USER-404-1")
+	f.Add("USER-18446744073709551616")
+	f.Fuzz(func(t *testing.T, code string) {
+		parsed, err := ParseCode(Code(code))
+		if err != nil {
+			return
+		}
+		require.NotEmpty(t, parsed.NS, "expected parsed NS to not be empty")
+		require.False(t, strings.ContainsRune(string(parsed.NS), DefaultCodeSeparator), "expected parsed NS to not contain separator")
+		rebuilt, err := BuildCode(parsed.Value, parsed.NS)
+		require.NoError(t, err, "expected code to be rebuilt from parsed code")
+		reparsed, err := ParseCode(rebuilt)
+		require.NoError(t, err, "expected rebuilt code to be parsed")
+		assert.Equal(t, parsed.NS, reparsed.NS, "expected NS to survive round-trip")
+		assert.Equal(t, parsed.Value, reparsed.Value, "expected value to survive round-trip")
+	})
+}
+
+func FuzzUnmarshalJSON(f *testing.F) {
+	f.Add([]byte(`{"type":"about:blank","title":"Not Found","status":404}`))
+	f.Add([]byte(`{"code":"USER-404","detail":"User not found","instance":"/users/123","foo":{"bar":[1,2,3]}}`))
+	f.Add([]byte(`{"status":"404"}`))
+	f.Add([]byte(`null`))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var p Problem
+		if err := json.Unmarshal(data, &p); err != nil {
+			return
+		}
+		b, err := json.Marshal(&p)
+		if err != nil {
+			// Only extensions with invalid keys are expected to prevent marshaling
+			require.ErrorIs(t, err, errExtensionKeyEmpty, "expected only invalid extension key errors")
+			return
+		}
+		var p2 Problem
+		require.NoError(t, json.Unmarshal(b, &p2), "expected marshaled problem to be unmarshaled")
+		assert.Equal(t, p.Code, p2.Code, "expected code to survive round-trip")
+		assert.Equal(t, p.Detail, p2.Detail, "expected detail to survive round-trip")
+		assert.Equal(t, p.Instance, p2.Instance, "expected instance to survive round-trip")
+		assert.Equal(t, p.Status, p2.Status, "expected status to survive round-trip")
+		assert.Equal(t, p.Title, p2.Title, "expected title to survive round-trip")
+		assert.Equal(t, p.Type, p2.Type, "expected type to survive round-trip")
+		assert.Equal(t, len(p.Extensions), len(p2.Extensions), "expected extensions to survive round-trip")
+	})
+}
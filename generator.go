@@ -20,8 +20,38 @@
 
 package problem
 
+import "golang.org/x/text/language"
+
 // Generator is responsible for generating a Problem. Its zero value (DefaultGenerator) is usable.
 type Generator struct {
+	// AllowedTypeURIs contains the patterns of the only type URI references that may be emitted by a Problem written as
+	// an HTTP response, which can be used to prevent a service from accidentally exposing unregistered or internal
+	// type URIs on its public API. A pattern ending with "*" matches any type URI starting with the preceding prefix,
+	// while any other pattern must match a type URI exactly. DefaultTypeURI is always allowed.
+	//
+	// A Problem whose type URI is not allowed is written with DefaultTypeURI in its place, although the Problem itself
+	// is never modified and so its original type URI remains visible within logs. When used in combination with
+	// Generator.StrictMode, Builder.Problem (and therefore Generator.New etc.) also panics if the type URI of the
+	// Problem is not allowed, surfacing the problem long before it reaches a client.
+	//
+	// If empty, any type URI is allowed.
+	//
+	// For example;
+	//
+	//	g := &Generator{AllowedTypeURIs: []string{
+	//		"https://example.com/problems/*",
+	//		"tag:example.com,2024:problem",
+	//	}}
+	AllowedTypeURIs []string
+	// Clock is the Clock used to return the current time when generating the timestamp of a Problem (see
+	// Generator.TimestampFlag), which can be useful for controlling the time within tests or using UTC.
+	//
+	// If nil, time.Now is used. A timestamp is only generated for a Problem if needed.
+	//
+	// For example;
+	//
+	//	g := &Generator{Clock: UTCClock(), TimestampFlag: FlagField | FlagLog}
+	Clock Clock
 	// CodeNSValidator is the NSValidator used to perform additional validation on a NS used within a Code constructed
 	// and/or parsed by a Coder.
 	//
@@ -66,8 +96,232 @@ type Generator struct {
 	// Generator.WriteProblem are called without a WriteOptions.ContentType being passed. This also applies to the
 	// Middleware functions as they call Generator.WriteError internally.
 	//
-	// If empty, ContentTypeJSONUTF8 will be used.
+	// It may be any content/media type supported by the Generator, including those within Generator.ContentTypes. If
+	// empty or unsupported, ContentTypeJSONUTF8 will be used.
+	//
+	// If Generator.NegotiateContentType is enabled, ContentType is only used when the Accept header of the HTTP request
+	// does not prefer another supported content/media type. Likewise, Profile.ContentType of any Profile used takes
+	// precedence over ContentType.
 	ContentType string
+	// ContentTypes contains any additional content/media types that are acceptable when writing a Problem as an HTTP
+	// response (e.g. via Generator.WriteProblem or WriteOptions.ContentType), each mapped to the Format in which a
+	// Problem is to be encoded. This allows vendor-specific media types to be used. Content/media types are matched
+	// exactly and the built-in content/media types (e.g. ContentTypeJSON) are always supported.
+	//
+	// If empty, only the built-in content/media types are supported.
+	//
+	// For example;
+	//
+	//	g := &Generator{
+	//		ContentType:  "application/vnd.company.problem+json;v=2",
+	//		ContentTypes: map[string]Format{"application/vnd.company.problem+json;v=2": FormatJSON},
+	//	}
+	ContentTypes map[string]Format
+	// DebugMode is whether information that is ordinarily only visible within logs is to be included when a Problem is
+	// written as an HTTP response (e.g. via Generator.WriteError or Generator.WriteProblem), which can be useful during
+	// local development.
+	//
+	// When enabled, the message of any wrapped error is included within the extensions (see ExtensionDebugError) along
+	// with any stack trace and UUID that would otherwise only be visible within logs (see Generator.StackFlag and
+	// Generator.UUIDFlag).
+	//
+	// To reduce the risk of accidentally leaking such information, DebugMode only takes effect when built with the
+	// problemdebug build tag (e.g. go run -tags problemdebug .) and is always ignored otherwise. As such, it is safe to
+	// enable DebugMode unconditionally as it will never take effect in a release build unless explicitly tagged.
+	//
+	// For example;
+	//
+	//	g := &Generator{DebugMode: true, StackFlag: FlagLog}
+	DebugMode bool
+	// DefaultProblemFactory is the ProblemFactory used to provide a Problem for an error that does not contain a Problem
+	// within its tree whenever Generator.WriteError or any of the Middleware functions are called without a function to
+	// provide a default Problem. This avoids having to pass the same function at every mount point.
+	//
+	// If nil, a Problem is generated by the Generator that simply wraps the error, which will typically represent an
+	// internal server error.
+	//
+	// For example;
+	//
+	//	g := &Generator{DefaultProblemFactory: func(err error) *Problem {
+	//		return http.InternalServerDefinition.New(Wrap(err))
+	//	}}
+	//	handler := MiddlewareUsing(g, nil)(mux)
+	DefaultProblemFactory ProblemFactory
+	// DetailComposer is the DetailComposer used to compose the detail of a Problem that wraps an error (e.g. via
+	// Builder.Wrap or Wrap) from its resolved detail and the wrapped error, avoiding the need to format the message of
+	// the wrapped error into the detail at each call site. This is typically only desirable for a Generator whose
+	// problems are only visible internally, as the message of a wrapped error may contain sensitive information.
+	//
+	// The composed detail is still subject to Generator.DetailMaxLen.
+	//
+	// If nil, the detail of a Problem is never composed with the wrapped error.
+	//
+	// For example;
+	//
+	//	g := &Generator{DetailComposer: AppendErrorDetailComposer()}
+	//	g.New(WithDetail("user not found"), Wrap(sql.ErrNoRows)).Detail  // "user not found: sql: no rows in result set"
+	DetailComposer DetailComposer
+	// DetailMaxLen is the maximum number of characters (i.e. runes) permitted within the detail of a Problem when it is
+	// built, where any detail exceeding DetailMaxLen is truncated with an ellipsis. This prevents overly verbose details
+	// (e.g. from wrapped error messages containing SQL) from being leaked to clients.
+	//
+	// If zero or less, the length of a detail is not limited.
+	//
+	// For example;
+	//
+	//	g := &Generator{DetailMaxLen: 16}
+	//	g.New(WithDetail("Something went terribly wrong")).Detail  // "Something went …"
+	DetailMaxLen int
+	// Encoders contains any additional content/media types that are acceptable when writing a Problem as an HTTP
+	// response (e.g. via Generator.WriteProblem or WriteOptions.ContentType), each mapped to the Encoder used to
+	// encode a Problem in an entirely custom format (e.g. protobuf, msgpack, or HTML). Generator.RegisterEncoder may be
+	// used to populate Encoders.
+	//
+	// Content/media types are matched exactly and an Encoder is only used for a content/media type that is not
+	// already supported (i.e. the built-in content/media types and those within Generator.ContentTypes).
+	//
+	// If empty, no custom formats are supported.
+	//
+	// For example;
+	//
+	//	g := &Generator{}
+	//	g.RegisterEncoder("application/problem+msgpack", func(w io.Writer, p *Problem) error {
+	//		return msgpack.NewEncoder(w).Encode(p)
+	//	})
+	Encoders map[string]Encoder
+	// ErrorMappers contains each ErrorMapper, in order, used to map an error that does not contain a Problem within its
+	// tree to a Definition, allowing errors from any library (e.g. sql.ErrNoRows) or domain to be mapped centrally
+	// instead of within every handler. The Definition provided by the first ErrorMapper to map an error is used.
+	//
+	// ErrorMappers are consulted by Builder.Wrap (and therefore Wrap etc.) when no Definition has been provided (see
+	// Builder.Definition and Builder.DefinitionType), in which case the Definition is used as if it had been, and by
+	// Generator.WriteError (and therefore the Middleware functions etc.) before any function to provide a default
+	// Problem, including Generator.DefaultProblemFactory.
+	//
+	// If empty, errors are never mapped to a Definition.
+	//
+	// For example;
+	//
+	//	g := &Generator{ErrorMappers: []ErrorMapper{
+	//		ErrorIsMapper(sql.ErrNoRows, http.NotFoundDefinition),
+	//		ErrorIsMapper(context.DeadlineExceeded, http.GatewayTimeoutDefinition),
+	//	}}
+	ErrorMappers []ErrorMapper
+	// ExtensionMergeStrategy is the MergeStrategy used to combine extensions from multiple sources (i.e. explicitly
+	// defined, unwrapped from a Problem, and derived from a Definition) when building a Problem.
+	//
+	// If zero, MergeStrategyReplace will be used, where only the extensions from the source with the highest precedence
+	// are used.
+	//
+	// For example;
+	//
+	//	def := Definition{Extensions: map[string]any{"docs": "https://docs.example.void"}}
+	//	g := &Generator{ExtensionMergeStrategy: MergeStrategyShallow}
+	//	def.NewUsing(g, WithExtension("userId", 123)).Extensions  // {"docs": "https://docs.example.void", "userId": 123}
+	ExtensionMergeStrategy MergeStrategy
+	// ExtensionPredicates contains an ExtensionPredicate for any extension key that is only to be included when a
+	// Problem is written as an HTTP response (e.g. via Generator.WriteError or Generator.WriteProblem) if the
+	// ExtensionPredicate returns true at that time. Generator.RegisterExtensionPredicate may be used to populate
+	// ExtensionPredicates.
+	//
+	// The Problem itself is never modified, so it is still logged and passed to any Notifier in its entirety.
+	//
+	// If empty, or no ExtensionPredicate exists for an extension key, the extension is always included.
+	//
+	// For example;
+	//
+	//	g := &Generator{}
+	//	g.RegisterExtensionPredicate("debugInfo", func(ctx context.Context, _ *Problem) bool {
+	//		return isSupportRequest(ctx)
+	//	})
+	ExtensionPredicates map[string]ExtensionPredicate
+	// ExtensionSanitizer is used to sanitize the extensions of a Problem, including any nested within the value of an
+	// extension, so that sensitive data (e.g. passwords, tokens, email addresses) that has been included accidentally is
+	// either dropped or masked before it ever reaches an HTTP response or a log.
+	//
+	// ExtensionSanitizer is applied when the Problem is built and again whenever the Problem is marshaled or logged, so
+	// that any extension set on the Problem afterward (e.g. via Problem.SetExtension) is also sanitized.
+	// DropExtensionSanitizer and MaskExtensionSanitizer may be used to sanitize extensions by key pattern, and
+	// ComposeExtensionSanitizer to combine them.
+	//
+	// If nil, extensions are never sanitized.
+	//
+	// For example;
+	//
+	//	g := &Generator{ExtensionSanitizer: ComposeExtensionSanitizer(
+	//		DropExtensionSanitizer("password"),
+	//		MaskExtensionSanitizer("", "email", "token"),
+	//	)}
+	//	g.New(WithExtension("email", "jo@example.void")).Extensions  // {"email": "[REDACTED]"}
+	ExtensionSanitizer ExtensionSanitizer
+	// FallbackJSON is the pre-encoded body written to an HTTP response in place of a Problem in JSON format whenever the
+	// Problem fails to be encoded (e.g. an extension value cannot be marshaled). This ensures that a client never
+	// receives an HTTP response without a body, even though the headers have already been written.
+	//
+	// If empty, DefaultFallbackJSON will be used.
+	//
+	// For example;
+	//
+	//	g := &Generator{FallbackJSON: `{"title":"Internal Server Error","type":"https://errors.example.void/internal"}`}
+	FallbackJSON string
+	// FallbackXML is the pre-encoded body written to an HTTP response in place of a Problem in XML format whenever the
+	// Problem fails to be encoded (e.g. an extension value cannot be marshaled). This ensures that a client never
+	// receives an HTTP response without a body, even though the headers have already been written.
+	//
+	// If empty, DefaultFallbackXML will be used.
+	FallbackXML string
+	// Hooks contains functions that are called at various points within the lifecycle of a Problem (i.e. before and
+	// after it is built, before it is written as an HTTP response, and after it is logged), allowing cross-cutting
+	// concerns (e.g. metrics, enrichment, auditing, redaction) to be attached once rather than at every call site.
+	//
+	// If empty, no hooks are called.
+	//
+	// For example;
+	//
+	//	g := &Generator{Hooks: Hooks{
+	//		AfterBuild: []AfterBuildHook{func(ctx context.Context, prob *Problem) {
+	//			prob.SetExtension("region", os.Getenv("REGION"))
+	//		}},
+	//		BeforeWrite: []BeforeWriteHook{func(prob *Problem, w http.ResponseWriter, req *http.Request) {
+	//			w.Header().Set("X-Problem-Code", string(prob.Code))
+	//		}},
+	//	}}
+	Hooks Hooks
+	// I18NOutputMode is the I18NOutputMode used to control how localized values are output within a Problem.
+	//
+	// When I18NOutputDual is used, the title and detail of a Problem are resolved as if Generator.Translator was nil,
+	// allowing clients to rely on them being machine-stable, while the localized title and detail (falling back to
+	// their non-localized values) are included within its extensions, using ExtensionLocalizedTitle and
+	// ExtensionLocalizedDetail respectively, for presenting to users. However, neither will replace an existing
+	// extension with the same key.
+	//
+	// If zero, I18NOutputLocalized is used.
+	//
+	// For example;
+	//
+	//	g := &Generator{I18NOutputMode: I18NOutputDual, Translator: translator}
+	//	p := g.New(WithTitleKeyOrElse("problem.user.notFound.title", "User Not Found"))
+	//	p.Title                                // "User Not Found"
+	//	p.Extensions[ExtensionLocalizedTitle]  // "Utilisateur introuvable"
+	I18NOutputMode I18NOutputMode
+	// LanguageFallbacks contains the chain of language.Tag to be consulted, in order, whenever Generator.Translator
+	// fails to find a localized value for a translation key, before falling back to any non-localized value.
+	//
+	// For each language.Tag, Generator.Translator is called with a context.Context containing it, and so the
+	// Translator is expected to use GetLanguage to determine the language in which the value is to be localized. Any
+	// language.Tag matching that already within the context.Context is skipped.
+	//
+	// If empty, no fallback languages are consulted.
+	//
+	// For example;
+	//
+	//	g := &Generator{
+	//		LanguageFallbacks: []language.Tag{language.French, language.English},
+	//		Translator:        translator,
+	//	}
+	//	ctx := UsingLanguage(context.Background(), language.CanadianFrench)
+	//	g.NewContext(ctx, WithTitleKey("problem.user.notFound.title"))  // tries fr-CA, then fr, then en
+	LanguageFallbacks []language.Tag
 	// LogArgKey is the key passed along with a Problem within the last two arguments to Generator.Logger.
 	//
 	// If empty, DefaultLogArgKey will be passed.
@@ -103,6 +357,197 @@ type Generator struct {
 	//	logger := slog.NewLogLogger(slog.NewJSONHandler(os.Stderr, nil), slog.LevelDebug)
 	//	g := &Generator{Logger: LoggerFrom(logger)}
 	Logger Logger
+	// NegotiateContentType is whether the Accept header of an HTTP request, including any quality values, is used to
+	// select the content/media type when Generator.WriteError or Generator.WriteProblem are called without a
+	// WriteOptions.ContentType being passed. This also applies to the Middleware functions as they call
+	// Generator.WriteError internally.
+	//
+	// Any content/media type supported by the Generator may be selected, including those within Generator.ContentTypes
+	// and Generator.Encoders, however, Generator.ContentType is preferred whenever it is at least as acceptable as any
+	// other. If the Accept header is missing or none are acceptable, Generator.ContentType is used with a fallback to
+	// ContentTypeJSONUTF8. A Vary header is also added to the HTTP response.
+	//
+	// If false, Generator.ContentType is always used with a fallback to ContentTypeJSONUTF8.
+	//
+	// For example;
+	//
+	//	g := &Generator{NegotiateContentType: true}
+	//	// Accept: application/problem+xml;q=0.9, application/problem+json;q=0.5
+	//	g.WriteProblem(prob, w, req)  // Content-Type: application/problem+xml; charset=utf-8
+	NegotiateContentType bool
+	// Notifiers contains each NotifierRoute used by Generator.Notify to route a Problem to any matching Notifier
+	// (e.g. webhook, pager).
+	//
+	// If empty, no notifications are sent.
+	//
+	// A Problem has no notion of tags, so any such criteria (e.g. only notifying of "critical" problems) are instead
+	// expressed using matchers (e.g. HasExtension for a "critical" extension, or HasCode).
+	//
+	// For example;
+	//
+	//	g := &Generator{Notifiers: []NotifierRoute{
+	//		{
+	//			Matchers: []Matcher{
+	//				HasStatus(http.StatusInternalServerError, OperatorGreaterThanOrEqual),
+	//				HasExtension("critical"),
+	//			},
+	//			Notifier: WebhookNotifier("https://hooks.example.void/problems"),
+	//		},
+	//	}}
+	Notifiers []NotifierRoute
+	// PoolProblems is whether each Problem built by the Generator is acquired from a pool, allowing it to be returned to
+	// the pool using Problem.Release once it is no longer needed. This is intended for services handling a very large
+	// volume of errors (e.g. gateways writing 100k+ problems per second) where problems are used solely for immediate
+	// serialization, as it saves an allocation per Problem and can therefore reduce GC pressure.
+	//
+	// Problems that are never released are simply garbage collected, so enabling PoolProblems is always safe, however,
+	// care must be taken to never use a Problem after it has been released. See Problem.Release for more information.
+	//
+	// For example;
+	//
+	//	g := &Generator{PoolProblems: true}
+	PoolProblems bool
+	// ProblemSelector is the ProblemSelector used by Builder.Wrap and Wrap to select the primary Problem from those found
+	// within a joined error (i.e. an error implementing Unwrap() []error, such as those returned by errors.Join). All
+	// other problems are recorded within the extensions of the Problem using ExtensionSubProblems as the key. As such,
+	// they should be marshalable if the Problem is to be written as an HTTP response.
+	//
+	// If nil, the first Problem within err's tree is unwrapped (i.e. the same as errors.As) and no others are recorded.
+	//
+	// For example;
+	//
+	//	g := &Generator{ProblemSelector: HighestStatusProblemSelector()}
+	ProblemSelector ProblemSelector
+	// Profiles contains each Profile, mapped to its name, that may be used when writing a Problem as an HTTP response
+	// for a specific audience (e.g. "public", "partner", or "internal"). A Profile bundles a content/media type, a
+	// FieldMask, and any Sanitizer functions, and is selected using WriteOptions.Profile or UsingProfile. This avoids
+	// having to configure multiple generators for each audience.
+	//
+	// If empty, or no Profile exists with the selected name, no Profile is used.
+	//
+	// For example;
+	//
+	//	g := &Generator{Profiles: map[string]Profile{
+	//		"public": {
+	//			FieldMask:  FieldMask{Exclude: []string{"stack", "uuid"}},
+	//			Sanitizers: []Sanitizer{redactDetail},
+	//		},
+	//		"internal": {ContentType: ContentTypeXMLUTF8},
+	//	}}
+	//	g.WriteProblem(prob, w, req, WriteOptions{Profile: "public"})
+	Profiles map[string]Profile
+	// PropagatedExtensions contains the keys of extensions that are to be preserved from a Problem within the tree of
+	// an error passed to Builder.Wrap or Wrap (e.g. a Problem decoded from an upstream service), in addition to any
+	// fields extracted by Generator.Unwrapper.
+	//
+	// Propagated extensions are treated as if unwrapped and so will not take precedence over any explicitly defined
+	// extensions, however, they will take precedence over any extensions derived from a Definition. As such,
+	// Generator.ExtensionMergeStrategy may need to be used to combine them.
+	//
+	// If empty, no extensions are propagated other than those extracted by Generator.Unwrapper.
+	//
+	// For example;
+	//
+	//	g := &Generator{
+	//		ExtensionMergeStrategy: MergeStrategyShallow,
+	//		PropagatedExtensions:   []string{ExtensionUpstream, "traceId"},
+	//	}
+	PropagatedExtensions []string
+	// RFC7807Mode is whether problems are written as HTTP responses in a way that is compatible with RFC 7807, which RFC
+	// 9457 obsoletes, for clients that are pinned to the older specification. Currently, this means that the
+	// Content-Type header of an HTTP response written in JSON or XML format contains no parameters (e.g. charset) as the
+	// media types registered by RFC 7807 define none.
+	//
+	// When used in combination with Generator.StrictMode, Builder.Problem (and therefore Generator.New etc.) also
+	// panics if the Problem does not conform to RFC 7807 (see ValidateRFC7807).
+	//
+	// If false, problems are written in accordance with RFC 9457.
+	//
+	// For example;
+	//
+	//	g := &Generator{RFC7807Mode: true, StrictMode: devMode}
+	RFC7807Mode bool
+	// RecordProvenance is whether the BuildSource of each field of a Problem is recorded when it is built so that it can
+	// be inspected after-the-fact using Problem.Provenance, complementing Generator.TraceBuild. This is intended for use
+	// in tests and debug endpoints as it incurs a small cost for every Problem built.
+	//
+	// If false, provenance is not recorded and Problem.Provenance always returns nil.
+	//
+	// For example;
+	//
+	//	g := &Generator{RecordProvenance: testing.Testing()}
+	RecordProvenance bool
+	// Registry is the Registry containing each Code known to the Generator, which is used to verify that a Code
+	// received from outside the Generator (e.g. within an HTTP request to support tooling) actually exists.
+	//
+	// If nil, any Code that is well-formed (see Generator.Coder) is assumed to exist.
+	//
+	// For example;
+	//
+	//	reg := &Registry{}
+	//	if err := reg.AssignCodes("USER", userDefinitions); err != nil {
+	//		log.Fatal(err)
+	//	}
+	//	g := &Generator{Registry: reg}
+	Registry *Registry
+	// Router is the Router used to evaluate rules against each Problem built by the Generator, applying the actions of
+	// any matching rule (e.g. overriding its LogLevel, sending a notification, removing sensitive fields, or mapping it
+	// to another Definition) before the Problem is returned.
+	//
+	// If nil, a Problem is returned as it was built.
+	//
+	// For example;
+	//
+	//	g := &Generator{Router: &Router{Rules: []RouterRule{
+	//		{
+	//			Matchers: []Matcher{HasStatus(http.StatusInternalServerError, OperatorGreaterThanOrEqual)},
+	//			Actions:  []RouterAction{LogLevelAction(LogLevelError)},
+	//		},
+	//	}}}
+	Router *Router
+	// SLOClassifier is used to classify a Problem in terms of its impact on a service level objective (SLO) and its
+	// error budget, allowing SRE tooling to distinguish problems that burn error budget (e.g. server faults) from those
+	// that are expected (e.g. client errors) directly from the Problem itself.
+	//
+	// The SLOClass is classified once the Problem has been built, but before it has been routed (see
+	// Generator.Router), so that it can be included within any metrics observed while routing. It is only ever visible
+	// within logs and via Problem.SLOClass. StatusSLOClassifier may be used to classify a Problem based on its status.
+	//
+	// If nil, a Problem is never classified.
+	//
+	// For example;
+	//
+	//	g := &Generator{SLOClassifier: func(prob *Problem) SLOClass {
+	//		if prob.Status == http.StatusBadGateway {
+	//			return "dependency_fault"
+	//		}
+	//		return StatusSLOClassifier(prob)
+	//	}}
+	SLOClassifier SLOClassifier
+	// SpanEventRecorder is the SpanEventRecorder used to record each Problem as an event on the span that is active
+	// within the context used to build it (e.g. via NewContext or BuildContext). This allows problems to be surfaced
+	// within a distributed trace without needing to log them, without this package depending on any specific tracing
+	// library (e.g. contrib/otelproblem provides one for OpenTelemetry).
+	//
+	// The event is recorded once the Problem has been routed (see Generator.Router).
+	//
+	// If nil, problems are never recorded as span events.
+	//
+	// For example;
+	//
+	//	g := &Generator{SpanEventRecorder: otelproblem.RecordSpanEvent}
+	SpanEventRecorder SpanEventRecorder
+	// StackCaptureMode controls when a stack trace that is captured while building a Problem is formatted. Capturing
+	// only the program counters of a stack trace is relatively cheap, while resolving and formatting its frames is not,
+	// so StackCaptureLazy can be used to avoid that cost for problems whose stack trace is only visible within logs but
+	// which are never actually logged (e.g. when filtered out).
+	//
+	// If zero, StackCaptureEager is used and a stack trace is formatted as soon as it is captured.
+	//
+	// For example;
+	//
+	//	g := &Generator{StackCaptureMode: StackCaptureLazy, StackFlag: FlagLog}
+	StackCaptureMode StackCaptureMode
 	// StackFlag provides control over the capturing of a stack trace and its visibility on a Problem.
 	//
 	// StackFlag is the default Flag. If Builder.Stack or WithStack are used, but no flags are provided, this is
@@ -116,7 +561,149 @@ type Generator struct {
 	//	g := &Generator{StackFlag: FlagField}            // Stack trace accessible via Problem.Stack
 	//	g := &Generator{StackFlag: FlagLog}              // Stack trace visible only in logs
 	//	g := &Generator{StackFlag: FlagField | FlagLog}  // Stack trace accessible via Problem.Stack and visible in logs
+	//
+	// StackFlag can be overridden for a specific Definition or Type via Definition.StackFlag and Type.StackFlag
+	// respectively.
 	StackFlag Flag
+	// StackFormatter is the StackFormatter used to format any stack trace captured when building a Problem, which can be
+	// useful when stack traces are ingested by tools that expect a specific format.
+	//
+	// A stack trace inherited from a Problem (e.g. via Builder.Wrap) is never reformatted.
+	//
+	// If nil, a stack trace is formatted in the same format as that used by zap (see ZapStackFormatter).
+	//
+	// For example;
+	//
+	//	g := &Generator{StackFormatter: RuntimeStackFormatter()}
+	StackFormatter StackFormatter
+	// StackFrameFilter is the StackFrameFilter used to drop frames from any stack trace captured when building a
+	// Problem (e.g. framework frames from net/http or middleware wrappers), reducing the size of both payloads and logs.
+	// Frames are filtered before Generator.StackMaxDepth is applied.
+	//
+	// A stack trace inherited from a Problem (e.g. via Builder.Wrap) is never filtered.
+	//
+	// If nil, no frames are dropped.
+	//
+	// For example;
+	//
+	//	g := &Generator{StackFrameFilter: ExcludePackagesStackFrameFilter("net/http")}
+	StackFrameFilter StackFrameFilter
+	// StackMaxDepth is the maximum number of frames retained within any stack trace captured when building a Problem,
+	// where the innermost frames are retained and any others are dropped. This prevents deep (e.g. recursive) stack
+	// traces from bloating both payloads and logs.
+	//
+	// A stack trace inherited from a Problem (e.g. via Builder.Wrap) is never truncated.
+	//
+	// If zero or less, the number of frames is not limited.
+	//
+	// For example;
+	//
+	//	g := &Generator{StackMaxDepth: 32}
+	StackMaxDepth int
+	// StatusTitles maps status codes to titles that override Type.Title when building a Problem with that status,
+	// which can be useful for applying custom wording (e.g. branding, legal) without needing to declare near-duplicate
+	// types just to change their titles.
+	//
+	// A title within StatusTitles is used before falling back to DefaultTitle, however, it never takes precedence over
+	// an explicitly defined title (e.g. Builder.Title or WithTitle), one unwrapped from a Problem, or a localized title
+	// resolved from Type.TitleKey using Generator.Translator. As such, localization is unaffected where a translation
+	// exists.
+	//
+	// Each title within StatusTitles is also localized by passing it to Generator.Translator as a translation key, with
+	// the title itself being used as-is if no localized value could be found. As such, titles can either be translation
+	// keys or already be in the desired language.
+	//
+	// If nil or no title is mapped to the status code, Type.Title will be used, with a fallback to DefaultTitle.
+	//
+	// For example;
+	//
+	//	g := &Generator{StatusTitles: map[int]string{
+	//		http.StatusNotFound:           "We couldn't find that",
+	//		http.StatusServiceUnavailable: "Down for maintenance",
+	//	}}
+	StatusTitles map[int]string
+	// StrictMode is whether misuse of the Generator is surfaced as loudly as possible when building a Problem, which is
+	// intended for use in development and testing. Currently, this means that Builder.Problem (and therefore
+	// Generator.New etc.) panics if the Problem is missing any of the Type.RequiredFields of its Type, has a type URI
+	// that is not allowed by Generator.AllowedTypeURIs or, if Generator.RFC7807Mode is enabled, does not conform to RFC
+	// 7807.
+	//
+	// If false, Type.RequiredFields is ignored.
+	//
+	// For example;
+	//
+	//	g := &Generator{StrictMode: devMode}
+	StrictMode bool
+	// TimestampFlag provides control over the generation of a timestamp and its visibility on a Problem.
+	//
+	// TimestampFlag is the default Flag. If Builder.Timestamp or WithTimestamp are used, but no flags are provided,
+	// this is considered equal to passing FlagField and FlagLog. This would mean that the timestamp will be generated and
+	// fully visible on the Problem both in terms of field and within the logs. If FlagDisable is ever passed, all other
+	// flags are ignored and the timestamp is not generated (or inherited) and will not be visible on the Problem.
+	//
+	// For example;
+	//
+	//	g := &Generator{TimestampFlag: FlagDisable}          // Timestamp not generated or inherited
+	//	g := &Generator{TimestampFlag: FlagField}            // Timestamp accessible via Problem.Timestamp
+	//	g := &Generator{TimestampFlag: FlagLog}              // Timestamp visible only in logs
+	//	g := &Generator{TimestampFlag: FlagField | FlagLog}  // Timestamp accessible via Problem.Timestamp and visible in logs
+	TimestampFlag Flag
+	// TitleMaxLen is the maximum number of characters (i.e. runes) permitted within the title of a Problem when it is
+	// built, where any title exceeding TitleMaxLen is truncated with an ellipsis.
+	//
+	// If zero or less, the length of a title is not limited.
+	//
+	// For example;
+	//
+	//	g := &Generator{TitleMaxLen: 8}
+	//	g.New(WithTitle("Service Unavailable")).Title  // "Service…"
+	TitleMaxLen int
+	// TraceBuild is called with a BuildStep for each field of a Problem when it is built, describing which source (e.g.
+	// explicit, wrapped, definition, or default) the value of the field was taken from. This is intended purely as a
+	// debugging aid for investigating precedence when layering options, definitions, and wrapped errors (e.g. "why is my
+	// title wrong?") and it is called synchronously, so should return quickly.
+	//
+	// Fields are traced in alphabetical order once the Problem has been built, so values reflect any truncation or
+	// localization.
+	//
+	// If nil, building a Problem is not traced.
+	//
+	// For example;
+	//
+	//	g := &Generator{TraceBuild: func(step BuildStep) {
+	//		log.Printf("%s=%v (from %s)", step.Field, step.Value, step.Source)
+	//	}}
+	TraceBuild BuildTracer
+	// TraceContextFlag provides control over the visibility of the trace context (i.e. the trace and span IDs) of the
+	// span that is active within the context used to build a Problem (e.g. via NewContext or BuildContext) on the
+	// Problem, as read by Generator.TraceContextReader.
+	//
+	// TraceContextFlag is the default Flag. If Builder.TraceContext or WithTraceContext are used, but no flags are
+	// provided, this is considered equal to passing FlagField and FlagLog. This would mean that the trace context will
+	// be fully visible on the Problem both in terms of fields and within the logs. If FlagDisable is ever passed, all
+	// other flags are ignored and the trace context is not read (or inherited) and will not be visible on the Problem.
+	//
+	// No trace context is present if the context contains no valid span.
+	//
+	// For example;
+	//
+	//	g := &Generator{TraceContextFlag: FlagDisable}          // Trace context not read or inherited
+	//	g := &Generator{TraceContextFlag: FlagField}            // Trace context accessible via Problem.TraceID and Problem.SpanID
+	//	g := &Generator{TraceContextFlag: FlagLog}              // Trace context visible only in logs
+	//	g := &Generator{TraceContextFlag: FlagField | FlagLog}  // Trace context accessible via fields and visible in logs
+	TraceContextFlag Flag
+	// TraceContextReader is the TraceContextReader used to read the trace context (i.e. the trace and span IDs) of the
+	// span that is active within the context used to build a Problem (e.g. via NewContext or BuildContext), without
+	// this package depending on any specific tracing library (e.g. contrib/otelproblem provides one for OpenTelemetry).
+	// See Generator.TraceContextFlag for more information.
+	//
+	// If nil, no trace context is ever read and so it is only present on a Problem if inherited from another Problem
+	// (e.g. via Builder.Wrap).
+	//
+	// For example;
+	//
+	//	g := &Generator{TraceContextFlag: FlagLog, TraceContextReader: otelproblem.TraceContext}
+	TraceContextReader TraceContextReader
 	// Translator is the problem.Translator used to provide localized values for translation keys, where possible, when
 	// constructing a Problem.
 	//
@@ -179,6 +766,9 @@ type Generator struct {
 	//	g := &Generator{UUIDFlag: FlagField}            // UUID accessible via Problem.UUID
 	//	g := &Generator{UUIDFlag: FlagLog}              // UUID visible only in logs
 	//	g := &Generator{UUIDFlag: FlagField | FlagLog}  // UUID accessible via Problem.UUID and visible in logs
+	//
+	// UUIDFlag can be overridden for a specific Definition or Type via Definition.UUIDFlag and Type.UUIDFlag
+	// respectively.
 	UUIDFlag Flag
 	// UUIDGenerator returns the problem.UUIDGenerator used to generate a Universally Unique Identifier when
 	// constructing a Problem.
@@ -199,6 +789,28 @@ type Generator struct {
 	//	}
 	//	g := &Generator{UUIDGenerator: nanoidGenerator(nanoid.Canonic())}
 	UUIDGenerator UUIDGenerator
+	// Visibility controls whether information (i.e. the detail, wrapped error messages, stack trace, and any selected
+	// extensions) is exposed on a Problem or only within logs, typically configured per environment (e.g. development,
+	// staging, or production) so that a single Generator can be used everywhere.
+	//
+	// Since Visibility is applied whenever a Problem is built, anything restricted to logs can never leak, even if the
+	// Problem is marshaled directly rather than written as an HTTP response. Visibility takes precedence over any Flag
+	// or option used to build a Problem as well as Generator.DebugMode.
+	//
+	// If zero, nothing is restricted to logs.
+	//
+	// For example;
+	//
+	//	var vis Visibility
+	//	if env == "production" {
+	//		vis = Visibility{
+	//			LogOnlyErrorMessages: true,
+	//			LogOnlyExtensions:    []string{"query", "upstream"},
+	//			LogOnlyStack:         true,
+	//		}
+	//	}
+	//	g := &Generator{StackFlag: FlagField | FlagLog, Visibility: vis}
+	Visibility Visibility
 }
 
 // DefaultGenerator is the default Generator used when none is given to some top-level functions and structs.
@@ -206,19 +818,80 @@ type Generator struct {
 // While relatively unopinionated, it is designed to work out-of-the-box with the most commonly desired behaviour having
 // the following characteristics:
 //
-//   - Stack traces are not captured and UUIDs are not generated by default (see Generator.StackFlag and
-//     Generator.UUIDFlag respectively for more information)
+//   - Stack traces are not captured and neither timestamps nor UUIDs are generated by default (see
+//     Generator.StackFlag, Generator.TimestampFlag, and Generator.UUIDFlag respectively for more information)
+//   - Any timestamp that is generated (e.g. via Builder.Timestamp or WithTimestamp) uses the current local time (see
+//     Generator.Clock for more information)
+//   - The trace context of the active span is never read and problems are never recorded as span events, as no tracing
+//     library is used by default (see Generator.TraceContextReader and Generator.SpanEventRecorder respectively for
+//     more information)
+//   - Any stack trace that is captured is formatted immediately and in the same format as that used by zap (see
+//     Generator.StackCaptureMode and Generator.StackFormatter for more information)
+//   - Any stack trace that is captured contains every frame (see Generator.StackFrameFilter and Generator.StackMaxDepth
+//     for more information)
 //   - Any UUID that is generated (e.g. via Builder.UUID or WithUUID) is a (V4) UUID (see Generator.UUIDGenerator for
 //     more information)
-//   - Any stack trace, UUID, or LogLevel of a Problem found in the tree of an error passed to Builder.Wrap or Wrap is
-//     unwrapped and treated as defaults for the generated Problem by default (see Generator.Unwrapper for more
-//     information)
+//   - Any stack trace, timestamp, trace context, UUID, or LogLevel of a Problem found in the tree of an error passed to
+//     Builder.Wrap or Wrap is unwrapped and treated as defaults for the generated Problem by default (see
+//     Generator.Unwrapper for more information)
 //   - Any translation keys are ignored (see Generator.Translator for more information)
+//   - No fallback languages are consulted when a translation key cannot be resolved (see
+//     Generator.LanguageFallbacks for more information)
+//   - Any localized title and detail replace their non-localized values (see Generator.I18NOutputMode for more
+//     information)
+//   - The title of a Problem is never overridden based on its status (see Generator.StatusTitles for more information)
+//   - Any type URI can be emitted when a Problem is written as an HTTP response (see Generator.AllowedTypeURIs for
+//     more information)
+//   - Problems are written in accordance with RFC 9457 rather than RFC 7807 (see Generator.RFC7807Mode for more
+//     information)
+//   - The fields required by a Type are never enforced (see Generator.StrictMode and Type.RequiredFields for more
+//     information)
+//   - Information that is ordinarily only visible within logs is never included when a Problem is written as an HTTP
+//     response (see Generator.DebugMode for more information)
+//   - Any error written as an HTTP response without a function to provide a default Problem is simply wrapped by a
+//     generated Problem (see Generator.DefaultProblemFactory for more information)
+//   - No functions are called at any point within the lifecycle of a Problem (see Generator.Hooks for more
+//     information)
+//   - Nothing is restricted to logs regardless of how a Problem is built (see Generator.Visibility for more
+//     information)
+//   - Problems are never acquired from a pool and are always garbage collected (see Generator.PoolProblems for more
+//     information)
+//   - Any Code that is well-formed is assumed to exist (see Generator.Registry for more information)
+//   - No error is mapped to a Definition when wrapped or written as an HTTP response (see Generator.ErrorMappers for
+//     more information)
+//   - The Accept header of an HTTP request is ignored when writing a Problem as an HTTP response (see
+//     Generator.NegotiateContentType for more information)
+//   - Only the built-in content/media types are supported when writing a Problem as an HTTP response (see
+//     Generator.ContentTypes and Generator.Encoders for more information)
+//   - If a Problem fails to be encoded when written as an HTTP response, DefaultFallbackJSON or DefaultFallbackXML is
+//     written instead (see Generator.FallbackJSON and Generator.FallbackXML for more information)
+//   - The detail of a Problem is never composed with the message of any wrapped error (see Generator.DetailComposer
+//     for more information)
+//   - The title and detail of a Problem are never truncated (see Generator.TitleMaxLen and Generator.DetailMaxLen
+//     respectively for more information)
 //   - Any Code constructed and/or parsed can have any non-empty NS and value and are separated by DefaultCodeSeparator
 //     (see Generator.CodeNSValidator, Generator.CodeValueLen, and Generator.CodeSeparator respectively for more
 //     information)
 //   - Any message that is logged (e.g. via Generator.Log or Generator.LogContext) is done so using slog.Default with
 //     DefaultLogArgKey passed as the key along with a Problem within the last two arguments (see Generator.Logger and
 //     Generator.LogArgKey respectively for more information)
+//   - Only the extensions from the source with the highest precedence are used (see Generator.ExtensionMergeStrategy
+//     for more information)
 //   - The LogLevel derived from a Type is always Type.LogLevel (see Generator.LogLeveler for more information)
+//   - Building a Problem is neither traced nor is its provenance recorded (see Generator.TraceBuild and
+//     Generator.RecordProvenance respectively for more information)
+//   - No notifications are sent for any Problem (see Generator.Notifiers for more information)
+//   - A Problem is returned as it was built without any rules being evaluated against it (see Generator.Router for
+//     more information)
+//   - A Problem is never classified in terms of its impact on a service level objective (see Generator.SLOClassifier
+//     for more information)
+//   - Only the first Problem within a joined error is unwrapped and no others are recorded (see
+//     Generator.ProblemSelector for more information)
+//   - Every extension of a Problem is included when written as an HTTP response, unless excluded by a FieldMask (see
+//     Generator.ExtensionPredicates for more information)
+//   - Extensions are never sanitized (see Generator.ExtensionSanitizer for more information)
+//   - Every Problem is written as an HTTP response in the same way regardless of its audience (see
+//     Generator.Profiles for more information)
+//   - No extensions are propagated from a wrapped Problem, other than those extracted by the Unwrapper (see
+//     Generator.PropagatedExtensions for more information)
 var DefaultGenerator = &Generator{}
@@ -0,0 +1,119 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package problem
+
+import (
+	"context"
+	"net/http"
+)
+
+type (
+	// AfterBuildHook is a function called by a Generator once a Problem has been built, allowing cross-cutting concerns
+	// (e.g. metrics, enrichment, auditing, redaction) to be attached once rather than at every call site. See
+	// Hooks.AfterBuild for more information.
+	//
+	// An AfterBuildHook is called synchronously and is never passed a nil pointer to a Problem. Since the Problem has
+	// yet to be returned to the caller, it may be modified in place.
+	AfterBuildHook func(ctx context.Context, prob *Problem)
+
+	// AfterLogHook is a function called by a Generator once a Problem has been logged along with the message used. See
+	// Hooks.AfterLog for more information.
+	//
+	// An AfterLogHook is called synchronously and should return quickly. The Problem must not be modified.
+	AfterLogHook func(ctx context.Context, msg string, prob *Problem)
+
+	// BeforeBuildHook is a function called by a Generator before a Problem is built from the given Builder, allowing it
+	// to be modified (e.g. to apply defaults). See Hooks.BeforeBuild for more information.
+	//
+	// A BeforeBuildHook is called synchronously and is passed a clone of the Builder used to build the Problem, so any
+	// changes made do not affect the Builder used by the caller.
+	BeforeBuildHook func(b *Builder)
+
+	// BeforeWriteHook is a function called by a Generator before a Problem is written as an HTTP response, allowing the
+	// response to be modified (e.g. by setting additional headers). See Hooks.BeforeWrite for more information.
+	//
+	// A BeforeWriteHook is called synchronously and is never passed a nil pointer to a Problem, however, the Problem
+	// must not be modified as it is typically owned by the caller. Neither the header nor body of the response have
+	// been written when it is called.
+	BeforeWriteHook func(prob *Problem, w http.ResponseWriter, req *http.Request)
+
+	// Hooks contains functions that are called by a Generator at various points within the lifecycle of a Problem.
+	//
+	// Each hook within a slice is called in the order in which they are provided.
+	Hooks struct {
+		// AfterBuild contains each AfterBuildHook to be called once a Problem has been built (e.g. via Generator.New
+		// or Builder.Problem) and routed (see Generator.Router), but before it is returned.
+		AfterBuild []AfterBuildHook
+		// AfterLog contains each AfterLogHook to be called once a Problem has been logged via Generator.LogContext,
+		// including when it is logged as it is written as an HTTP response.
+		AfterLog []AfterLogHook
+		// BeforeBuild contains each BeforeBuildHook to be called before a Problem is built (e.g. via Generator.New or
+		// Builder.Problem).
+		BeforeBuild []BeforeBuildHook
+		// BeforeWrite contains each BeforeWriteHook to be called before a Problem is written as an HTTP response (e.g.
+		// via Generator.WriteProblem or Generator.WriteError), but only if the response is to be written (see
+		// GuardWrites).
+		BeforeWrite []BeforeWriteHook
+	}
+)
+
+// afterBuild calls each AfterBuildHook within Generator.Hooks for the given Problem, if not nil.
+func (g *Generator) afterBuild(ctx context.Context, prob *Problem) {
+	if prob == nil {
+		return
+	}
+	for _, hook := range g.Hooks.AfterBuild {
+		hook(ctx, prob)
+	}
+}
+
+// afterLog calls each AfterLogHook within Generator.Hooks for the given message and Problem, if not nil.
+func (g *Generator) afterLog(ctx context.Context, msg string, prob *Problem) {
+	if prob == nil {
+		return
+	}
+	for _, hook := range g.Hooks.AfterLog {
+		hook(ctx, msg, prob)
+	}
+}
+
+// beforeBuild calls each BeforeBuildHook within Generator.Hooks for a clone of the given Builder, which is returned.
+// If Generator.Hooks contains no BeforeBuildHook, b is returned as-is.
+func (g *Generator) beforeBuild(b *Builder) *Builder {
+	if len(g.Hooks.BeforeBuild) == 0 {
+		return b
+	}
+	b = b.Clone()
+	for _, hook := range g.Hooks.BeforeBuild {
+		hook(b)
+	}
+	return b
+}
+
+// beforeWrite calls each BeforeWriteHook within Generator.Hooks for the given Problem, if not nil.
+func (g *Generator) beforeWrite(prob *Problem, w http.ResponseWriter, req *http.Request) {
+	if prob == nil {
+		return
+	}
+	for _, hook := range g.Hooks.BeforeWrite {
+		hook(prob, w, req)
+	}
+}
@@ -21,23 +21,52 @@
 package problem
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"encoding/xml"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"sync/atomic"
+	"time"
 )
 
 // WriteOptions contains options that can be used when writing errors/problems to HTTP responses.
 //
+// WriteOptions may also be attached to the context.Context of an HTTP request (e.g. per route using RouteMiddleware),
+// in which case any WriteOptions passed explicitly take precedence.
+//
 // All fields are optional with default behaviour clearly documented.
 type WriteOptions struct {
+	// BodyObserver is called with the exact encoded body once it has been written to the HTTP response, allowing it to
+	// be captured (e.g. for egress size metrics or audit trails) without the Problem being encoded again. See
+	// BodyObserver for more information.
+	//
+	// When present, the body is fully encoded in memory before anything is written to the HTTP response.
+	//
+	// If nil, the body is not observed.
+	BodyObserver BodyObserver
 	// ContentType is the content/media type to be used in the HTTP response.
 	//
 	// The value will be ignored if unsupported or not appropriate for the function called. If empty,
 	// Generator.ContentType will be used with a fallback to either ContentTypeJSONUTF8 or a more appropriate
 	// content/media type depending on the function called.
 	ContentType string
+	// FieldMask controls which fields of the Problem, including the keys of its extensions, are included within the
+	// HTTP response. See FieldMask for more information.
+	//
+	// The Problem itself is never modified, so it is still logged and passed to any Notifier in its entirety.
+	//
+	// If zero, Profile.FieldMask of any resolved Profile is used, otherwise all fields are included.
+	FieldMask FieldMask
+	// LinkHeader is whether a Link header is added to the HTTP response containing links to the documentation (using
+	// the "describedby" relation type) and remediation steps (using the "help" relation type) of the Problem, where it
+	// has either. See ExtensionDocsURL and ExtensionHelpURL for more information.
+	//
+	// By default, no Link header is added.
+	LinkHeader bool
 	// LogArgs contains arguments to be passed to Generator.LogContext along with the Problem.
 	//
 	// If empty, no additional arguments will be passed.
@@ -51,13 +80,109 @@ type WriteOptions struct {
 	//
 	// If empty, a basic message will be passed.
 	LogMessage string
+	// Profile is the name of the Profile within Generator.Profiles to be used when writing the HTTP response. See
+	// Profile for more information.
+	//
+	// If empty, the name of the Profile within the HTTP request's context.Context is used, if any (see UsingProfile).
+	// If no Profile exists with the resolved name, no Profile is used.
+	Profile string
+	// Signer is called with the encoded body of the HTTP response so that it can be signed (e.g. using an HMAC), with
+	// the header returned by Signer being added to the HTTP response. See Signer for more information.
+	//
+	// When present, the body is fully encoded in memory before anything is written to the HTTP response, and nothing
+	// is written if the Problem fails to be encoded.
+	//
+	// If nil, the HTTP response is not signed.
+	Signer Signer
 	// Status is the status code to the written to the HTTP response.
 	//
 	// If less than or equal to zero, Problem.Status will be used with a fallback to http.StatusInternalServerError.
 	Status int
+	// WriteTimeout is the maximum duration allowed for the HTTP response to be written, including the encoding of the
+	// Problem into its body, which is applied as a write deadline on the underlying connection using
+	// http.ResponseController. This prevents slow clients from tying up goroutines while writing problems (e.g. during
+	// an error storm), in which case an error is returned once the deadline has been exceeded.
+	//
+	// The write deadline is ignored if the http.ResponseWriter does not support it.
+	//
+	// If less than or equal to zero, no write deadline is applied, however, any deadline applied by the http.Server
+	// (e.g. http.Server.WriteTimeout) still applies.
+	WriteTimeout time.Duration
 }
 
+// BodyObserver is a function used to observe the exact body written to an HTTP response for a Problem.
+//
+// A BodyObserver is called synchronously once the body has been written and so should return quickly. body must not be
+// modified or retained after the function returns without being copied. If the Problem failed to be encoded, body will
+// contain the fallback body that was written instead (see Generator.FallbackJSON and Generator.FallbackXML).
+//
+// For example;
+//
+//	observer := func(ctx context.Context, prob *Problem, body []byte) {
+//		egressBytes.WithLabelValues(strconv.Itoa(prob.Status)).Add(float64(len(body)))
+//	}
+type BodyObserver func(ctx context.Context, prob *Problem, body []byte)
+
+// Encoder is a function used to encode the given Problem in a custom format (e.g. protobuf, msgpack, or HTML) to w. See
+// Generator.Encoders for more information.
+type Encoder func(w io.Writer, p *Problem) error
+
+// MiddlewareOptions contains options that can be used to customize the behaviour of the Middleware functions.
+//
+// All fields are optional with default behaviour clearly documented.
+type MiddlewareOptions struct {
+	// Repanic is used to match any value recovered from a panic that is to be propagated (i.e. re-panicked) instead of
+	// being written as an HTTP response for a Problem. This allows the Middleware functions to coexist with servers
+	// and/or frameworks that rely on specific panics (e.g. http.ErrAbortHandler).
+	//
+	// If nil, all recovered values are written as an HTTP response for a Problem.
+	//
+	// For example;
+	//
+	//	handler := MiddlewareWithOptions(nil, MiddlewareOptions{Repanic: func(v any) bool {
+	//		_, isRuntimeErr := v.(runtime.Error)
+	//		return isRuntimeErr || v == http.ErrAbortHandler
+	//	}})(mux)
+	Repanic func(v any) bool
+	// WriteOptions contains options used when writing an HTTP response for a Problem formed from a value recovered
+	// from a panic. See WriteOptions for more information.
+	WriteOptions
+}
+
+// ProblemFactory is a function used to provide a Problem for an error that does not contain a Problem within its tree
+// (e.g. when writing an error as an HTTP response).
+type ProblemFactory func(err error) *Problem
+
+// Signer is a function used to sign the given body of an HTTP response for a Problem, returning the name and value of
+// the header to be added to the HTTP response containing the signature.
+//
+// The body is exactly what is written to the HTTP response. CanonicalJSON may also be useful for cases where a
+// signature is to be verified against a Problem after it has been decoded.
+//
+// If headerName is empty, no header is added.
+//
+// For example;
+//
+//	signer := func(body []byte) (string, string) {
+//		mac := hmac.New(sha256.New, key)
+//		mac.Write(body)
+//		return "Problem-Signature", hex.EncodeToString(mac.Sum(nil))
+//	}
+type Signer func(body []byte) (headerName, headerValue string)
+
 const (
+	// DefaultFallbackJSON is the default pre-encoded body written to an HTTP response in place of a Problem in JSON
+	// format whenever it fails to be encoded. See Generator.FallbackJSON for more information.
+	//
+	// It deliberately omits a status so that it can never conflict with the status code of the HTTP response.
+	DefaultFallbackJSON = `{"title":"` + DefaultTitle + `","type":"` + DefaultTypeURI + `"}` + "\n"
+	// DefaultFallbackXML is the default pre-encoded body written to an HTTP response in place of a Problem in XML
+	// format whenever it fails to be encoded. See Generator.FallbackXML for more information.
+	//
+	// It deliberately omits a status so that it can never conflict with the status code of the HTTP response.
+	DefaultFallbackXML = `<problem xmlns="` + xmlPreferredSpaceName + `"><title>` + DefaultTitle + `</title><type>` +
+		DefaultTypeURI + `</type></problem>`
+
 	// contentTypeHeader is the header representing an HTTP response's content/media type.
 	contentTypeHeader = "Content-Type"
 	// defaultHTTPLogMessage is the default log message used when writing errors/problems to an HTTP response.
@@ -67,35 +192,15 @@ const (
 	defaultHTTPPanicLogMessage = "A panic recovery has occurred"
 )
 
-// apply applies the fields from the given WriteOptions, if any and where applicable.
-//
-// The fields of any WriteOptions found are handled as follows:
-//
-//   - ContentType is applied if not empty and valid (based on function provided)
-//   - LogArgs is applied if not empty
-//   - LogDisabled is always applied as only a true value changes anything
-//   - LogMessage is applied if not empty
-//   - Status is applied if greater than zero
+// apply applies the fields from each of the given WriteOptions, in order, where applicable. See WriteOptions.merge for
+// how the fields of each WriteOptions are handled.
 //
 // If LogMessage is empty and a non-empty log message is not applied, defaultHTTPLogMessage will be applied.
 //
 // Panics if ContentType is empty and a non-empty valid content/media type is not applied.
 func (wo WriteOptions) apply(opts []WriteOptions, isValidCT func(ct string) bool) WriteOptions {
-	if len(opts) > 0 {
-		_opts := opts[0]
-		if _opts.ContentType != "" && isValidCT(_opts.ContentType) {
-			wo.ContentType = _opts.ContentType
-		}
-		wo.LogDisabled = _opts.LogDisabled
-		if len(_opts.LogArgs) > 0 {
-			wo.LogArgs = _opts.LogArgs
-		}
-		if _opts.LogMessage != "" {
-			wo.LogMessage = _opts.LogMessage
-		}
-		if _opts.Status > 0 {
-			wo.Status = _opts.Status
-		}
+	for _, _opts := range opts {
+		wo = wo.merge(_opts, isValidCT)
 	}
 	if wo.ContentType == "" {
 		// Sanity check - should never happen
@@ -107,69 +212,227 @@ func (wo WriteOptions) apply(opts []WriteOptions, isValidCT func(ct string) bool
 	return wo
 }
 
+// merge returns a copy of the WriteOptions with the fields from the given WriteOptions applied, where applicable.
+//
+// The fields of other are handled as follows:
+//
+//   - BodyObserver is applied if not nil
+//   - ContentType is applied if not empty and valid (based on function provided, if not nil)
+//   - FieldMask is applied if not zero
+//   - LinkHeader is applied if true
+//   - LogArgs is applied if not empty
+//   - LogDisabled is applied if true
+//   - LogMessage is applied if not empty
+//   - Profile is applied if not empty
+//   - Signer is applied if not nil
+//   - Status is applied if greater than zero
+//   - WriteTimeout is applied if greater than zero
+func (wo WriteOptions) merge(other WriteOptions, isValidCT func(ct string) bool) WriteOptions {
+	if other.BodyObserver != nil {
+		wo.BodyObserver = other.BodyObserver
+	}
+	if other.ContentType != "" && (isValidCT == nil || isValidCT(other.ContentType)) {
+		wo.ContentType = other.ContentType
+	}
+	if !other.FieldMask.IsZero() {
+		wo.FieldMask = other.FieldMask
+	}
+	if other.LinkHeader {
+		wo.LinkHeader = true
+	}
+	if len(other.LogArgs) > 0 {
+		wo.LogArgs = other.LogArgs
+	}
+	if other.LogDisabled {
+		wo.LogDisabled = true
+	}
+	if other.LogMessage != "" {
+		wo.LogMessage = other.LogMessage
+	}
+	if other.Profile != "" {
+		wo.Profile = other.Profile
+	}
+	if other.Signer != nil {
+		wo.Signer = other.Signer
+	}
+	if other.Status > 0 {
+		wo.Status = other.Status
+	}
+	if other.WriteTimeout > 0 {
+		wo.WriteTimeout = other.WriteTimeout
+	}
+	return wo
+}
+
+// RegisterEncoder registers the given Encoder within Generator.Encoders to be used to encode a Problem when written as
+// an HTTP response with the content/media type provided. See Generator.Encoders for more information.
+//
+// RegisterEncoder is not safe for concurrent use and so is intended to be called while the Generator is being
+// configured (e.g. at startup) before it is used.
+//
+// Panics if contentType is empty or enc is nil.
+func (g *Generator) RegisterEncoder(contentType string, enc func(w io.Writer, p *Problem) error) {
+	if contentType == "" {
+		panic(errors.New("content type is empty"))
+	}
+	if enc == nil {
+		panic(errors.New("encoder is nil"))
+	}
+	if g.Encoders == nil {
+		g.Encoders = make(map[string]Encoder)
+	}
+	g.Encoders[contentType] = enc
+}
+
 // WriteError writes an HTTP response for a Problem where the Problem is unwrapped from err, where possible, with the
-// given function being used to provide a default Problem, relying solely on WriteOptions.ContentType to determine how
-// the response is formed, with a graceful fallback to Generator.ContentType and ContentTypeJSONUTF8. WriteOptions can
-// also be passed for more granular control.
+// given function being used to provide a default Problem (see Generator.DefaultProblemFactory if nil), relying solely
+// on WriteOptions.ContentType to determine how the response is formed, with a graceful fallback to
+// Generator.ContentType and ContentTypeJSONUTF8. WriteOptions can also be passed for more granular control.
+//
+// If the context.Context of req has been canceled (i.e. the client has closed the connection) and err does not contain a
+// Problem, a Problem is generated from ClientClosedRequestDefinition instead of using probFunc.
 //
 // An error is returned if the Problem fails to be written to w.
 func (g *Generator) WriteError(err error, w http.ResponseWriter, req *http.Request, probFunc func(err error) *Problem, opts ...WriteOptions) error {
-	prob, isProblem := As(err)
-	if !isProblem {
-		prob = probFunc(err)
-	}
-	return g.WriteProblem(prob, w, req, opts...)
+	return g.WriteProblem(g.problemFromError(req.Context(), err, probFunc, false), w, req, opts...)
 }
 
 // WriteErrorJSON writes an HTTP response for a Problem in JSON format where the Problem is unwrapped from err, where
-// possible, with the given function being used to provide a default Problem. WriteOptions can also be passed for more
-// granular control.
+// possible, with the given function being used to provide a default Problem (see Generator.DefaultProblemFactory if
+// nil). WriteOptions can also be passed for more granular control.
+//
+// If the context.Context of req has been canceled and err does not contain a Problem, a Problem is generated from
+// ClientClosedRequestDefinition instead. See Generator.WriteError for more information.
 //
 // An error is returned if the Problem fails to be written to w.
 func (g *Generator) WriteErrorJSON(err error, w http.ResponseWriter, req *http.Request, probFunc func(err error) *Problem, opts ...WriteOptions) error {
-	prob, isProblem := As(err)
-	if !isProblem {
-		prob = probFunc(err)
-	}
-	return g.WriteProblemJSON(prob, w, req, opts...)
+	return g.WriteProblemJSON(g.problemFromError(req.Context(), err, probFunc, false), w, req, opts...)
 }
 
 // WriteErrorXML writes an HTTP response for a Problem in XML format where the Problem is unwrapped from err, where
-// possible, with the given function being used to provide a default Problem. WriteOptions can also be passed for more
-// granular control.
+// possible, with the given function being used to provide a default Problem (see Generator.DefaultProblemFactory if
+// nil). WriteOptions can also be passed for more granular control.
+//
+// If the context.Context of req has been canceled and err does not contain a Problem, a Problem is generated from
+// ClientClosedRequestDefinition instead. See Generator.WriteError for more information.
 //
 // An error is returned if the Problem fails to be written to w.
 func (g *Generator) WriteErrorXML(err error, w http.ResponseWriter, req *http.Request, probFunc func(err error) *Problem, opts ...WriteOptions) error {
-	prob, isProblem := As(err)
-	if !isProblem {
-		prob = probFunc(err)
-	}
-	return g.WriteProblemXML(prob, w, req, opts...)
+	return g.WriteProblemXML(g.problemFromError(req.Context(), err, probFunc, false), w, req, opts...)
 }
 
 // WriteProblem writes an HTTP response for the given Problem, optionally using WriteOptions for more granular control,
 // relying solely on WriteOptions.ContentType to determine how the response is formed, with a graceful fallback to
 // Generator.ContentType and ContentTypeJSONUTF8.
 //
+// If a Profile is resolved (see WriteOptions.Profile), Profile.ContentType takes precedence over Generator.ContentType.
+// If Generator.NegotiateContentType is enabled, the Accept header of req is used to select the content/media type
+// whenever WriteOptions.ContentType is not passed, with the same graceful fallback.
+//
+// Any WriteOptions attached to the context.Context of req (see RouteMiddleware) are applied before those passed.
+//
+// If an HTTP response has already been written for a Problem for req, prob is only logged. See GuardWrites for more
+// information.
+//
 // An error is returned if prob fails to be written to w.
 func (g *Generator) WriteProblem(prob *Problem, w http.ResponseWriter, req *http.Request, opts ...WriteOptions) error {
-	return g.writeProblem(prob, w, req, WriteOptions{ContentType: g.contentType()}.apply(opts, isValidContentType))
+	opts = routeWriteOptions(req.Context(), opts)
+	return g.writeProblem(prob, w, req, WriteOptions{ContentType: g.defaultContentType(w, req, opts)}.apply(opts, g.isValidContentType))
 }
 
 // WriteProblemJSON writes an HTTP response for the given Problem in JSON format, optionally using WriteOptions for more
 // granular control.
 //
+// Any WriteOptions attached to the context.Context of req (see RouteMiddleware) are applied before those passed.
+//
+// If an HTTP response has already been written for a Problem for req, prob is only logged. See GuardWrites for more
+// information.
+//
 // An error is returned if prob fails to be written to w.
 func (g *Generator) WriteProblemJSON(prob *Problem, w http.ResponseWriter, req *http.Request, opts ...WriteOptions) error {
-	return g.writeProblemJSON(prob, w, req, WriteOptions{ContentType: ContentTypeJSONUTF8}.apply(opts, isValidContentTypeForJSON))
+	opts = routeWriteOptions(req.Context(), opts)
+	return g.writeProblemJSON(prob, w, req, WriteOptions{ContentType: ContentTypeJSONUTF8}.apply(opts, g.isValidContentTypeForJSON))
 }
 
 // WriteProblemXML writes an HTTP response for the given Problem in XML format, optionally using WriteOptions for more
 // granular control.
 //
+// Any WriteOptions attached to the context.Context of req (see RouteMiddleware) are applied before those passed.
+//
+// If an HTTP response has already been written for a Problem for req, prob is only logged. See GuardWrites for more
+// information.
+//
 // An error is returned if prob fails to be written to w.
 func (g *Generator) WriteProblemXML(prob *Problem, w http.ResponseWriter, req *http.Request, opts ...WriteOptions) error {
-	return g.writeProblemXML(prob, w, req, WriteOptions{ContentType: ContentTypeXMLUTF8}.apply(opts, isValidContentTypeForXML))
+	opts = routeWriteOptions(req.Context(), opts)
+	return g.writeProblemXML(prob, w, req, WriteOptions{ContentType: ContentTypeXMLUTF8}.apply(opts, g.isValidContentTypeForXML))
+}
+
+// fallbackJSON returns Generator.FallbackJSON if not empty, otherwise DefaultFallbackJSON.
+func (g *Generator) fallbackJSON() string {
+	if g.FallbackJSON != "" {
+		return g.FallbackJSON
+	}
+	return DefaultFallbackJSON
+}
+
+// fallbackXML returns Generator.FallbackXML if not empty, otherwise DefaultFallbackXML.
+func (g *Generator) fallbackXML() string {
+	if g.FallbackXML != "" {
+		return g.FallbackXML
+	}
+	return DefaultFallbackXML
+}
+
+// observeProblem is called before an HTTP response is written for the given Problem, using WriteOptions, that are
+// expected to have been applied, to determine whether the Problem is logged. The Problem is also passed to any matching
+// Notifier, validated against any RouteCatalog bound to the HTTP request, and passed to each BeforeWriteHook within
+// Generator.Hooks.
+//
+// If an HTTP response has already been written for a Problem for the HTTP request (see GuardWrites), the Problem is
+// only logged and false is returned to indicate that the HTTP response must not be written. Otherwise, true is
+// returned.
+func (g *Generator) observeProblem(prob *Problem, w http.ResponseWriter, req *http.Request, opts WriteOptions) bool {
+	ctx := req.Context()
+	first := claimWrite(ctx)
+	if first {
+		validateRoute(ctx, req, prob)
+	}
+	if !opts.LogDisabled && opts.LogMessage != "" {
+		g.LogContext(ctx, opts.LogMessage, prob, opts.LogArgs...)
+	}
+	if first {
+		g.Notify(ctx, prob)
+		g.beforeWrite(prob, w, req)
+	}
+	return first
+}
+
+// problemFromError returns the Problem unwrapped from err, where possible, otherwise a Problem generated from the
+// Definition to which err is mapped by Generator.ErrorMappers, if any, otherwise the Problem provided by the given
+// function. If probFunc is nil, Generator.DefaultProblemFactory is used instead, if present, otherwise a Problem is
+// generated that simply wraps err.
+//
+// However, if ctx has been canceled (i.e. the client has closed the connection), a Problem is generated from
+// ClientClosedRequestDefinition that wraps err instead of using probFunc, unless err represents a value recovered from
+// a panic (i.e. recovered is true), as a genuine crash must never be hidden as a client disconnection.
+func (g *Generator) problemFromError(ctx context.Context, err error, probFunc func(err error) *Problem, recovered bool) *Problem {
+	if prob, isProblem := As(err); isProblem {
+		return prob
+	}
+	if !recovered && isClientClosed(ctx) {
+		return g.new(ctx, []Option{FromDefinition(ClientClosedRequestDefinition), Wrap(err)}, 1)
+	}
+	if def, ok := g.mapError(err); ok {
+		return g.new(ctx, []Option{FromDefinition(def), Wrap(err)}, 1)
+	}
+	if probFunc != nil {
+		return probFunc(err)
+	}
+	if f := g.DefaultProblemFactory; f != nil {
+		return f(err)
+	}
+	return g.new(ctx, []Option{Wrap(err)}, 1)
 }
 
 // writeProblem writes an HTTP response for the given Problem using WriteOptions, that are expected to have been
@@ -179,14 +442,18 @@ func (g *Generator) WriteProblemXML(prob *Problem, w http.ResponseWriter, req *h
 //
 // Panics if WriteOptions.ContentType is not recognized.
 func (g *Generator) writeProblem(prob *Problem, w http.ResponseWriter, req *http.Request, opts WriteOptions) error {
-	switch opts.ContentType {
-	case ContentTypeJSON, ContentTypeJSONUTF8:
+	switch f, _ := g.contentTypeFormat(opts.ContentType); f {
+	case FormatJSON:
 		return g.writeProblemJSON(prob, w, req, opts)
-	case ContentTypeXML, ContentTypeXMLUTF8:
+	case FormatXML:
 		return g.writeProblemXML(prob, w, req, opts)
-	default:
+	}
+	switch enc := g.Encoders[opts.ContentType]; enc {
+	case nil:
 		// Sanity check - should never happen
 		panic(fmt.Errorf("unexpected WriteOptions.ContentType applied: %q", opts.ContentType))
+	default:
+		return g.writeProblemUsing(prob, w, req, opts, enc)
 	}
 }
 
@@ -195,14 +462,14 @@ func (g *Generator) writeProblem(prob *Problem, w http.ResponseWriter, req *http
 //
 // An error is returned if prob fails to be written to w.
 func (g *Generator) writeProblemJSON(prob *Problem, w http.ResponseWriter, req *http.Request, opts WriteOptions) error {
-	if !opts.LogDisabled && opts.LogMessage != "" {
-		g.LogContext(req.Context(), opts.LogMessage, prob, opts.LogArgs...)
+	if !g.observeProblem(prob, w, req, opts) {
+		return nil
 	}
 
-	w.Header().Set(contentTypeHeader, opts.ContentType)
-	w.WriteHeader(firstNonZeroValue(opts.Status, prob.Status, http.StatusInternalServerError))
-
-	return json.NewEncoder(w).Encode(prob)
+	opts.ContentType = g.contentTypeRFC7807(opts.ContentType)
+	return writeProblemBody(req.Context(), prob, w, opts, g.fallbackJSON(), func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(g.outputProblem(req.Context(), prob, opts))
+	})
 }
 
 // writeProblemXML writes an HTTP response for the given Problem in XML format using WriteOptions, that are expected to
@@ -210,14 +477,134 @@ func (g *Generator) writeProblemJSON(prob *Problem, w http.ResponseWriter, req *
 //
 // An error is returned if prob fails to be written to w.
 func (g *Generator) writeProblemXML(prob *Problem, w http.ResponseWriter, req *http.Request, opts WriteOptions) error {
-	if !opts.LogDisabled && opts.LogMessage != "" {
-		g.LogContext(req.Context(), opts.LogMessage, prob, opts.LogArgs...)
+	if !g.observeProblem(prob, w, req, opts) {
+		return nil
 	}
 
+	opts.ContentType = g.contentTypeRFC7807(opts.ContentType)
+	return writeProblemBody(req.Context(), prob, w, opts, g.fallbackXML(), func(w io.Writer) error {
+		return xml.NewEncoder(w).Encode(g.outputProblem(req.Context(), prob, opts))
+	})
+}
+
+// writeProblemUsing writes an HTTP response for the given Problem in a custom format using the given Encoder and
+// WriteOptions, that are expected to have been applied, to determine how the response is formed and whether the
+// Problem is logged.
+//
+// Unlike the built-in formats, no fallback body is written if prob fails to be encoded.
+//
+// An error is returned if prob fails to be written to w.
+func (g *Generator) writeProblemUsing(prob *Problem, w http.ResponseWriter, req *http.Request, opts WriteOptions, enc Encoder) error {
+	if !g.observeProblem(prob, w, req, opts) {
+		return nil
+	}
+
+	return writeProblemBody(req.Context(), prob, w, opts, "", func(w io.Writer) error {
+		return enc(w, g.outputProblem(req.Context(), prob, opts))
+	})
+}
+
+// countingWriter is an io.Writer that counts the number of bytes written to the underlying io.Writer.
+type countingWriter struct {
+	n int
+	w io.Writer
+}
+
+// Write writes p to the underlying io.Writer, counting the number of bytes written.
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += n
+	return n, err
+}
+
+// claimWrite returns whether an HTTP response can be written for a Problem based on the write guard within the given
+// context.Context, if any, marking it as written. See GuardWrites for more information.
+//
+// If ctx has no write guard, true is always returned.
+func claimWrite(ctx context.Context) bool {
+	guard, ok := ctx.Value(contextKeyWriteGuard).(*atomic.Bool)
+	if !ok {
+		return true
+	}
+	return guard.CompareAndSwap(false, true)
+}
+
+// setWriteDeadline applies a write deadline to the given http.ResponseWriter using http.ResponseController, where the
+// deadline is the given timeout from now.
+//
+// The deadline is not cleared once the HTTP response has been written since it must also cover any buffered body that
+// is only flushed once the handler returns, after which the http.Server clears it. Any error, including
+// http.ErrNotSupported, is ignored as the HTTP response can still be written without a deadline.
+func setWriteDeadline(w http.ResponseWriter, timeout time.Duration) {
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(timeout))
+}
+
+// writeProblemBody writes the headers of an HTTP response for the given Problem using WriteOptions, that are expected
+// to have been applied, before using the given function to encode the Problem into the body of the HTTP response.
+//
+// If either WriteOptions.Signer or WriteOptions.BodyObserver are present, the body is encoded in memory so that it can
+// be signed before anything is written and/or observed once written.
+//
+// If WriteOptions.WriteTimeout is present, it is applied as a write deadline before anything is written.
+//
+// If prob fails to be encoded before anything has been written to the body, the fallback body is written instead.
+// However, an error is still returned if prob fails to be encoded or written to w.
+func writeProblemBody(ctx context.Context, prob *Problem, w http.ResponseWriter, opts WriteOptions, fallback string, encode func(w io.Writer) error) error {
+	status := firstNonZeroValue(opts.Status, prob.Status, http.StatusInternalServerError)
+	if opts.WriteTimeout > 0 {
+		setWriteDeadline(w, opts.WriteTimeout)
+	}
+	if opts.LinkHeader {
+		addLinkHeader(w.Header(), prob)
+	}
+	addRateLimitHeaders(w.Header(), prob)
+	addRetryAfterHeader(w.Header(), prob)
+	if opts.Signer == nil && opts.BodyObserver == nil {
+		w.Header().Set(contentTypeHeader, opts.ContentType)
+		w.WriteHeader(status)
+		cw := &countingWriter{w: w}
+		err := encode(cw)
+		if err != nil && cw.n == 0 {
+			_, _ = io.WriteString(w, fallback)
+		}
+		return err
+	}
+
+	var buf bytes.Buffer
+	encodeErr := encode(&buf)
+	body := buf.Bytes()
+	if encodeErr != nil {
+		body = []byte(fallback)
+	}
 	w.Header().Set(contentTypeHeader, opts.ContentType)
-	w.WriteHeader(firstNonZeroValue(opts.Status, prob.Status, http.StatusInternalServerError))
+	if opts.Signer != nil {
+		if name, value := opts.Signer(body); name != "" {
+			w.Header().Set(name, value)
+		}
+	}
+	w.WriteHeader(status)
+	if _, err := w.Write(body); err != nil {
+		return err
+	}
+	if opts.BodyObserver != nil {
+		opts.BodyObserver(ctx, prob, body)
+	}
+	return encodeErr
+}
 
-	return xml.NewEncoder(w).Encode(prob)
+// GuardWrites returns a copy of the given parent context.Context containing a guard that ensures that, once an HTTP
+// response has been written for a Problem for an HTTP request bound to the context.Context, any subsequent attempts to
+// write an HTTP response for a Problem (e.g. via WriteProblem or WriteError) for the same HTTP request only result in
+// the Problem being logged. This protects against layered middleware each trying to write a Problem to the same HTTP
+// response.
+//
+// If parent already contains a guard, it is returned as-is so that the guard is shared. The Middleware functions
+// call GuardWrites on each HTTP request automatically.
+func GuardWrites(parent context.Context) context.Context {
+	if _, ok := parent.Value(contextKeyWriteGuard).(*atomic.Bool); ok {
+		return parent
+	}
+	return context.WithValue(parent, contextKeyWriteGuard, new(atomic.Bool))
 }
 
 // Middleware is a convenient shorthand for calling MiddlewareUsing with DefaultGenerator.
@@ -229,33 +616,83 @@ func Middleware(probFunc func(err error) *Problem, opts ...WriteOptions) func(ht
 // with the given Generator (which can be retrieved using GetGenerator) and also provides panic recovery, allowing
 // recovered values to be used to form Problem HTTP responses, optionally using WriteOptions for more granular control.
 //
-// If a value recovered from a panic is not a Problem (which is highly likely), probFunc is called with an error
-// representation of that value (if not already an error) to be used to construct a Problem.
+// This is a convenient shorthand for calling MiddlewareUsingWithOptions with MiddlewareOptions containing the given
+// WriteOptions, where MiddlewareUsingWithOptions may be preferred for even more granular control (e.g. to propagate
+// specific panics).
 func MiddlewareUsing(gen *Generator, probFunc func(err error) *Problem, opts ...WriteOptions) func(http.Handler) http.Handler {
+	return recoveryMiddleware(gen, probFunc, nil, opts)
+}
+
+// MiddlewareUsingWithOptions returns a middleware function that is responsible for populating the HTTP request's
+// context.Context with the given Generator (which can be retrieved using GetGenerator) and also provides panic
+// recovery, allowing recovered values to be used to form Problem HTTP responses, optionally using MiddlewareOptions for
+// more granular control.
+//
+// If a value recovered from a panic is not a Problem (which is highly likely), probFunc is called with an error
+// representation of that value (i.e. a ValueError if not already an error) to be used to construct a Problem. Wrapping
+// this error (e.g. using Wrap or WrapValue) will preserve any structured value recovered from a panic (see
+// ExtensionValue). If probFunc is nil, Generator.DefaultProblemFactory is used instead. This is the case even if the
+// HTTP request's context.Context has been canceled (i.e. the client has closed the connection) so that a genuine crash
+// is never hidden as a client disconnection (see ClientClosedRequestDefinition).
+//
+// Any recovered value matched by MiddlewareOptions.Repanic is propagated instead.
+//
+// The HTTP request's context.Context is also guarded so that an HTTP response is only ever written for a single Problem
+// per HTTP request, with any subsequent attempts only resulting in the Problem being logged (see GuardWrites).
+func MiddlewareUsingWithOptions(gen *Generator, probFunc func(err error) *Problem, opts ...MiddlewareOptions) func(http.Handler) http.Handler {
+	var mwOpts MiddlewareOptions
+	if len(opts) > 0 {
+		mwOpts = opts[0]
+	}
+	return recoveryMiddleware(gen, probFunc, mwOpts.Repanic, []WriteOptions{mwOpts.WriteOptions})
+}
+
+// MiddlewareWithOptions is a convenient shorthand for calling MiddlewareUsingWithOptions with DefaultGenerator.
+func MiddlewareWithOptions(probFunc func(err error) *Problem, opts ...MiddlewareOptions) func(http.Handler) http.Handler {
+	return MiddlewareUsingWithOptions(nil, probFunc, opts...)
+}
+
+// RouteMiddleware returns a middleware function that attaches the given WriteOptions to the HTTP request's
+// context.Context (see UsingWriteOptions) so that they are applied whenever a Problem is written for the HTTP request
+// (e.g. via Generator.WriteError or Generator.WriteProblem), before any WriteOptions passed explicitly. It is designed
+// for routers that support middleware per route or group of routes (e.g. chi and gorilla/mux), allowing handlers deeper
+// in the stack to have the way in which problems are written (e.g. log message, content/media type, status) customized
+// per route.
+//
+// Like MiddlewareUsing, it also provides panic recovery, using the Generator within the HTTP request's context.Context
+// (see GetGenerator) and Generator.DefaultProblemFactory to write a Problem for any value recovered from a panic using
+// the WriteOptions attached to the context.Context. Any recovered value matched by MiddlewareOptions.Repanic is
+// propagated instead. The HTTP request's context.Context is also guarded (see GuardWrites).
+//
+// For example;
+//
+//	r := chi.NewRouter()
+//	r.Use(Middleware(nil))
+//	r.With(RouteMiddleware(MiddlewareOptions{WriteOptions: WriteOptions{
+//		ContentType: ContentTypeXMLUTF8,
+//		LogMessage:  "Legacy API problem",
+//	}})).Get("/legacy/users/{id}", getLegacyUser)
+func RouteMiddleware(opts ...MiddlewareOptions) func(http.Handler) http.Handler {
+	var mwOpts MiddlewareOptions
+	if len(opts) > 0 {
+		mwOpts = opts[0]
+	}
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-			if gen == nil {
-				gen = DefaultGenerator
-			}
-
-			req = req.WithContext(UsingGenerator(req.Context(), gen))
+			req = req.WithContext(GuardWrites(UsingWriteOptions(req.Context(), mwOpts.WriteOptions)))
 
 			defer func() {
 				if r := recover(); r != nil {
-					var prob *Problem
+					if repanic := mwOpts.Repanic; repanic != nil && repanic(r) {
+						panic(r)
+					}
+					gen := GetGenerator(req.Context())
+					wOpts := routeWriteOptions(req.Context(), nil)
 					_opts := WriteOptions{
-						ContentType: gen.contentType(),
+						ContentType: gen.defaultContentType(w, req, wOpts),
 						LogMessage:  defaultHTTPPanicLogMessage,
-					}.apply(opts, isValidContentType)
-					if err, isErr := r.(error); isErr && err != nil {
-						var isProblem bool
-						prob, isProblem = As(err)
-						if !isProblem {
-							prob = probFunc(err)
-						}
-					} else {
-						prob = probFunc(fmt.Errorf("%v", r))
-					}
+					}.apply(wOpts, gen.isValidContentType)
+					prob := gen.problemFromError(req.Context(), valueAsError(r), nil, true)
 					_ = gen.writeProblem(prob, w, req, _opts)
 				}
 			}()
@@ -300,3 +737,47 @@ func WriteProblemJSON(prob *Problem, w http.ResponseWriter, req *http.Request, o
 func WriteProblemXML(prob *Problem, w http.ResponseWriter, req *http.Request, opts ...WriteOptions) error {
 	return GetGenerator(req.Context()).WriteProblemXML(prob, w, req, opts...)
 }
+
+// recoveryMiddleware returns a middleware function that populates the HTTP request's context.Context with the given
+// Generator and recovers from any panic, writing an HTTP response for a Problem formed from the recovered value using
+// the given WriteOptions, unless matched by repanic. See MiddlewareUsingWithOptions for more information.
+func recoveryMiddleware(gen *Generator, probFunc func(err error) *Problem, repanic func(v any) bool, wOpts []WriteOptions) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if gen == nil {
+				gen = DefaultGenerator
+			}
+
+			req = req.WithContext(GuardWrites(UsingGenerator(req.Context(), gen)))
+
+			defer func() {
+				if r := recover(); r != nil {
+					if repanic != nil && repanic(r) {
+						panic(r)
+					}
+					_opts := WriteOptions{
+						ContentType: gen.defaultContentType(w, req, wOpts),
+						LogMessage:  defaultHTTPPanicLogMessage,
+					}.apply(wOpts, gen.isValidContentType)
+					prob := gen.problemFromError(req.Context(), valueAsError(r), probFunc, true)
+					_ = gen.writeProblem(prob, w, req, _opts)
+				}
+			}()
+
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// routeWriteOptions returns the WriteOptions attached to the given context.Context (see UsingWriteOptions), if any,
+// followed by the first of the WriteOptions provided, if any, so that they can be applied in that order.
+func routeWriteOptions(ctx context.Context, opts []WriteOptions) []WriteOptions {
+	if len(opts) > 1 {
+		opts = opts[:1]
+	}
+	route, ok := GetWriteOptions(ctx)
+	if !ok {
+		return opts
+	}
+	return append([]WriteOptions{route}, opts...)
+}
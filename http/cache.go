@@ -0,0 +1,61 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package http
+
+import (
+	"github.com/neocotic/go-problem"
+	"sync"
+)
+
+// cachedProblems returns a shared problem.ProblemView for each built-in reusable problem.Type, mapped to the HTTP status
+// code of the problem.Type. The problems are only constructed once when first called.
+var cachedProblems = sync.OnceValue(func() map[int]problem.ProblemView {
+	types := AllTypes()
+	views := make(map[int]problem.ProblemView, len(types))
+	for _, t := range types {
+		p := &problem.Problem{
+			Status: t.Status,
+			Title:  t.Title,
+			Type:   problem.DefaultTypeURI,
+		}
+		views[t.Status] = p.Freeze()
+	}
+	return views
+})
+
+// CachedProblem returns a precomputed problem.ProblemView for the given HTTP status code, containing only its status,
+// title, and type URI reference (i.e. problem.DefaultTypeURI), or nil if code is unknown.
+//
+// The same problem.ProblemView is returned on each call for code and is shared by all callers, which makes it ideal
+// for ultra-fast standard responses where no per-request data is needed (e.g. a gateway rejecting requests), as nothing
+// is built or translated. Since it is shared between goroutines, it is read-only and so cannot be written as an HTTP
+// response directly, as writing may pass it to hooks and notifiers that could mutate it. Instead,
+// problem.ProblemView.CopyOnWrite must be used to obtain a problem.Problem that is owned by the caller.
+//
+// For example;
+//
+//	if !limiter.Allow() {
+//		problem.WriteProblem(CachedProblem(http.StatusTooManyRequests).CopyOnWrite(), w, req)
+//		return
+//	}
+func CachedProblem(code int) problem.ProblemView {
+	return cachedProblems()[code]
+}
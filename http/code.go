@@ -0,0 +1,119 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package http
+
+import (
+	"errors"
+	"fmt"
+	"github.com/neocotic/go-problem"
+	"net/http"
+	"strings"
+)
+
+// ParseCodeOptions contains options that can be used by ParseRequestCode to control where a problem.Code is read from
+// and how it is validated.
+//
+// All fields are optional with default behaviour clearly documented.
+type ParseCodeOptions struct {
+	// Header is the name of the HTTP request header from which the problem.Code is read, but only if not found within
+	// the query parameter.
+	//
+	// If empty, DefaultCodeHeader will be used.
+	Header string
+	// NS is the problem.NS that the problem.Code is expected to have.
+	//
+	// If empty, a problem.Code within any problem.NS is accepted.
+	NS problem.NS
+	// Param is the name of the query parameter of the HTTP request from which the problem.Code is read.
+	//
+	// If empty, DefaultCodeParam will be used.
+	Param string
+}
+
+const (
+	// DefaultCodeHeader is the name of the HTTP request header from which a problem.Code is read by ParseRequestCode by
+	// default.
+	DefaultCodeHeader = "Problem-Code"
+	// DefaultCodeParam is the name of the query parameter from which a problem.Code is read by ParseRequestCode by
+	// default.
+	DefaultCodeParam = "code"
+)
+
+// ErrCodeMissing is returned when an HTTP request does not contain a problem.Code.
+var ErrCodeMissing = errors.New("missing problem code")
+
+// ParseRequestCode reads a problem.Code from the given HTTP request, first from its query parameters and then from its
+// headers (see ParseCodeOptions), and parses it using problem.Generator.Coder. If problem.Generator.Registry is not nil,
+// the problem.Code must also be registered within it. This is useful for endpoints that receive a problem.Code from a
+// client (e.g. error-handling callbacks or support tooling).
+//
+// If gen is nil, the problem.Generator within the HTTP request's context.Context is used, if any, otherwise
+// problem.DefaultGenerator.
+//
+// A problem.Problem generated from BadRequestDefinition is returned instead of a problem.ParsedCode if the HTTP request
+// contains no problem.Code (wrapping ErrCodeMissing), it cannot be parsed (wrapping problem.ErrCode), or it is not
+// registered (wrapping problem.ErrRegistry).
+//
+// For example;
+//
+//	parsed, prob := ParseRequestCode(req, nil, ParseCodeOptions{NS: "USER"})
+//	if prob != nil {
+//		problem.WriteProblem(prob, w, req)
+//		return
+//	}
+func ParseRequestCode(req *http.Request, gen *problem.Generator, opts ...ParseCodeOptions) (problem.ParsedCode, *problem.Problem) {
+	if gen == nil {
+		gen = problem.GetGenerator(req.Context())
+	}
+	var _opts ParseCodeOptions
+	if len(opts) > 0 {
+		_opts = opts[0]
+	}
+	param := _opts.Param
+	if param == "" {
+		param = DefaultCodeParam
+	}
+	header := _opts.Header
+	if header == "" {
+		header = DefaultCodeHeader
+	}
+
+	code := strings.TrimSpace(req.URL.Query().Get(param))
+	if code == "" {
+		code = strings.TrimSpace(req.Header.Get(header))
+	}
+	var (
+		parsed problem.ParsedCode
+		err    error
+	)
+	if code == "" {
+		err = ErrCodeMissing
+	} else if parsed, err = gen.Coder(_opts.NS).Parse(problem.Code(code)); err == nil {
+		if reg := gen.Registry; reg != nil && !reg.Registered(parsed.Code) {
+			err = fmt.Errorf("%w: code is not registered: %q", problem.ErrRegistry, parsed.Code)
+		}
+	}
+	if err != nil {
+		opts := []problem.Option{problem.WithDetail(err.Error()), problem.Wrap(err)}
+		return problem.ParsedCode{}, BadRequestDefinition.NewContextUsing(req.Context(), gen, opts...)
+	}
+	return parsed, nil
+}
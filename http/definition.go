@@ -40,6 +40,13 @@ var (
 		Type:      BadRequest,
 	}
 
+	// ClientClosedRequestDefinition is a built-in reusable problem.Definition that may be used to represent a
+	// non-standard HTTP Client Closed Request error.
+	//
+	// It is equal to problem.ClientClosedRequestDefinition, which is used when a client disconnect is detected while
+	// writing an error as an HTTP response.
+	ClientClosedRequestDefinition = problem.ClientClosedRequestDefinition
+
 	// ConflictDefinition is a built-in reusable problem.Definition that may be used to represent an HTTP Conflict
 	// error.
 	ConflictDefinition = problem.Definition{
@@ -234,6 +241,13 @@ var (
 		Type:      RequestedRangeNotSatisfiable,
 	}
 
+	// SSLCertificateErrorDefinition is a built-in reusable problem.Definition that may be used to represent a
+	// non-standard HTTP SSL Certificate Error error.
+	SSLCertificateErrorDefinition = problem.Definition{
+		DetailKey: "problem.http.SSLCertificateErrorDefinition.detail",
+		Type:      SSLCertificateError,
+	}
+
 	// ServiceUnavailableDefinition is a built-in reusable problem.Definition that may be used to represent an HTTP
 	// Service Unavailable error.
 	ServiceUnavailableDefinition = problem.Definition{
@@ -305,6 +319,64 @@ var (
 	}
 )
 
+// AllDefinitions returns a slice containing each built-in reusable problem.Definition, ordered by the HTTP status code
+// of their problem.Type.
+//
+// A new slice is returned on each call so that it may be safely modified by the caller. This can be useful for
+// bulk-registering, linting, or documenting all built-in definitions programmatically.
+//
+// For example;
+//
+//	for _, def := range AllDefinitions() {
+//		fmt.Printf("%d %s\n", def.Type.Status, def.DetailKey)
+//	}
+func AllDefinitions() []problem.Definition {
+	return []problem.Definition{
+		BadRequestDefinition,
+		UnauthorizedDefinition,
+		PaymentRequiredDefinition,
+		ForbiddenDefinition,
+		NotFoundDefinition,
+		MethodNotAllowedDefinition,
+		NotAcceptableDefinition,
+		ProxyAuthRequiredDefinition,
+		RequestTimeoutDefinition,
+		ConflictDefinition,
+		GoneDefinition,
+		LengthRequiredDefinition,
+		PreconditionFailedDefinition,
+		RequestEntityTooLargeDefinition,
+		RequestURITooLongDefinition,
+		UnsupportedMediaTypeDefinition,
+		RequestedRangeNotSatisfiableDefinition,
+		ExpectationFailedDefinition,
+		TeapotDefinition,
+		MisdirectedRequestDefinition,
+		UnprocessableEntityDefinition,
+		LockedDefinition,
+		FailedDependencyDefinition,
+		TooEarlyDefinition,
+		UpgradeRequiredDefinition,
+		PreconditionRequiredDefinition,
+		TooManyRequestsDefinition,
+		RequestHeaderFieldsTooLargeDefinition,
+		UnavailableForLegalReasonsDefinition,
+		SSLCertificateErrorDefinition,
+		ClientClosedRequestDefinition,
+		InternalServerDefinition,
+		NotImplementedDefinition,
+		BadGatewayDefinition,
+		ServiceUnavailableDefinition,
+		GatewayTimeoutDefinition,
+		HTTPVersionNotSupportedDefinition,
+		VariantAlsoNegotiatesDefinition,
+		InsufficientStorageDefinition,
+		LoopDetectedDefinition,
+		NotExtendedDefinition,
+		NetworkAuthenticationRequiredDefinition,
+	}
+}
+
 // StatusDefinition returns a problem.Definition for the given HTTP status code or an empty/zero problem.Definition if
 // code is unknown.
 //
@@ -386,6 +458,10 @@ func StatusDefinitionOrElse(code int, defaultDefinition problem.Definition) prob
 		return RequestHeaderFieldsTooLargeDefinition
 	case http.StatusUnavailableForLegalReasons:
 		return UnavailableForLegalReasonsDefinition
+	case StatusSSLCertificateError:
+		return SSLCertificateErrorDefinition
+	case StatusClientClosedRequest:
+		return ClientClosedRequestDefinition
 	case http.StatusInternalServerError:
 		return InternalServerDefinition
 	case http.StatusNotImplemented:
@@ -0,0 +1,99 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"github.com/neocotic/go-problem"
+	"net"
+	"syscall"
+)
+
+// FromNetError returns a problem.Definition that is most appropriate for the given network error, typically
+// encountered by a gateway or proxy when communicating with an upstream server, or an empty/zero problem.Definition if
+// err is not a recognized network error.
+//
+// The following errors are recognized, in order of precedence:
+//
+//   - A TLS certificate that could not be verified maps to SSLCertificateErrorDefinition
+//   - A timeout (incl. context.DeadlineExceeded) maps to GatewayTimeoutDefinition
+//   - A DNS lookup failure, refused or reset connection, or failed TLS handshake maps to BadGatewayDefinition
+//   - Any other net.Error maps to BadGatewayDefinition
+//
+// For example;
+//
+//	res, err := client.Do(req)
+//	if err != nil {
+//		def := FromNetError(err)
+//		if def.Type.Status == 0 {
+//			def = InternalServerDefinition
+//		}
+//		problem.WriteProblem(def.New(problem.Wrap(err)), w, req)
+//		return
+//	}
+func FromNetError(err error) problem.Definition {
+	if err == nil {
+		return problem.Definition{}
+	}
+	if isCertificateError(err) {
+		return SSLCertificateErrorDefinition
+	}
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return GatewayTimeoutDefinition
+	}
+	var (
+		dnsErr    *net.DNSError
+		alertErr  tls.AlertError
+		recordErr tls.RecordHeaderError
+	)
+	switch {
+	case errors.As(err, &dnsErr),
+		errors.Is(err, syscall.ECONNREFUSED),
+		errors.Is(err, syscall.ECONNRESET),
+		errors.As(err, &alertErr),
+		errors.As(err, &recordErr),
+		errors.As(err, &netErr):
+		return BadGatewayDefinition
+	default:
+		return problem.Definition{}
+	}
+}
+
+// isCertificateError returns whether the given error, or any error within its tree, indicates that a TLS certificate
+// could not be verified.
+func isCertificateError(err error) bool {
+	var (
+		verifyErr     *tls.CertificateVerificationError
+		authorityErr  x509.UnknownAuthorityError
+		hostnameErr   x509.HostnameError
+		invalidErr    x509.CertificateInvalidError
+		constraintErr x509.ConstraintViolationError
+	)
+	return errors.As(err, &verifyErr) ||
+		errors.As(err, &authorityErr) ||
+		errors.As(err, &hostnameErr) ||
+		errors.As(err, &invalidErr) ||
+		errors.As(err, &constraintErr)
+}
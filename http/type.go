@@ -25,6 +25,16 @@ import (
 	"net/http"
 )
 
+const (
+	// StatusClientClosedRequest is the non-standard HTTP status code, popularized by nginx, that is used to indicate
+	// that the client closed the connection before the server could respond (i.e. the HTTP request's context.Context
+	// was canceled).
+	StatusClientClosedRequest = problem.StatusClientClosedRequest
+	// StatusSSLCertificateError is the non-standard HTTP status code, popularized by nginx, that is used to indicate
+	// that a TLS certificate could not be verified.
+	StatusSSLCertificateError = 495
+)
+
 var (
 	// BadGateway is a built-in reusable problem.Type that may be used to represent an HTTP Bad Gateway error.
 	BadGateway = problem.Type{
@@ -42,6 +52,13 @@ var (
 		TitleKey: "problem.http.BadRequest.title",
 	}
 
+	// ClientClosedRequest is a built-in reusable problem.Type that may be used to represent a non-standard HTTP Client
+	// Closed Request error.
+	//
+	// It is equal to problem.ClientClosedRequestType, which is used when a client disconnect is detected while writing
+	// an error as an HTTP response.
+	ClientClosedRequest = problem.ClientClosedRequestType
+
 	// Conflict is a built-in reusable problem.Type that may be used to represent an HTTP Conflict error.
 	Conflict = problem.Type{
 		LogLevel: problem.LogLevelDebug,
@@ -280,6 +297,15 @@ var (
 		TitleKey: "problem.http.RequestedRangeNotSatisfiable.title",
 	}
 
+	// SSLCertificateError is a built-in reusable problem.Type that may be used to represent a non-standard HTTP SSL
+	// Certificate Error error.
+	SSLCertificateError = problem.Type{
+		LogLevel: problem.LogLevelDebug,
+		Status:   StatusSSLCertificateError,
+		Title:    "SSL Certificate Error",
+		TitleKey: "problem.http.SSLCertificateError.title",
+	}
+
 	// ServiceUnavailable is a built-in reusable problem.Type that may be used to represent an HTTP Service Unavailable
 	// error.
 	ServiceUnavailable = problem.Type{
@@ -367,6 +393,63 @@ var (
 	}
 )
 
+// AllTypes returns a slice containing each built-in reusable problem.Type, ordered by their HTTP status code.
+//
+// A new slice is returned on each call so that it may be safely modified by the caller. This can be useful for
+// bulk-registering, linting, or documenting all built-in types programmatically.
+//
+// For example;
+//
+//	for _, t := range AllTypes() {
+//		fmt.Printf("%d %s\n", t.Status, t.Title)
+//	}
+func AllTypes() []problem.Type {
+	return []problem.Type{
+		BadRequest,
+		Unauthorized,
+		PaymentRequired,
+		Forbidden,
+		NotFound,
+		MethodNotAllowed,
+		NotAcceptable,
+		ProxyAuthRequired,
+		RequestTimeout,
+		Conflict,
+		Gone,
+		LengthRequired,
+		PreconditionFailed,
+		RequestEntityTooLarge,
+		RequestURITooLong,
+		UnsupportedMediaType,
+		RequestedRangeNotSatisfiable,
+		ExpectationFailed,
+		Teapot,
+		MisdirectedRequest,
+		UnprocessableEntity,
+		Locked,
+		FailedDependency,
+		TooEarly,
+		UpgradeRequired,
+		PreconditionRequired,
+		TooManyRequests,
+		RequestHeaderFieldsTooLarge,
+		UnavailableForLegalReasons,
+		SSLCertificateError,
+		ClientClosedRequest,
+		InternalServer,
+		NotImplemented,
+		BadGateway,
+		ServiceUnavailable,
+		GatewayTimeout,
+		HTTPVersionNotSupported,
+		VariantAlsoNegotiates,
+		InsufficientStorage,
+		LoopDetected,
+		NotExtended,
+		NetworkAuthenticationRequired,
+	}
+}
+
 // StatusType returns a problem.Type for the given HTTP status code or an empty/zero problem.Type if code is unknown.
 //
 // For example;
@@ -446,6 +529,10 @@ func StatusTypeOrElse(code int, defaultType problem.Type) problem.Type {
 		return RequestHeaderFieldsTooLarge
 	case http.StatusUnavailableForLegalReasons:
 		return UnavailableForLegalReasons
+	case StatusSSLCertificateError:
+		return SSLCertificateError
+	case StatusClientClosedRequest:
+		return ClientClosedRequest
 	case http.StatusInternalServerError:
 		return InternalServer
 	case http.StatusNotImplemented:
@@ -20,14 +20,137 @@
 
 package problem
 
-import "context"
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"golang.org/x/text/language"
+)
+
+// I18NOutputMode controls how localized values are output within a Problem. See Generator.I18NOutputMode for more
+// information.
+type I18NOutputMode uint
+
+const (
+	// I18NOutputLocalized indicates that the title and detail of a Problem are localized, where possible.
+	I18NOutputLocalized I18NOutputMode = iota
+	// I18NOutputDual indicates that the title and detail of a Problem are never localized, allowing them to remain
+	// machine-stable, while their localized counterparts are included within its extensions (see
+	// ExtensionLocalizedDetail and ExtensionLocalizedTitle).
+	I18NOutputDual
+)
+
+// Key is a structured translation key that may be used as Definition.DetailKey or Type.TitleKey whenever a simple
+// string is insufficient (e.g. a message identifier along with named arguments for its placeholders).
+//
+// Unlike other structured values, a Key survives a round-trip when a Definition or Type is marshaled to and unmarshaled
+// from JSON (e.g. a catalog within a configuration file). When unmarshaled, a translation key that is a JSON string is
+// decoded as a string while a JSON object is decoded as a Key.
+//
+// For example;
+//
+//	def := Definition{DetailKey: Key{ID: "user.notFound", Args: map[string]any{"name": "bob"}}}
+//	b, _ := json.Marshal(def)  // {...,"detailKey":{"args":{"name":"bob"},"id":"user.notFound"},...}
+type Key struct {
+	// Args contains any named arguments to be used by Generator.Translator when resolving the localized value (e.g. to
+	// replace placeholders within a message).
+	Args map[string]any `json:"args,omitempty" xml:"args,omitempty" yaml:"args,omitempty"`
+	// ID is the identifier of the message to be resolved by Generator.Translator.
+	ID string `json:"id" xml:"id" yaml:"id"`
+}
+
+// KeyInfo contains information on a translation key found within a Definition or Type by ExtractTranslationKeys.
+type KeyInfo struct {
+	// Code is the Code of the Definition in which the translation key was found, if any.
+	Code Code
+	// Default is the non-localized value used when the translation key cannot be resolved (e.g. Definition.Detail for
+	// Definition.DetailKey), if any.
+	Default string
+	// Field is the name of the Problem field for which the translation key is used (i.e. "detail" or "title").
+	Field string
+	// Key is the translation key.
+	Key any
+	// Status is the status of the Type in which the translation key was found, if any.
+	Status int
+	// TypeURI is the URI of the Type in which the translation key was found, if any.
+	TypeURI string
+}
+
+// RenderedProblem contains a Problem generated from a Definition for a specific language by RenderAll, along with
+// the fields whose translation key could not be resolved for that language.
+type RenderedProblem struct {
+	// Missing contains each Field (i.e. FieldDetail and/or FieldTitle) whose translation key could not be resolved for
+	// the language, in which case the Problem contains the non-localized value for that Field.
+	//
+	// If empty, every translation key was resolved.
+	Missing []Field
+	// Problem is the Problem generated from the Definition for the language.
+	Problem *Problem
+}
+
+const (
+	// ExtensionLocalizedDetail is the key of the extension containing the localized detail of a Problem when
+	// I18NOutputDual is used. It is only included if the Problem has a detail.
+	ExtensionLocalizedDetail = "localized_detail"
+	// ExtensionLocalizedTitle is the key of the extension containing the localized title of a Problem when
+	// I18NOutputDual is used.
+	ExtensionLocalizedTitle = "localized_title"
+)
+
+// String returns Key.ID, allowing a Key to be used by any Translator that supports keys implementing fmt.Stringer.
+func (k Key) String() string {
+	return k.ID
+}
 
 // Translator is a function that returns a localized value based on the translation key provided.
 //
 // An empty string must always be returned if no localized value could be found for key, even if there was an internal
 // error. This allows a Problem to be constructed using a fallback value for the associated field.
+//
+// A Translator should use GetLanguage to determine the language in which the value is to be localized, where
+// available, in order to support Generator.LanguageFallbacks. Likewise, it should support any Key it may be given.
 type Translator func(ctx context.Context, key any) string
 
+// ExtractTranslationKeys returns information on all translation keys (i.e. Definition.DetailKey and Type.TitleKey)
+// found within the given definitions (including the Type of each) followed by those found within the given types, in
+// order, along with the context in which each was found. This allows translation files to be generated programmatically and any unused and/or missing translation
+// keys to be detected.
+//
+// Any translation key that is nil is ignored. Otherwise, a KeyInfo is returned for every occurrence of a translation
+// key, even if it occurs multiple times (e.g. multiple definitions sharing the same Type).
+//
+// For example;
+//
+//	for _, info := range ExtractTranslationKeys(http.AllDefinitions(), nil) {
+//		fmt.Printf("%v=%s\n", info.Key, info.Default)
+//	}
+func ExtractTranslationKeys(defs []Definition, types []Type) []KeyInfo {
+	var infos []KeyInfo
+	for _, def := range defs {
+		if def.DetailKey != nil {
+			infos = append(infos, KeyInfo{
+				Code:    def.Code,
+				Default: def.Detail,
+				Field:   "detail",
+				Key:     def.DetailKey,
+				Status:  def.Type.Status,
+				TypeURI: def.Type.URI,
+			})
+		}
+		if def.Type.TitleKey != nil {
+			info := typeKeyInfo(def.Type)
+			info.Code = def.Code
+			infos = append(infos, info)
+		}
+	}
+	for _, defType := range types {
+		if defType.TitleKey != nil {
+			infos = append(infos, typeKeyInfo(defType))
+		}
+	}
+	return infos
+}
+
 // NoopTranslator returns a Translator that always returns an empty string, forcing the Problem to be constructed using
 // a fallback value for the associated field.
 func NoopTranslator() Translator {
@@ -36,15 +159,115 @@ func NoopTranslator() Translator {
 	}
 }
 
+// RenderAll returns a RenderedProblem generated from the given Definition for each of the languages provided using the
+// Translator, allowing every localized variant of a Definition to be previewed (e.g. within tests or an admin UI) and
+// any missing translations to be detected.
+//
+// Each Problem is generated using a Generator whose Translator is translator, otherwise the same as DefaultGenerator,
+// with a context.Context containing the language (see UsingLanguage). As no fallback languages are consulted, a
+// translation key is only considered to be resolved if translator returns a localized value for that language.
+//
+// If translator is nil, NoopTranslator is used and so every translation key is missing.
+//
+// For example;
+//
+//	for tag, rendered := range RenderAll(UserNotFound, []language.Tag{language.English, language.French}, translator) {
+//		if len(rendered.Missing) > 0 {
+//			t.Errorf("missing %v translations for %s", rendered.Missing, tag)
+//		}
+//	}
+func RenderAll(def Definition, langs []language.Tag, translator Translator) map[language.Tag]RenderedProblem {
+	if translator == nil {
+		translator = NoopTranslator()
+	}
+	gen := &Generator{Translator: translator}
+	rendered := make(map[language.Tag]RenderedProblem, len(langs))
+	for _, tag := range langs {
+		ctx := UsingLanguage(context.Background(), tag)
+		var missing []Field
+		if def.DetailKey != nil && translator(ctx, def.DetailKey) == "" {
+			missing = append(missing, FieldDetail)
+		}
+		if def.Type.TitleKey != nil && translator(ctx, def.Type.TitleKey) == "" {
+			missing = append(missing, FieldTitle)
+		}
+		rendered[tag] = RenderedProblem{
+			Missing: missing,
+			Problem: def.NewContextUsing(ctx, gen),
+		}
+	}
+	return rendered
+}
+
+// Translate returns the localized value for the given translation key using Generator.Translator, where possible,
+// including consulting Generator.LanguageFallbacks in the same way as when building a Problem. This allows integrations
+// to localize values related to a Problem (e.g. within its extensions) consistently with its title and detail.
+//
+// An empty string is returned if no localized value could be found for key or Generator.Translator is nil.
+func (g *Generator) Translate(ctx context.Context, key any) string {
+	return g.translateOrElse(ctx, true, key, "")
+}
+
 // translateOrElse returns the localized value for the given translation key using Generator.Translator, where possible,
 // falling back on the default value provided.
 //
-// If Generator.Translator is nil, defaultValue is returned. This is the equivalent of using NoopTranslator.
-func (g *Generator) translateOrElse(ctx context.Context, key any, defaultValue string) string {
-	if t := g.Translator; t != nil {
+// If no localized value could be found for key, Generator.Translator is called again for each language.Tag within
+// Generator.LanguageFallbacks, in order, with ctx containing that language.Tag (see UsingLanguage), until one is found.
+//
+// If localize is false, key is nil, or Generator.Translator is nil, defaultValue is returned. This is the equivalent of
+// using NoopTranslator.
+func (g *Generator) translateOrElse(ctx context.Context, localize bool, key any, defaultValue string) string {
+	t := g.Translator
+	if t == nil || !localize || key == nil {
 		return defaultValue
-	} else if v := t(ctx, key); v != "" {
+	}
+	if v := t(ctx, key); v != "" {
 		return v
 	}
+	current, hasCurrent := GetLanguage(ctx)
+	for _, tag := range g.LanguageFallbacks {
+		if hasCurrent && tag == current {
+			continue
+		}
+		if v := t(UsingLanguage(ctx, tag), key); v != "" {
+			return v
+		}
+	}
 	return defaultValue
 }
+
+// typeKeyInfo returns a KeyInfo for Type.TitleKey of the given Type.
+func typeKeyInfo(defType Type) KeyInfo {
+	return KeyInfo{
+		Default: defType.Title,
+		Field:   "title",
+		Key:     defType.TitleKey,
+		Status:  defType.Status,
+		TypeURI: defType.URI,
+	}
+}
+
+// unmarshalKey returns the translation key unmarshaled from the given JSON data, which is a string if data is a JSON
+// string, a Key if data is a JSON object, otherwise nil if data is a JSON null.
+//
+// An error is returned if data is any other type of JSON value or is unable to be unmarshaled.
+func unmarshalKey(data json.RawMessage) (any, error) {
+	switch {
+	case len(data) == 0 || string(data) == "null":
+		return nil, nil
+	case data[0] == '"':
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, err
+		}
+		return s, nil
+	case data[0] == '{':
+		var k Key
+		if err := json.Unmarshal(data, &k); err != nil {
+			return nil, err
+		}
+		return k, nil
+	default:
+		return nil, fmt.Errorf("unsupported translation key: %s", data)
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package problem
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/language"
+	"net/http"
+	"testing"
+)
+
+func Test_Generator_New_NilTranslator(t *testing.T) {
+	gen := &Generator{}
+
+	var prob *Problem
+	assert.NotPanics(t, func() {
+		prob = gen.New(WithTitleKeyOrElse("title", "Fallback"))
+	})
+	assert.Equal(t, "Fallback", prob.Title)
+}
+
+func Test_Generator_New_Translator(t *testing.T) {
+	gen := &Generator{Translator: func(_ context.Context, key any) string {
+		if key == "title" {
+			return "Translated"
+		}
+		return ""
+	}}
+
+	assert.Equal(t, "Translated", gen.New(WithTitleKeyOrElse("title", "Fallback")).Title)
+	assert.Equal(t, "Fallback", gen.New(WithTitleKeyOrElse("other", "Fallback")).Title)
+}
+
+func Test_Generator_New_StatusTitles(t *testing.T) {
+	gen := &Generator{
+		StatusTitles: map[int]string{
+			http.StatusNotFound:           "title.notFound",
+			http.StatusServiceUnavailable: "Down for maintenance",
+		},
+		Translator: func(ctx context.Context, key any) string {
+			if tag, _ := GetLanguage(ctx); tag == language.French && key == "title.notFound" {
+				return "Introuvable"
+			}
+			return ""
+		},
+	}
+	ctx := UsingLanguage(context.Background(), language.French)
+
+	assert.Equal(t, "Introuvable", gen.NewContext(ctx, WithStatus(http.StatusNotFound)).Title)
+	assert.Equal(t, "Down for maintenance", gen.NewContext(ctx, WithStatus(http.StatusServiceUnavailable)).Title)
+	assert.Equal(t, "title.notFound", gen.New(WithStatus(http.StatusNotFound)).Title)
+}
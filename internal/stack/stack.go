@@ -89,6 +89,31 @@ func Capture(skip int) *Stack {
 	return stack
 }
 
+// Callers captures the program counters of the current stack trace and returns a copy of them, which is cheaper than
+// Take as no frames are resolved or formatted.
+//
+// skip is the number of frames before recording the stack trace with zero identifying the caller of Callers.
+func Callers(skip int) []uintptr {
+	stack := Capture(skip + 1)
+	defer stack.Free()
+
+	return append([]uintptr(nil), stack.pcs...)
+}
+
+// Frames resolves the frames for the given program counters (excl. final runtime.main/runtime.goexit frame), in the
+// same way as Formatter.FormatStack.
+func Frames(pcs []uintptr) []runtime.Frame {
+	if len(pcs) == 0 {
+		return nil
+	}
+	frames := runtime.CallersFrames(pcs)
+	result := make([]runtime.Frame, 0, len(pcs))
+	for frame, more := frames.Next(); more; frame, more = frames.Next() {
+		result = append(result, frame)
+	}
+	return result
+}
+
 // Take captures the current stack trace and returns its string representation.
 //
 // skip is the number of frames before recording the stack trace with zero identifying the caller of Take.
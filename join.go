@@ -0,0 +1,132 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package problem
+
+import "errors"
+
+// ExtensionSubProblems is the key of the extension containing any other problems found within a joined error (i.e. an
+// error implementing Unwrap() []error) passed to Builder.Wrap or Wrap, when not selected as the primary Problem. See
+// Generator.ProblemSelector for more information.
+const ExtensionSubProblems = "subProblems"
+
+// ProblemSelector is a function used to select the primary Problem from those found within a joined error (i.e. an
+// error implementing Unwrap() []error), in the order in which they were joined. probs is guaranteed to contain at least
+// two problems. If nil is returned, the first Problem is selected.
+type ProblemSelector func(probs []*Problem) *Problem
+
+// FirstProblemSelector returns a ProblemSelector that selects the first Problem found within a joined error.
+func FirstProblemSelector() ProblemSelector {
+	return func(probs []*Problem) *Problem {
+		return probs[0]
+	}
+}
+
+// HighestStatusProblemSelector returns a ProblemSelector that selects the Problem with the highest status found within
+// a joined error. If multiple problems share the highest status, the first of those is selected.
+func HighestStatusProblemSelector() ProblemSelector {
+	return func(probs []*Problem) *Problem {
+		selected := probs[0]
+		for _, p := range probs[1:] {
+			if p.Status > selected.Status {
+				selected = p
+			}
+		}
+		return selected
+	}
+}
+
+// MostSevereProblemSelector returns a ProblemSelector that selects the Problem with the most severe LogLevel found
+// within a joined error. If multiple problems share the most severe LogLevel, the first of those is selected.
+func MostSevereProblemSelector() ProblemSelector {
+	return func(probs []*Problem) *Problem {
+		selected := probs[0]
+		for _, p := range probs[1:] {
+			if p.LogInfo().Level > selected.LogInfo().Level {
+				selected = p
+			}
+		}
+		return selected
+	}
+}
+
+// selectJoinedProblems returns the primary Problem selected by the given ProblemSelector from those found within the
+// first joined error (i.e. an error implementing Unwrap() []error) within err's chain, along with all other problems
+// that were found, in the order in which they were joined. Additionally, every error within the joined error is
+// returned, in the order in which they were joined except for that containing the primary Problem, which is moved to
+// the front.
+//
+// Only the first Problem within the tree of each joined error is considered. If err's chain contains a Problem before
+// any joined error, fewer than two problems are found, or selector is nil, nil is returned for all.
+func selectJoinedProblems(err error, selector ProblemSelector) (*Problem, []*Problem, []error) {
+	if selector == nil {
+		return nil, nil, nil
+	}
+	var errs []error
+	for depth := 0; err != nil && depth < MaxTreeDepth; depth++ {
+		if p, isProblem := err.(*Problem); isProblem {
+			if p == nil {
+				break
+			}
+			return nil, nil, nil
+		}
+		if joined, isJoined := err.(interface{ Unwrap() []error }); isJoined {
+			errs = joined.Unwrap()
+			break
+		}
+		err = errors.Unwrap(err)
+	}
+	var probs []*Problem
+	var probIndices []int
+	for i, e := range errs {
+		if p, isProblem := As(e); isProblem && p != nil {
+			probs = append(probs, p)
+			probIndices = append(probIndices, i)
+		}
+	}
+	if len(probs) < 2 {
+		return nil, nil, nil
+	}
+	primary := selector(probs)
+	if primary == nil {
+		primary = probs[0]
+	}
+	primaryIndex := -1
+	others := make([]*Problem, 0, len(probs)-1)
+	for i, p := range probs {
+		if p == primary && primaryIndex < 0 {
+			primaryIndex = probIndices[i]
+		} else {
+			others = append(others, p)
+		}
+	}
+	if primaryIndex < 0 {
+		// Selector returned a Problem that was not found so fallback to the first
+		primary, primaryIndex, others = probs[0], probIndices[0], probs[1:]
+	}
+	branches := make([]error, 0, len(errs))
+	branches = append(branches, errs[primaryIndex])
+	for i, e := range errs {
+		if i != primaryIndex {
+			branches = append(branches, e)
+		}
+	}
+	return primary, others, branches
+}
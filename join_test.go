@@ -0,0 +1,81 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package problem
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/fs"
+	"net/http"
+	"testing"
+)
+
+func Test_New_JoinedError(t *testing.T) {
+	first := New(WithStatus(http.StatusBadRequest), WithUUID(FlagField))
+	second := New(WithStatus(http.StatusConflict), WithUUID(FlagField))
+	joined := errors.Join(fs.ErrNotExist, first, second)
+
+	p := New(Wrap(joined), WithUUID(FlagField))
+
+	assert.Equal(t, first.UUID, p.UUID, "expected UUID propagated from first Problem")
+	assert.ErrorIs(t, p, fs.ErrNotExist)
+	assert.ErrorIs(t, p, first)
+	assert.ErrorIs(t, p, second)
+	assert.Equal(t, []error{fs.ErrNotExist, first, second}, p.Unwrap())
+}
+
+func Test_New_JoinedError_ProblemSelector(t *testing.T) {
+	gen := &Generator{ProblemSelector: HighestStatusProblemSelector()}
+	first := gen.New(WithStatus(http.StatusBadRequest), WithUUID(FlagField))
+	second := gen.New(WithStatus(http.StatusConflict), WithUUID(FlagField))
+	joined := errors.Join(fs.ErrNotExist, first, second)
+
+	p := gen.New(Wrap(joined), WithUUID(FlagField))
+
+	assert.Equal(t, second.UUID, p.UUID, "expected UUID propagated from selected Problem")
+	assert.Equal(t, []*Problem{first}, p.Extensions[ExtensionSubProblems])
+	assert.ErrorIs(t, p, fs.ErrNotExist)
+	assert.ErrorIs(t, p, first)
+	assert.ErrorIs(t, p, second)
+}
+
+func Test_New_JoinedError_UnhashableError(t *testing.T) {
+	require.NotPanics(t, func() {
+		inner := New(Wrap(dataError{Data: []int{1}}))
+		assert.Equal(t, []error{dataError{Data: []int{1}}}, inner.Unwrap())
+
+		p := New(Wrap(inner))
+		assert.ErrorIs(t, p, inner)
+
+		p = New(Wrap(errors.Join(dataError{Data: []int{2}}, inner)))
+		assert.ErrorIs(t, p, inner)
+	})
+}
+
+func Test_Problem_Unwrap(t *testing.T) {
+	err := errors.New("test")
+
+	assert.Nil(t, (*Problem)(nil).Unwrap())
+	assert.Nil(t, New().Unwrap())
+	assert.Equal(t, []error{err}, New(Wrap(err)).Unwrap())
+	assert.Equal(t, []error{err}, New(Wrap(err)).Freeze().Unwrap())
+}
@@ -0,0 +1,66 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package problem
+
+import (
+	"net/http"
+	"strings"
+)
+
+const (
+	// ExtensionDocsURL is the key of the extension containing the URL of documentation describing a Problem, which is
+	// populated from Definition.DocsURL or Type.DocsURL.
+	//
+	// Unlike the type URI of a Problem, which identifies the type of problem, this URL is intended to be followed by
+	// clients to learn more about it.
+	ExtensionDocsURL = "docsUrl"
+	// ExtensionHelpURL is the key of the extension containing the URL of actionable remediation steps for a Problem,
+	// which is populated from Definition.HelpURL or Type.HelpURL.
+	//
+	// Unlike the type URI of a Problem, which identifies the type of problem, this URL is intended to be followed by
+	// clients to resolve it.
+	ExtensionHelpURL = "helpUrl"
+)
+
+const (
+	// linkHeader is the name of the HTTP header used to link to the documentation and remediation steps of a Problem.
+	linkHeader = "Link"
+	// linkRelDocs is the link relation type used to link to the documentation of a Problem.
+	linkRelDocs = "describedby"
+	// linkRelHelp is the link relation type used to link to the remediation steps of a Problem.
+	linkRelHelp = "help"
+)
+
+// addLinkHeader adds a Link header to the given http.Header containing links to the documentation (using the
+// "describedby" relation type) and remediation steps (using the "help" relation type) of the Problem provided, if it
+// has either. See WriteOptions.LinkHeader for more information.
+func addLinkHeader(header http.Header, prob *Problem) {
+	var links []string
+	if u := prob.HelpURL(); u != "" {
+		links = append(links, "<"+u+">; rel=\""+linkRelHelp+"\"")
+	}
+	if u := prob.DocsURL(); u != "" {
+		links = append(links, "<"+u+">; rel=\""+linkRelDocs+"\"")
+	}
+	if len(links) > 0 {
+		header.Add(linkHeader, strings.Join(links, ", "))
+	}
+}
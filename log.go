@@ -25,26 +25,69 @@ import (
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"log/slog"
+	"maps"
+	"time"
 )
 
 type (
 	// LogInfo contains information associated with a Problem that is only relevant for logging purposes.
 	LogInfo struct {
+		// Detail is the detail of the Problem that is only visible within logs (see Visibility.LogOnlyDetail).
+		Detail string
+		// Extensions contains any extensions of the Problem that are only visible within logs (see
+		// Visibility.LogOnlyExtensions).
+		Extensions Extensions
 		// Level is the LogLevel that has either been explicitly defined during construction or inherited from a Type or
 		// another Problem within an err's tree if unwrapped accordingly.
 		Level LogLevel
+		// Owner is the owner of the Problem (e.g. the team responsible for it), derived from Definition.Owner or
+		// inherited from another Problem within an err's tree if unwrapped accordingly.
+		Owner string
+		// RunbookURL is the URL of the runbook to be followed by whoever is triaging the Problem, derived from
+		// Definition.RunbookURL or inherited from another Problem within an err's tree if unwrapped accordingly.
+		RunbookURL string
+		// SLOClass is the classification of the Problem in terms of its impact on a service level objective (SLO) and
+		// its error budget.
+		//
+		// SLOClass is only populated if Generator.SLOClassifier is present.
+		SLOClass SLOClass
+		// SpanID is the hex-encoded identifier of the span (e.g. an OpenTelemetry span) that was active within the context
+		// used during construction or inherited from another Problem within an err's tree if unwrapped accordingly.
+		//
+		// SpanID is only populated if Generator.TraceContextFlag has FlagLog or either Builder.TraceContext or
+		// WithTraceContext were used and either passed no flags or FlagLog explicitly.
+		SpanID string
 		// Stack is a string representation of the stack trace captured during construction or inherited from another
 		// Problem within an err's tree if unwrapped accordingly.
 		//
 		// Stack is only populated if Generator.StackFlag has FlagLog or either Builder.Stack or WithStack were used and
 		// either passed no flags or FlagLog explicitly.
+		//
+		// If the stack trace was captured lazily (see Generator.StackCaptureMode), it is only formatted when first
+		// accessed via Problem.LogInfo or when the Problem is logged.
 		Stack string
+		// Timestamp is the time at which the Problem occurred, generated during construction or inherited from another
+		// Problem within an err's tree if unwrapped accordingly.
+		//
+		// Timestamp is only populated if Generator.TimestampFlag has FlagLog or either Builder.Timestamp or
+		// WithTimestamp were used and either passed no flags or FlagLog explicitly.
+		Timestamp time.Time
+		// TraceID is the hex-encoded identifier of the trace of the span (e.g. an OpenTelemetry span) that was active within
+		// the context used during construction or inherited from another Problem within an err's tree if unwrapped
+		// accordingly.
+		//
+		// TraceID is only populated if Generator.TraceContextFlag has FlagLog or either Builder.TraceContext or
+		// WithTraceContext were used and either passed no flags or FlagLog explicitly.
+		TraceID string
 		// UUID is the Universally Unique Identifier generated during construction or inherited from another Problem
 		// within an err's tree if unwrapped accordingly.
 		//
 		// UUID is only populated if Generator.UUIDFlag has FlagLog or either Builder.UUID or WithUUID were used and
 		// either passed no flags or FlagLog explicitly.
 		UUID string
+		// lazyStack is the stack trace captured during construction that is yet to be formatted, if any. See
+		// Generator.StackCaptureMode for more information.
+		lazyStack *lazyStack
 	}
 
 	// LogLeveler is a function that can be used by a Generator to override the LogLevel derived from a Type (i.e.
@@ -106,6 +149,7 @@ func (g *Generator) LogContext(ctx context.Context, msg string, prob *Problem, a
 		fn = DefaultLogger()
 	}
 	fn(ctx, prob.logLevel(), msg, args...)
+	g.afterLog(ctx, msg, prob)
 }
 
 // logLevel checks if Generator.LogLeveler is present and, if so, calls it with the given Type to allow for the LogLevel
@@ -138,6 +182,7 @@ func (p *Problem) LogInfo() LogInfo {
 	var info LogInfo
 	if p != nil {
 		info = p.logInfo
+		info.Stack = info.stack()
 	}
 	if info.Level == 0 {
 		info.Level = DefaultLogLevel
@@ -147,31 +192,49 @@ func (p *Problem) LogInfo() LogInfo {
 
 // LogValue returns a slog.GroupValue representation of the Problem containing attrs for only non-empty fields.
 func (p *Problem) LogValue() slog.Value {
-	attrs := make([]slog.Attr, 0, 10)
+	attrs := make([]slog.Attr, 0, 16)
 	if p.Code != "" {
 		attrs = append(attrs, slog.String("code", string(p.Code)))
 	}
-	if p.Detail != "" {
-		attrs = append(attrs, slog.String("detail", p.Detail))
+	if detail := firstNonZeroValue(p.Detail, p.logInfo.Detail); detail != "" {
+		attrs = append(attrs, slog.String("detail", detail))
 	}
 	if p.err != nil {
 		attrs = append(attrs, slog.Any("error", p.err))
 	}
-	if len(p.Extensions) > 0 {
-		attrs = append(attrs, mapLogGroup("extensions", p.Extensions))
+	if extensions := p.logExtensions(); len(extensions) > 0 {
+		attrs = append(attrs, mapLogGroup("extensions", extensions))
 	}
 	if p.Instance != "" {
 		attrs = append(attrs, slog.String("instance", p.Instance))
 	}
-	if p.logInfo.Stack != "" {
-		attrs = append(attrs, slog.String("stack", p.logInfo.Stack))
+	if p.logInfo.Owner != "" {
+		attrs = append(attrs, slog.String("owner", p.logInfo.Owner))
+	}
+	if p.logInfo.RunbookURL != "" {
+		attrs = append(attrs, slog.String("runbookUrl", p.logInfo.RunbookURL))
+	}
+	if p.logInfo.SLOClass != "" {
+		attrs = append(attrs, slog.String("sloClass", string(p.logInfo.SLOClass)))
+	}
+	if p.logInfo.SpanID != "" {
+		attrs = append(attrs, slog.String("spanId", p.logInfo.SpanID))
+	}
+	if s := p.logInfo.stack(); s != "" {
+		attrs = append(attrs, slog.String("stack", s))
 	}
 	if p.Status != 0 {
 		attrs = append(attrs, slog.Int("status", p.Status))
 	}
+	if !p.logInfo.Timestamp.IsZero() {
+		attrs = append(attrs, slog.Time("timestamp", p.logInfo.Timestamp))
+	}
 	if p.Title != "" {
 		attrs = append(attrs, slog.String("title", p.Title))
 	}
+	if p.logInfo.TraceID != "" {
+		attrs = append(attrs, slog.String("traceId", p.logInfo.TraceID))
+	}
 	if p.Type != "" {
 		attrs = append(attrs, slog.String("type", p.Type))
 	}
@@ -186,29 +249,47 @@ func (p *Problem) MarshalLogObject(enc zapcore.ObjectEncoder) error {
 	if p.Code != "" {
 		enc.AddString("code", string(p.Code))
 	}
-	if p.Detail != "" {
-		enc.AddString("detail", p.Detail)
+	if detail := firstNonZeroValue(p.Detail, p.logInfo.Detail); detail != "" {
+		enc.AddString("detail", detail)
 	}
 	if p.err != nil {
 		enc.AddString("error", p.err.Error())
 	}
-	if len(p.Extensions) > 0 {
-		if err := enc.AddReflected("extensions", p.Extensions); err != nil {
+	if extensions := p.logExtensions(); len(extensions) > 0 {
+		if err := enc.AddReflected("extensions", extensions); err != nil {
 			return err
 		}
 	}
 	if p.Instance != "" {
 		enc.AddString("instance", p.Instance)
 	}
-	if p.logInfo.Stack != "" {
-		enc.AddString("stack", p.logInfo.Stack)
+	if p.logInfo.Owner != "" {
+		enc.AddString("owner", p.logInfo.Owner)
+	}
+	if p.logInfo.RunbookURL != "" {
+		enc.AddString("runbookUrl", p.logInfo.RunbookURL)
+	}
+	if p.logInfo.SLOClass != "" {
+		enc.AddString("sloClass", string(p.logInfo.SLOClass))
+	}
+	if p.logInfo.SpanID != "" {
+		enc.AddString("spanId", p.logInfo.SpanID)
+	}
+	if s := p.logInfo.stack(); s != "" {
+		enc.AddString("stack", s)
 	}
 	if p.Status != 0 {
 		enc.AddInt("status", p.Status)
 	}
+	if !p.logInfo.Timestamp.IsZero() {
+		enc.AddTime("timestamp", p.logInfo.Timestamp)
+	}
 	if p.Title != "" {
 		enc.AddString("title", p.Title)
 	}
+	if p.logInfo.TraceID != "" {
+		enc.AddString("traceId", p.logInfo.TraceID)
+	}
 	if p.Type != "" {
 		enc.AddString("type", p.Type)
 	}
@@ -218,6 +299,17 @@ func (p *Problem) MarshalLogObject(enc zapcore.ObjectEncoder) error {
 	return nil
 }
 
+// logExtensions returns the extensions of the Problem to be logged, including any that are only visible within logs (see
+// Visibility.LogOnlyExtensions), sanitized using the ExtensionSanitizer of the Generator that built it, if any.
+func (p *Problem) logExtensions() Extensions {
+	if len(p.logInfo.Extensions) == 0 {
+		return sanitizeExtensions(p.Extensions, p.sanitizer)
+	}
+	extensions := maps.Clone(p.logInfo.Extensions)
+	maps.Copy(extensions, p.Extensions)
+	return sanitizeExtensions(extensions, p.sanitizer)
+}
+
 // logLevel returns the LogLevel recommend to be used to log the Problem.
 func (p *Problem) logLevel() LogLevel {
 	if p == nil || p.logInfo.Level == 0 {
@@ -0,0 +1,102 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package mappers provides ready-made problem.ErrorMapper functions for common errors from the standard library (e.g.
+// context.DeadlineExceeded) that can be used within problem.Generator.ErrorMappers or standalone using MapError.
+package mappers
+
+import (
+	"context"
+	"errors"
+	"github.com/neocotic/go-problem"
+	problemhttp "github.com/neocotic/go-problem/http"
+	"io"
+	"net"
+)
+
+// Context returns a problem.ErrorMapper that maps context.DeadlineExceeded to problemhttp.GatewayTimeoutDefinition and
+// context.Canceled to problem.ClientClosedRequestDefinition.
+func Context() problem.ErrorMapper {
+	return func(err error) (problem.Definition, bool) {
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			return problemhttp.GatewayTimeoutDefinition, true
+		case errors.Is(err, context.Canceled):
+			return problem.ClientClosedRequestDefinition, true
+		default:
+			return problem.Definition{}, false
+		}
+	}
+}
+
+// Defaults returns all ready-made problem.ErrorMapper functions, in the order in which they are consulted by MapError.
+// A new slice is returned on each call so that it can be safely appended to.
+//
+// For example;
+//
+//	g := &problem.Generator{ErrorMappers: append(mappers.Defaults(), customMapper)}
+func Defaults() []problem.ErrorMapper {
+	return []problem.ErrorMapper{Context(), Net(), EOF()}
+}
+
+// EOF returns a problem.ErrorMapper that maps io.EOF and io.ErrUnexpectedEOF to problemhttp.BadRequestDefinition.
+//
+// It is intended for errors returned when reading and/or decoding the body of an HTTP request (e.g. json.Decoder
+// returns io.EOF for an empty body), where it indicates a malformed request. As such, it should not be used where such
+// errors could originate elsewhere (e.g. when reading the body of an HTTP response from an upstream service).
+func EOF() problem.ErrorMapper {
+	return func(err error) (problem.Definition, bool) {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return problemhttp.BadRequestDefinition, true
+		}
+		return problem.Definition{}, false
+	}
+}
+
+// MapError returns the problem.Definition to which the given error is mapped by the first of Defaults to do so, if any.
+//
+// For example;
+//
+//	if def, ok := mappers.MapError(err); ok {
+//		return def.New(problem.Wrap(err))
+//	}
+func MapError(err error) (problem.Definition, bool) {
+	if err == nil {
+		return problem.Definition{}, false
+	}
+	for _, mapper := range Defaults() {
+		if def, ok := mapper(err); ok {
+			return def, true
+		}
+	}
+	return problem.Definition{}, false
+}
+
+// Net returns a problem.ErrorMapper that maps a net.Error that is a timeout (e.g. dialing or reading from an upstream
+// service) to problemhttp.GatewayTimeoutDefinition.
+func Net() problem.ErrorMapper {
+	return func(err error) (problem.Definition, bool) {
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return problemhttp.GatewayTimeoutDefinition, true
+		}
+		return problem.Definition{}, false
+	}
+}
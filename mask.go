@@ -0,0 +1,161 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package problem
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+)
+
+// ExtensionPredicate is a function used to decide whether an extension is to be included when a Problem is written as
+// an HTTP response, which is evaluated at that time rather than when the Problem is built. See
+// Generator.ExtensionPredicates for more information.
+//
+// ctx is the context.Context of the HTTP request for which the Problem is being written.
+type ExtensionPredicate func(ctx context.Context, prob *Problem) bool
+
+// FieldMask controls which fields of a Problem, including the keys of its extensions, are included when the Problem
+// is written as an HTTP response (e.g. via WriteOptions.FieldMask). This allows a single Problem to serve different
+// audiences (e.g. a public API and an internal admin UI) without it being built again.
+//
+// Fields are identified by their serialized names (e.g. "code", "detail", "instance", "spanId", "stack", "timestamp",
+// "traceId", and "uuid") while extensions are identified by their keys. The "status", "title", and "type" fields are
+// always included as they are fundamental to the representation of a Problem.
+//
+// For example;
+//
+//	public := FieldMask{Exclude: []string{"stack", "uuid", ExtensionDebugError}}
+//	minimal := FieldMask{Include: []string{"code", "detail"}}
+type FieldMask struct {
+	// Exclude contains the names of fields and/or the keys of extensions that are to be excluded. Exclude takes
+	// precedence over Include.
+	//
+	// If empty, nothing is excluded other than anything not within Include.
+	Exclude []string
+	// Include contains the names of fields and/or the keys of extensions that are to be included. Everything else is
+	// excluded.
+	//
+	// If empty, everything is included other than anything within Exclude.
+	Include []string
+}
+
+// IsZero returns whether the FieldMask contains no fields or extension keys and so has no effect.
+func (fm FieldMask) IsZero() bool {
+	return len(fm.Exclude) == 0 && len(fm.Include) == 0
+}
+
+// RegisterExtensionPredicate registers the given ExtensionPredicate within Generator.ExtensionPredicates to decide
+// whether the extension with the key provided is to be included when a Problem is written as an HTTP response. See
+// Generator.ExtensionPredicates for more information.
+//
+// RegisterExtensionPredicate is not safe for concurrent use and so is intended to be called while the Generator is
+// being configured (e.g. at startup) before it is used.
+//
+// Panics if key is empty or pred is nil.
+func (g *Generator) RegisterExtensionPredicate(key string, pred func(ctx context.Context, prob *Problem) bool) {
+	if key == "" {
+		panic(errors.New("extension key is empty"))
+	}
+	if pred == nil {
+		panic(errors.New("extension predicate is nil"))
+	}
+	if g.ExtensionPredicates == nil {
+		g.ExtensionPredicates = make(map[string]ExtensionPredicate)
+	}
+	g.ExtensionPredicates[key] = pred
+}
+
+// apply returns a shallow clone of the given Problem with any fields and extensions excluded by the FieldMask removed.
+// If the FieldMask has no effect or prob is nil, prob is returned as-is.
+func (fm FieldMask) apply(prob *Problem) *Problem {
+	if fm.IsZero() || prob == nil {
+		return prob
+	}
+	clone := prob.clone()
+	if !fm.includes("code") {
+		clone.Code = ""
+	}
+	if !fm.includes("detail") {
+		clone.Detail = ""
+	}
+	if !fm.includes("instance") {
+		clone.Instance = ""
+	}
+	if !fm.includes("spanId") {
+		clone.SpanID = ""
+	}
+	if !fm.includes("stack") {
+		clone.Stack = ""
+	}
+	if !fm.includes("timestamp") {
+		clone.Timestamp = time.Time{}
+	}
+	if !fm.includes("traceId") {
+		clone.TraceID = ""
+	}
+	if !fm.includes("uuid") {
+		clone.UUID = ""
+	}
+	for k := range clone.Extensions {
+		if !fm.includes(k) {
+			delete(clone.Extensions, k)
+		}
+	}
+	if len(clone.Extensions) == 0 {
+		clone.Extensions = nil
+	}
+	return clone
+}
+
+// filterExtensions returns a shallow clone of the given Problem without any extensions whose ExtensionPredicate within
+// Generator.ExtensionPredicates returns false. If no extensions are excluded, prob is returned as-is.
+func (g *Generator) filterExtensions(ctx context.Context, prob *Problem) *Problem {
+	if len(g.ExtensionPredicates) == 0 || prob == nil {
+		return prob
+	}
+	var excluded []string
+	for k := range prob.Extensions {
+		if pred := g.ExtensionPredicates[k]; pred != nil && !pred(ctx, prob) {
+			excluded = append(excluded, k)
+		}
+	}
+	if len(excluded) == 0 {
+		return prob
+	}
+	clone := prob.clone()
+	for _, k := range excluded {
+		delete(clone.Extensions, k)
+	}
+	if len(clone.Extensions) == 0 {
+		clone.Extensions = nil
+	}
+	return clone
+}
+
+// includes returns whether the field or extension with the given name is included by the FieldMask.
+func (fm FieldMask) includes(name string) bool {
+	if slices.Contains(fm.Exclude, name) {
+		return false
+	}
+	return len(fm.Include) == 0 || slices.Contains(fm.Include, name)
+}
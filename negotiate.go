@@ -0,0 +1,132 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package problem
+
+import (
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// acceptRange contains a media range parsed from an Accept header along with its quality value.
+type acceptRange struct {
+	// mediaType is the media range (e.g. "application/problem+json", "application/*", or "*/*").
+	mediaType string
+	// q is the quality value of the media range, between zero and one.
+	q float64
+}
+
+// negotiateContentType returns the most suitable content/media type supported by the Generator based on the Accept
+// header of the given HTTP request, falling back to the content/media type provided if the header is missing or none
+// of the supported content/media types are acceptable.
+//
+// Where multiple content/media types are equally acceptable, the fallback is preferred, followed by the built-in
+// content/media types in JSON and then XML format, followed by those within Generator.ContentTypes and then
+// Generator.Encoders, each in lexicographical order.
+func (g *Generator) negotiateContentType(req *http.Request, fallback string) string {
+	ranges := parseAccept(req.Header.Values("Accept"))
+	if len(ranges) == 0 {
+		return fallback
+	}
+	candidates := []string{fallback, ContentTypeJSONUTF8, ContentTypeXMLUTF8}
+	candidates = append(candidates, sortedKeys(g.ContentTypes)...)
+	candidates = append(candidates, sortedKeys(g.Encoders)...)
+	var best string
+	var bestQ float64
+	for _, ct := range candidates {
+		if !g.isValidContentType(ct) {
+			continue
+		}
+		if q := acceptQuality(ranges, ct); q > bestQ {
+			best, bestQ = ct, q
+		}
+	}
+	if best == "" {
+		return fallback
+	}
+	return best
+}
+
+// acceptQuality returns the quality value of the most specific media range within ranges that matches the given
+// content/media type, ignoring any of its parameters, or zero if none match.
+func acceptQuality(ranges []acceptRange, ct string) float64 {
+	mediaType, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return 0
+	}
+	typ, _, _ := strings.Cut(mediaType, "/")
+	var q float64
+	specificity := -1
+	for _, r := range ranges {
+		var s int
+		switch r.mediaType {
+		case mediaType:
+			s = 2
+		case typ + "/*":
+			s = 1
+		case "*/*":
+			s = 0
+		default:
+			continue
+		}
+		if s > specificity {
+			q, specificity = r.q, s
+		}
+	}
+	return q
+}
+
+// parseAccept returns each media range parsed from the given Accept header values along with its quality value. Any
+// malformed media range is ignored.
+func parseAccept(values []string) []acceptRange {
+	var ranges []acceptRange
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			if strings.TrimSpace(part) == "" {
+				continue
+			}
+			mediaType, params, err := mime.ParseMediaType(part)
+			if err != nil {
+				continue
+			}
+			q := 1.0
+			if v, found := params["q"]; found {
+				if q, err = strconv.ParseFloat(v, 64); err != nil || q < 0 || q > 1 {
+					continue
+				}
+			}
+			ranges = append(ranges, acceptRange{mediaType: mediaType, q: q})
+		}
+	}
+	return ranges
+}
+
+// sortedKeys returns the keys of the given map in lexicographical order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
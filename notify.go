@@ -0,0 +1,221 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package problem
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+type (
+	// Notifier is a function used by a Generator to notify an external system (e.g. webhook, pager) of a Problem.
+	//
+	// A Notifier is always invoked asynchronously and is never passed a nil pointer to a Problem. The context passed
+	// is never canceled, even if the context from which the notification originated is, however, it will contain all
+	// of its values. As such, a Notifier should apply its own timeout (e.g. WebhookOptions.Timeout).
+	//
+	// Any error returned is logged via Generator.LogContext along with the Problem.
+	Notifier func(ctx context.Context, prob *Problem) error
+
+	// NotifierRoute routes a Problem to a Notifier based on whether it matches all the matchers provided.
+	NotifierRoute struct {
+		// Matchers contains each Matcher that a Problem must match in order for it to be passed to Notifier.
+		//
+		// If empty, all problems are passed to Notifier.
+		Matchers []Matcher
+		// Notifier is the Notifier to be notified of any Problem matching Matchers.
+		//
+		// If nil, the NotifierRoute is ignored.
+		Notifier Notifier
+	}
+
+	// WebhookOptions contains options that can be used when posting a Problem to a webhook.
+	//
+	// All fields are optional with default behaviour clearly documented.
+	WebhookOptions struct {
+		// Client is the http.Client used to post the Problem to the webhook.
+		//
+		// If nil, http.DefaultClient will be used.
+		Client *http.Client
+		// Header contains any additional headers to be sent when posting the Problem to the webhook (e.g.
+		// Authorization).
+		//
+		// If empty, only the Content-Type header will be sent.
+		Header http.Header
+		// Payload is a function that returns the value to be encoded as JSON and posted to the webhook for the given
+		// Problem.
+		//
+		// If nil, the Problem itself will be posted.
+		Payload func(prob *Problem) any
+		// Timeout is the maximum amount of time permitted to post the Problem to the webhook, including reading the
+		// response.
+		//
+		// If zero or less, DefaultWebhookTimeout will be used.
+		Timeout time.Duration
+	}
+)
+
+const (
+	// DefaultWebhookTimeout is the default maximum amount of time permitted to post a Problem to a webhook. See
+	// WebhookOptions.Timeout for more information.
+	DefaultWebhookTimeout = 10 * time.Second
+	// MaxConcurrentNotifications is the maximum number of notifications that may be in flight at any time, across all
+	// generators. Any notification beyond this is dropped rather than queued, so that a burst of problems (e.g. during
+	// an outage) cannot exhaust resources while waiting on slow notifiers.
+	MaxConcurrentNotifications = 100
+)
+
+const (
+	// contentTypeJSONUTF8 is the content/media type used to post JSON data to webhooks.
+	contentTypeJSONUTF8 = "application/json; charset=utf-8"
+	// defaultNotifyDroppedLogMessage is the log message used when a notification is dropped.
+	defaultNotifyDroppedLogMessage = "A problem notification has been dropped"
+	// defaultNotifyErrorLogMessage is the log message used when a Notifier returns an error.
+	defaultNotifyErrorLogMessage = "A problem notification has failed"
+	// maxWebhookDrainSize is the maximum number of bytes read from the body of a webhook response before it is closed,
+	// allowing the connection to be reused without reading an excessively large (or even endless) body.
+	maxWebhookDrainSize = 64 << 10
+)
+
+// notifySemaphore limits the number of notifications that may be in flight at any time to MaxConcurrentNotifications.
+var notifySemaphore = make(chan struct{}, MaxConcurrentNotifications)
+
+// Notify notifies each Notifier within Generator.Notifiers whose NotifierRoute matches the given Problem.
+//
+// Each Notifier is invoked asynchronously so Notify never blocks. However, if MaxConcurrentNotifications are already in
+// flight, the notification is dropped instead. Any error returned by a Notifier, or dropped notification, is logged via
+// Generator.LogContext.
+//
+// Notify is called automatically whenever a Problem is written to an HTTP response by the Generator.
+func (g *Generator) Notify(ctx context.Context, prob *Problem) {
+	if prob == nil || len(g.Notifiers) == 0 {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	for _, route := range g.Notifiers {
+		if route.Notifier == nil || !Match(prob, route.Matchers...) {
+			continue
+		}
+		g.notify(ctx, route.Notifier, prob)
+	}
+}
+
+// Notify is a convenient shorthand for calling Generator.Notify on the Generator within the given context.Context, if
+// any, otherwise DefaultGenerator.
+func Notify(ctx context.Context, prob *Problem) {
+	GetGenerator(ctx).Notify(ctx, prob)
+}
+
+// SlackNotifier returns a Notifier that posts a basic Slack message containing the string representation of a Problem
+// to the given Slack incoming webhook URL, optionally using WebhookOptions for more granular control.
+//
+// WebhookOptions.Payload is ignored if provided.
+func SlackNotifier(url string, opts ...WebhookOptions) Notifier {
+	var _opts WebhookOptions
+	if len(opts) > 0 {
+		_opts = opts[0]
+	}
+	_opts.Payload = func(prob *Problem) any {
+		return map[string]string{"text": prob.String()}
+	}
+	return WebhookNotifier(url, _opts)
+}
+
+// WebhookNotifier returns a Notifier that posts a Problem as JSON to the given webhook URL, optionally using
+// WebhookOptions for more granular control.
+//
+// An error is returned by the Notifier if the Problem could not be posted within WebhookOptions.Timeout or if the
+// webhook responds with a non-2xx status code.
+func WebhookNotifier(url string, opts ...WebhookOptions) Notifier {
+	var _opts WebhookOptions
+	if len(opts) > 0 {
+		_opts = opts[0]
+	}
+	client := _opts.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	timeout := _opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultWebhookTimeout
+	}
+	return func(ctx context.Context, prob *Problem) error {
+		var payload any = prob
+		if fn := _opts.Payload; fn != nil {
+			payload = fn(prob)
+		}
+		body, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		for k, v := range _opts.Header {
+			req.Header[k] = v
+		}
+		req.Header.Set(contentTypeHeader, contentTypeJSONUTF8)
+		res, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer res.Body.Close()
+		_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, maxWebhookDrainSize))
+		if res.StatusCode < 200 || res.StatusCode > 299 {
+			return fmt.Errorf("webhook responded with unexpected status: %d", res.StatusCode)
+		}
+		return nil
+	}
+}
+
+// notify invokes the given Notifier asynchronously with the Problem provided, logging any error returned via
+// Generator.LogContext.
+//
+// If MaxConcurrentNotifications are already in flight, the Notifier is not invoked and the dropped notification is
+// logged instead.
+//
+// If the Problem was acquired from a pool, the Notifier is passed a clone instead as the Problem may be released before
+// the Notifier is invoked. See Problem.Release for more information.
+func (g *Generator) notify(ctx context.Context, notifier Notifier, prob *Problem) {
+	select {
+	case notifySemaphore <- struct{}{}:
+	default:
+		g.LogContext(ctx, defaultNotifyDroppedLogMessage, prob)
+		return
+	}
+	if prob.pooled {
+		prob = prob.clone()
+	}
+	go func() {
+		defer func() { <-notifySemaphore }()
+		if err := notifier(ctx, prob); err != nil {
+			g.LogContext(ctx, defaultNotifyErrorLogMessage, prob, "error", err)
+		}
+	}()
+}
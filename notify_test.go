@@ -0,0 +1,81 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package problem
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_Generator_Notify_MaxConcurrentNotifications(t *testing.T) {
+	var (
+		invoked atomic.Int32
+		release = make(chan struct{})
+		wg      sync.WaitGroup
+	)
+	wg.Add(MaxConcurrentNotifications)
+	gen := &Generator{Notifiers: []NotifierRoute{{
+		Notifier: func(_ context.Context, _ *Problem) error {
+			invoked.Add(1)
+			wg.Done()
+			<-release
+			return nil
+		},
+	}}}
+	prob := gen.New()
+
+	for i := 0; i <= MaxConcurrentNotifications; i++ {
+		gen.Notify(context.Background(), prob)
+	}
+	wg.Wait()
+	close(release)
+
+	assert.Equal(t, int32(MaxConcurrentNotifications), invoked.Load())
+	assert.Eventually(t, func() bool {
+		return len(notifySemaphore) == 0
+	}, time.Second, time.Millisecond)
+}
+
+func Test_WebhookNotifier_Timeout(t *testing.T) {
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+		select {
+		case <-req.Context().Done():
+		case <-done:
+		}
+	}))
+	defer srv.Close()
+	defer close(done)
+	notifier := WebhookNotifier(srv.URL, WebhookOptions{Timeout: 10 * time.Millisecond})
+
+	err := notifier(context.Background(), New())
+
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "expected deadline exceeded, got %v", err)
+}
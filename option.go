@@ -20,6 +20,8 @@
 
 package problem
 
+import "time"
+
 // Option is used to customize the generation of a Problem and/or to override fields derived from a Definition and/or
 // Type.
 //
@@ -57,6 +59,15 @@ func WithCode(code Code) Option {
 	}
 }
 
+// WithDeadlineExtensions customizes a Generator to include extensions describing the deadline of the context.Context
+// used to generate a Problem, but only if it wraps context.DeadlineExceeded. See Builder.DeadlineExtensions for more
+// information.
+func WithDeadlineExtensions(start time.Time) Option {
+	return func(b *Builder) {
+		b.DeadlineExtensions(start)
+	}
+}
+
 // WithDetail customizes a Generator to return a Problem with the given detail. See Problem.Detail for more information.
 //
 // If detail is not empty, it will take precedence over anything provided using FromDefinition or any of the Wrap
@@ -131,6 +142,14 @@ func WithExtensions(extensions Extensions) Option {
 	}
 }
 
+// WithFieldErrors customizes a Generator to return a Problem with the given field errors appended to the
+// ExtensionFieldErrors extension. See Builder.FieldErrors for more information.
+func WithFieldErrors(errs ...FieldError) Option {
+	return func(b *Builder) {
+		b.FieldErrors(errs...)
+	}
+}
+
 // WithInstance customizes a Generator to return a Problem with the given instance URI reference. See Problem.Instance
 // for more information.
 //
@@ -166,6 +185,26 @@ func WithLogLevel(level LogLevel) Option {
 	}
 }
 
+// WithRateLimit customizes a Generator to return a Problem describing the rate limit that was applied to the request
+// that resulted in it. See Builder.RateLimit for more information.
+//
+// For example;
+//
+//	New(FromDefinition(problemhttp.TooManyRequestsDefinition), WithRateLimit(100, 0, 30*time.Second))
+func WithRateLimit(limit, remaining int, reset time.Duration) Option {
+	return func(b *Builder) {
+		b.RateLimit(limit, remaining, reset)
+	}
+}
+
+// WithRetryAfter customizes a Generator to return a Problem with a hint for how long a client should wait before
+// retrying a request that resulted in it. See Builder.RetryAfter for more information.
+func WithRetryAfter(d time.Duration) Option {
+	return func(b *Builder) {
+		b.RetryAfter(d)
+	}
+}
+
 // WithStack customizes a Generator to control if/how a captured stack trace is visible on a Problem. See Problem.Stack
 // for more information.
 //
@@ -203,6 +242,23 @@ func WithStatus(status int) Option {
 	}
 }
 
+// WithTimestamp customizes a Generator to control if/how a generated timestamp is visible on a Problem. See
+// Problem.Timestamp for more information.
+//
+// By default, Generator.TimestampFlag is used to control visibility of a timestamp.
+//
+// If no flags are provided, this is considered equal to passing FlagField and FlagLog. If FlagDisable is given, all
+// other flags are ignored. No timestamp is generated if FlagDisable is provided.
+//
+// If a timestamp needs to be generated and any of the Wrap options are used and a Problem is unwrapped that already
+// has a timestamp, its timestamp will be used instead of generating a timestamp to ensure that the time at which the
+// problem originally occurred is retained.
+func WithTimestamp(flags ...Flag) Option {
+	return func(b *Builder) {
+		b.Timestamp(flags...)
+	}
+}
+
 // WithTitle customizes a Generator to return a Problem with the given title. See Problem.Title for more information.
 //
 // If title is not empty, it will take precedence over anything provided using FromDefinition, FromType, or any of the
@@ -244,6 +300,24 @@ func WithTitleKeyOrElse(key any, title string) Option {
 	}
 }
 
+// WithTraceContext customizes a Generator to control if/how the trace context (i.e. the trace and span IDs) of the span
+// that is active within the context passed to NewContext, as read by Generator.TraceContextReader, is visible on a
+// Problem. See Problem.TraceID and Problem.SpanID for more information.
+//
+// By default, Generator.TraceContextFlag is used to control visibility of the trace context.
+//
+// If no flags are provided, this is considered equal to passing FlagField and FlagLog. If FlagDisable is given, all
+// other flags are ignored. The trace context is not read if FlagDisable is provided.
+//
+// If any of the Wrap options are used and a Problem is unwrapped that already has a trace context, its trace context
+// will be used instead of that of the active span to ensure that the span in which the problem originally occurred is
+// retained.
+func WithTraceContext(flags ...Flag) Option {
+	return func(b *Builder) {
+		b.TraceContext(flags...)
+	}
+}
+
 // WithType customizes a Generator to return a Problem with the given type URI reference. See Problem.Type for more
 // information.
 //
@@ -295,9 +369,19 @@ func WithUUID(flags ...Flag) Option {
 // and/or FromType.
 //
 // If no Unwrapper is provided, Generator.Unwrapper is used from Builder.Generator if not nil, otherwise from
-// DefaultGenerator. If an Unwrapper could still not be resolved, it defaults to PropagatedFieldUnwrapper.
+// DefaultGenerator. If an Unwrapper could still not be resolved, it defaults to PropagatedFieldUnwrapper. Any extensions
+// within Generator.PropagatedExtensions are also preserved from a wrapped Problem, and any joined error is handled using
+// Generator.ProblemSelector. See Builder.Wrap for more information.
 func Wrap(err error, unwrapper ...Unwrapper) Option {
 	return func(b *Builder) {
 		b.Wrap(err, unwrapper...)
 	}
 }
+
+// WrapValue customizes a Generator to return a Problem wrapping the given value, which is useful when the value is not
+// guaranteed to be an error (e.g. a value recovered from a panic). See Builder.WrapValue for more information.
+func WrapValue(v any, unwrapper ...Unwrapper) Option {
+	return func(b *Builder) {
+		b.WrapValue(v, unwrapper...)
+	}
+}
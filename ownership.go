@@ -0,0 +1,49 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package problem
+
+const (
+	// ExtensionOwner is the key of the extension containing the owner of a Problem (e.g. the team responsible for it),
+	// which is populated from Definition.Owner, but only when written as an HTTP response for a Profile with
+	// Profile.Ownership enabled.
+	ExtensionOwner = "owner"
+	// ExtensionRunbookURL is the key of the extension containing the URL of the runbook to be followed by whoever is
+	// triaging a Problem, which is populated from Definition.RunbookURL, but only when written as an HTTP response for
+	// a Profile with Profile.Ownership enabled.
+	ExtensionRunbookURL = "runbookUrl"
+)
+
+// ownershipProblem returns a shallow clone of the given Problem that also contains its owner and runbook URL within its
+// extensions (see ExtensionOwner and ExtensionRunbookURL), which are ordinarily only visible within logs, but only if
+// prob has either. Otherwise, prob is returned as-is. Nothing that is already present on prob is replaced.
+func ownershipProblem(prob *Problem) *Problem {
+	if prob == nil || (prob.logInfo.Owner == "" && prob.logInfo.RunbookURL == "") {
+		return prob
+	}
+	clone := prob.clone()
+	if prob.logInfo.Owner != "" {
+		clone.Extensions = putExtensionIfAbsent(clone.Extensions, ExtensionOwner, prob.logInfo.Owner)
+	}
+	if prob.logInfo.RunbookURL != "" {
+		clone.Extensions = putExtensionIfAbsent(clone.Extensions, ExtensionRunbookURL, prob.logInfo.RunbookURL)
+	}
+	return clone
+}
@@ -0,0 +1,350 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package problem
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// DefaultMaxParseSize is the default maximum number of bytes read when parsing a Problem. See ParseOptions.MaxSize for
+// more information.
+const DefaultMaxParseSize = 1 << 20
+
+// ErrNotProblem is returned when parsing content whose content/media type does not represent a Problem (i.e. it is
+// neither ContentTypeJSON nor ContentTypeXML, regardless of any parameters).
+var ErrNotProblem = errors.New("content is not a problem")
+
+// ParseOptions contains options that can be used to customize the behaviour of ParseReader and ParseResponse.
+//
+// All fields are optional with default behaviour clearly documented.
+type ParseOptions struct {
+	// MaxSize is the maximum number of bytes that may be read when parsing a Problem, with an error being returned if
+	// the content exceeds it. This protects against excessively large (or even malicious) content from an untrusted
+	// source (e.g. an upstream service).
+	//
+	// If zero or less, DefaultMaxParseSize is used.
+	MaxSize int64
+}
+
+// FromMap returns a Problem constructed from the given map, where the values of any reserved members (i.e. "code",
+// "detail", "instance", "spanId", "stack", "status", "timestamp", "title", "traceId", "type", and "uuid") are assigned
+// to the corresponding fields of the Problem and all remaining entries are contained within Problem.Extensions. This is
+// intended for layers that produce problems dynamically (e.g. plugins or rules engines written in Lua or JavaScript).
+//
+// Any member other than "status" and "timestamp" must be a string (or Code for "code"). "status" may be any integer,
+// a float64 without a fractional part (e.g. as decoded from JSON), a json.Number, or a string containing an integer.
+// "timestamp" may be a time.Time or a string formatted using time.RFC3339. If present, "extensions" must be a
+// map[string]any whose entries are also contained within Problem.Extensions, however, any top-level entries with the
+// same key take precedence.
+//
+// Since the Problem is constructed rather than generated, it is not logged and contains no stack trace, timestamp, or
+// UUID other than those within m.
+//
+// An error is returned if any reserved member has a value of an unsupported type, any extension has an empty key, or
+// any entry within "extensions" has a reserved key (i.e. conflicts with Problem-level fields).
+//
+// For example;
+//
+//	prob, err := FromMap(map[string]any{"status": 409, "title": "Conflict", "resource": "user"})
+func FromMap(m map[string]any) (*Problem, error) {
+	var prob Problem
+	var extensions Extensions
+	if v, ok := m["extensions"]; ok && v != nil {
+		nested, isMap := v.(map[string]any)
+		if !isMap {
+			return nil, fmt.Errorf("unsupported type for problem member %q: %T", "extensions", v)
+		}
+		extensions = make(Extensions, len(nested)+len(m))
+		for k, ev := range nested {
+			if err := validationExtensionKey(k); err != nil {
+				return nil, err
+			}
+			extensions[k] = ev
+		}
+	}
+	for k, v := range m {
+		var err error
+		switch k {
+		case "code":
+			var code string
+			if code, err = mapString(k, v); err == nil {
+				prob.Code = Code(code)
+			}
+		case "detail":
+			prob.Detail, err = mapString(k, v)
+		case "extensions":
+			continue
+		case "instance":
+			prob.Instance, err = mapString(k, v)
+		case "spanId":
+			prob.SpanID, err = mapString(k, v)
+		case "stack":
+			prob.Stack, err = mapString(k, v)
+		case "status":
+			prob.Status, err = mapStatus(v)
+		case "timestamp":
+			prob.Timestamp, err = mapTimestamp(v)
+		case "title":
+			prob.Title, err = mapString(k, v)
+		case "traceId":
+			prob.TraceID, err = mapString(k, v)
+		case "type":
+			prob.Type, err = mapString(k, v)
+		case "uuid":
+			prob.UUID, err = mapString(k, v)
+		default:
+			if k == "" {
+				return nil, errExtensionKeyEmpty
+			}
+			if extensions == nil {
+				extensions = make(Extensions, len(m))
+			}
+			extensions[k] = v
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(extensions) > 0 {
+		prob.Extensions = extensions
+	}
+	return &prob, nil
+}
+
+// ParseReader decodes a Problem from the given io.Reader based on the content/media type provided, which must be
+// either ContentTypeJSON or ContentTypeXML (regardless of any parameters), optionally using ParseOptions for more
+// granular control.
+//
+// Any unknown members of a Problem are contained within Problem.Extensions (see Problem.UnmarshalJSON and
+// Problem.UnmarshalXML). Since the Problem is decoded rather than generated, it is not logged and contains no stack
+// trace or UUID other than those within the content.
+//
+// ErrNotProblem is returned if contentType does not represent a Problem. An error is also returned if the content
+// exceeds ParseOptions.MaxSize or cannot be read or decoded.
+//
+// For example;
+//
+//	prob, err := ParseReader(msg.Body, msg.Header.Get("Content-Type"))
+//	if err != nil {
+//		return err
+//	}
+func ParseReader(r io.Reader, contentType string, opts ...ParseOptions) (*Problem, error) {
+	format, err := parseFormat(contentType)
+	if err != nil {
+		return nil, err
+	}
+	data, err := readProblem(r, opts)
+	if err != nil {
+		return nil, err
+	}
+	return decodeProblem(data, format)
+}
+
+// ParseResponse decodes a Problem from the body of the given http.Response based on its Content-Type header,
+// optionally using ParseOptions for more granular control. This is typically used by gateways that need to consume
+// problems from upstream services so that they can be wrapped (e.g. using Wrap) and propagated.
+//
+// When the http.Response contains a Problem, its body is replaced so that it can still be read by the caller. If the
+// decoded Problem has no status, the status code of the http.Response is used.
+//
+// ErrNotProblem is returned, and the body is left untouched, if the http.Response does not contain a Problem. An error
+// is also returned if the body exceeds ParseOptions.MaxSize or cannot be read or decoded. See ParseReader for more
+// information.
+//
+// For example;
+//
+//	res, err := client.Do(req)
+//	if err != nil {
+//		return err
+//	}
+//	defer res.Body.Close()
+//	if upstream, err := ParseResponse(res); err == nil {
+//		return New(WithStatus(http.StatusBadGateway), Wrap(upstream))
+//	}
+func ParseResponse(res *http.Response, opts ...ParseOptions) (*Problem, error) {
+	format, err := parseFormat(res.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, err
+	}
+	body := res.Body
+	data, err := readProblem(body, opts)
+	if err != nil {
+		// Restore what has been read so that the entire body can still be read by the caller
+		res.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(data), body), body}
+		return nil, err
+	}
+	_ = body.Close()
+	res.Body = io.NopCloser(bytes.NewReader(data))
+	prob, err := decodeProblem(data, format)
+	if err != nil {
+		return nil, err
+	}
+	if prob.Status == 0 {
+		prob.Status = res.StatusCode
+	}
+	return prob, nil
+}
+
+// decodeProblem decodes a Problem from the given data in the Format provided.
+func decodeProblem(data []byte, format Format) (*Problem, error) {
+	var prob Problem
+	var err error
+	if format == FormatJSON {
+		err = json.Unmarshal(data, &prob)
+	} else {
+		err = xml.Unmarshal(data, &prob)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode problem: %w", err)
+	}
+	return &prob, nil
+}
+
+// parseFormat returns the Format represented by the given content/media type, regardless of any parameters, or
+// ErrNotProblem if it does not represent a Problem.
+func parseFormat(contentType string) (Format, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrNotProblem, contentType)
+	}
+	switch mediaType {
+	case ContentTypeJSON:
+		return FormatJSON, nil
+	case ContentTypeXML:
+		return FormatXML, nil
+	default:
+		return 0, fmt.Errorf("%w: %q", ErrNotProblem, contentType)
+	}
+}
+
+// readProblem reads all data from the given io.Reader, returning an error if it exceeds the maximum size derived from
+// the first of the ParseOptions provided, if any, or DefaultMaxParseSize.
+func readProblem(r io.Reader, opts []ParseOptions) ([]byte, error) {
+	maxSize := int64(DefaultMaxParseSize)
+	if len(opts) > 0 && opts[0].MaxSize > 0 {
+		maxSize = opts[0].MaxSize
+	}
+	data, err := io.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return data, fmt.Errorf("failed to read problem: %w", err)
+	}
+	if int64(len(data)) > maxSize {
+		return data, fmt.Errorf("problem exceeds maximum size of %d bytes", maxSize)
+	}
+	return data, nil
+}
+
+// mapStatus returns the status represented by the given value of the "status" member of a map passed to FromMap.
+//
+// An error is returned if v is not an integer, a float64 without a fractional part, a json.Number, or a string
+// containing an integer.
+func mapStatus(v any) (int, error) {
+	switch n := v.(type) {
+	case nil:
+		return 0, nil
+	case int:
+		return n, nil
+	case int8:
+		return int(n), nil
+	case int16:
+		return int(n), nil
+	case int32:
+		return int(n), nil
+	case int64:
+		return int(n), nil
+	case uint:
+		return int(n), nil
+	case uint8:
+		return int(n), nil
+	case uint16:
+		return int(n), nil
+	case uint32:
+		return int(n), nil
+	case uint64:
+		return int(n), nil
+	case float32:
+		if f := float64(n); f == math.Trunc(f) {
+			return int(f), nil
+		}
+	case float64:
+		if n == math.Trunc(n) {
+			return int(n), nil
+		}
+	case json.Number:
+		if i, err := n.Int64(); err == nil {
+			return int(i), nil
+		}
+	case string:
+		if i, err := strconv.Atoi(n); err == nil {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("unsupported value for problem member %q: %v", "status", v)
+}
+
+// mapString returns the string value of the member with the given key of a map passed to FromMap.
+//
+// An error is returned if v is neither a string nor a Code.
+func mapString(key string, v any) (string, error) {
+	switch s := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return s, nil
+	case Code:
+		return string(s), nil
+	default:
+		return "", fmt.Errorf("unsupported type for problem member %q: %T", key, v)
+	}
+}
+
+// mapTimestamp returns the time represented by the given value of the "timestamp" member of a map passed to FromMap.
+//
+// An error is returned if v is neither a time.Time nor a string formatted using time.RFC3339.
+func mapTimestamp(v any) (time.Time, error) {
+	switch t := v.(type) {
+	case nil:
+		return time.Time{}, nil
+	case time.Time:
+		return t, nil
+	case string:
+		ts, err := time.Parse(time.RFC3339, t)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid value for problem member %q: %w", "timestamp", err)
+		}
+		return ts, nil
+	default:
+		return time.Time{}, fmt.Errorf("unsupported type for problem member %q: %T", "timestamp", v)
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package problem
+
+import "sync"
+
+// problemPool contains problems that have been released so that they can be reused when building a Problem while
+// Generator.PoolProblems is enabled.
+var problemPool = &sync.Pool{
+	New: func() any {
+		return new(Problem)
+	},
+}
+
+// Release returns the Problem to a pool so that it can be reused when building another Problem, but only if it was
+// acquired from the pool (see Generator.PoolProblems). Otherwise, Release does nothing.
+//
+// Release is intended for problems that are used solely for immediate serialization (e.g. written as an HTTP response
+// and then discarded) by services handling a very large volume of errors, where saving an allocation per Problem can
+// reduce GC pressure (see BenchmarkGenerator_WriteProblem_PoolProblems). The Problem is reset when released, so it MUST
+// NOT be used (or referenced by anything that is still in use, e.g. by wrapping it within an error) afterwards.
+//
+// Release MUST be called at most once for each Problem. Calling Release again is only harmless until the pool reuses the
+// same pointer for another Problem, after which it would release that Problem while still in use elsewhere. Calling
+// Release on a clone of a pooled Problem (e.g. one returned by Problem.Freeze) always does nothing.
+//
+// Since each Notifier is invoked asynchronously, including those within Generator.Notifiers and any passed to
+// NotifyAction, it is always passed a clone of a pooled Problem so that it is unaffected by Release. However, any hook
+// within Generator.Hooks is passed the pooled Problem itself and so MUST NOT retain it (e.g. by capturing it within a
+// goroutine) beyond its invocation.
+//
+// For example;
+//
+//	g := &Generator{PoolProblems: true}
+//	prob := g.New(FromDefinition(http.TooManyRequestsDefinition))
+//	err := g.WriteProblem(prob, w, req)
+//	// The Problem is no longer used once written, so it can be safely released
+//	prob.Release()
+//	return err
+func (p *Problem) Release() {
+	if p == nil || !p.pooled {
+		return
+	}
+	*p = Problem{}
+	problemPool.Put(p)
+}
+
+// newProblem returns a Problem acquired from a pool if Generator.PoolProblems is enabled, otherwise a new Problem.
+//
+// Either way, the returned Problem is empty, however, a Problem acquired from a pool is marked as such so that it can be
+// returned using Problem.Release.
+func (g *Generator) newProblem() *Problem {
+	if !g.PoolProblems {
+		return new(Problem)
+	}
+	prob := problemPool.Get().(*Problem)
+	prob.pooled = true
+	return prob
+}
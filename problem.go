@@ -26,8 +26,11 @@ import (
 	"encoding/xml"
 	"fmt"
 	"github.com/neocotic/go-optional"
+	"net/http"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // Extensions is a map that may contain additional information used extend the details of a Problem.
@@ -38,30 +41,29 @@ var (
 	_ xml.Unmarshaler = (Extensions)(nil)
 )
 
-// MarshalXML marshals the encoded entries within the map into XML.
+// MarshalXML marshals the encoded entries within the map into XML, sorted by key.
 //
 // This is required in order to allow extensions to be marshaled at the top-level of a Problem. Additionally,
-// xml.Unmarshaler does not support marshaling maps by default since XML documents are ordered by design. Unfortunately,
-// this function cannot guarantee ordering.
+// xml.Marshaler does not support marshaling maps by default since XML documents are ordered by design. Any value that
+// is a map[string]any is marshaled as an element containing an element for each of its entries, sorted by key, while
+// any value that is a []any (or []FieldError) is marshaled as an element containing an "i" element for each of its
+// items, as demonstrated by RFC 9457. This allows extensions unmarshaled by Problem.UnmarshalXML to be marshaled again.
 //
 // An error is returned if unable to marshal any of the entries or the map contains a key that is either empty or
 // reserved (i.e. conflicts with Problem-level fields).
 func (es Extensions) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
-	for k, v := range es {
-		err := validationExtensionKey(k)
-		if err != nil {
-			return err
-		}
-		err = e.EncodeElement(v, xml.StartElement{Name: xml.Name{Local: k}})
-		if err != nil {
-			return err
+	var err error
+	rangeExtensions(es, func(key string, value any) bool {
+		if err = validationExtensionKey(key); err == nil {
+			err = encodeXMLValue(e, key, value)
 		}
-	}
-	return nil
+		return err == nil
+	})
+	return err
 }
 
 // UnmarshalXML does nothing but is required to ensure that any <extensions> element is not unmarshaled as this cannot
-// be supported.
+// be supported. Instead, Problem.UnmarshalXML collects any unknown top-level elements into Problem.Extensions.
 func (es Extensions) UnmarshalXML(_ *xml.Decoder, _ xml.StartElement) error {
 	// Does nothing
 	return nil
@@ -96,11 +98,15 @@ type (
 		// Clients consuming problem details MUST ignore any such extensions that they don't recognize; this allows
 		// problem types to evolve and include additional information in the future.
 		//
-		// If/when the Problem is marshalled to JSON or XML any such extensions are serialized at the top level.
-		// However, such information is lost if an attempt is made to unmarshal the serialized data bac into XML due to
-		// limitations with xml.Unmarshaler. JSON data can be unmarshaled without any issues. If Extensions contains a
+		// If/when the Problem is marshalled to JSON or XML any such extensions are serialized at the top level and
+		// any unknown top-level members are unmarshaled back into Extensions. However, since XML carries no type
+		// information, extensions unmarshaled from XML only ever contain strings, maps, and slices (see
+		// Problem.UnmarshalXML). JSON data can be unmarshaled without any such issues. If Extensions contains a
 		// key that is empty or reserved (i.e. conflicts with Problem-level fields), an error will occur when attempting
 		// to marshal the Problem to JSON or XML.
+		//
+		// Problem.SetExtension, Problem.DeleteExtension, and Problem.RangeExtensions may be preferred over accessing
+		// Extensions directly as they never modify the map in place.
 		Extensions Extensions `json:"-" xml:"extensions,omitempty"`
 		// Instance is a URI reference that identifies the specific occurrence of the Problem.
 		//
@@ -108,6 +114,17 @@ type (
 		//
 		// It may be a relative URI; this means that it must be resolved relative to the document's base URI.
 		Instance string `json:"instance,omitempty" xml:"instance,omitempty"`
+		// SpanID is the hex-encoded identifier of the span (e.g. an OpenTelemetry span) that was active within the context
+		// used to build the Problem (e.g. via NewContext or BuildContext), as read by Generator.TraceContextReader.
+		//
+		// When present, it can be used along with TraceID to correlate an occurrence of the Problem with the distributed
+		// trace in which it occurred.
+		//
+		// SpanID is only populated if Generator.TraceContextFlag has FlagField or either Builder.TraceContext or
+		// WithTraceContext were used and either passed no flags or FlagField explicitly. If FlagField is not present but
+		// FlagLog is, the Problem will contain the span ID internally for logging within LogValue, however, SpanID will
+		// be empty. This can be useful for cases where trace correlation is desired for logging only.
+		SpanID string `json:"spanId,omitempty" xml:"spanId,omitempty"`
 		// Stack is a string representation of the stack trace captured when the Problem generated.
 		//
 		// When present, Stack can be used to help debug the problem, however, care should be taken as a stack trace
@@ -129,11 +146,32 @@ type (
 		// it has been changed (e.g. by an intermediary or cache), and when message bodies persist without HTTP
 		// information. Generic HTTP software will still use the HTTP status code.
 		Status int `json:"status" xml:"status"`
+		// Timestamp is the time at which the Problem occurred, as returned by Generator.Clock.
+		//
+		// When present, it can be used to correlate an occurrence of the Problem with logs and other telemetry.
+		//
+		// Timestamp is only populated if Generator.TimestampFlag has FlagField or either Builder.Timestamp or
+		// WithTimestamp were used and either passed no flags or FlagField explicitly. If FlagField is not present but
+		// FlagLog is, the Problem will contain a timestamp internally for logging within LogValue, however, Timestamp
+		// will be zero. This can be useful for cases where a timestamp is desired for logging only.
+		Timestamp time.Time `json:"timestamp,omitempty" xml:"-"`
 		// Title is a short, human-readable summary of the type of the Problem.
 		//
 		// It SHOULD NOT change from occurrence to occurrence of the problem, except for purposes of localization (e.g.
 		// using proactive content negotiation).
 		Title string `json:"title" xml:"title"`
+		// TraceID is the hex-encoded identifier of the trace of the span (e.g. an OpenTelemetry span) that was active within
+		// the context used to build the Problem (e.g. via NewContext or BuildContext), as read by
+		// Generator.TraceContextReader.
+		//
+		// When present, it can be used to correlate an occurrence of the Problem with the distributed trace in which it
+		// occurred.
+		//
+		// TraceID is only populated if Generator.TraceContextFlag has FlagField or either Builder.TraceContext or
+		// WithTraceContext were used and either passed no flags or FlagField explicitly. If FlagField is not present but
+		// FlagLog is, the Problem will contain the trace ID internally for logging within LogValue, however, TraceID will
+		// be empty. This can be useful for cases where trace correlation is desired for logging only.
+		TraceID string `json:"traceId,omitempty" xml:"traceId,omitempty"`
 		// Type is a URI reference that identifies the type of the Problem.
 		//
 		// It is encouraged that, when dereferenced, it provides human-readable documentation for the problem type (e.g.
@@ -159,11 +197,24 @@ type (
 		err error
 		// logInfo contains the relevant logging information for the Problem.
 		logInfo LogInfo
+		// pooled is whether the Problem was acquired from a pool and can therefore be returned to it using
+		// Problem.Release.
+		pooled bool
+		// sanitizer is the ExtensionSanitizer of the Generator used to build the Problem, if any, which is applied to
+		// Problem.Extensions whenever the Problem is marshaled or logged.
+		sanitizer ExtensionSanitizer
+		// provenance contains the BuildSource of each field of the Problem, but only if Generator.RecordProvenance was
+		// enabled when it was built.
+		provenance map[Field]BuildSource
 	}
 
 	// jsonProblem is used to allow JSON data to be unmarshaled into a Problem struct without having
 	// Problem.UnmarshalJSON invoked, resulting in a stack overflow.
 	jsonProblem Problem
+
+	// xmlRepeated is used to collect the values of sibling XML elements sharing the same name when unmarshaling
+	// extensions from XML, so that they can be distinguished from a []any value.
+	xmlRepeated []any
 )
 
 const (
@@ -178,6 +229,9 @@ const (
 	// xmlDefaultSpaceName is used to detect whenever a Problem is being marshaled to XML without an explicit space name
 	// so that it can be replaced with a preferred one.
 	xmlDefaultSpaceName = ""
+	// xmlItemLocalName is the local name of each element representing an item within an array in XML, as demonstrated
+	// by RFC 9457.
+	xmlItemLocalName = "i"
 	// xmlPreferredLocalName is substituted for xmlDefaultLocalName whenever it is detected while a Problem is being
 	// marshaled to XML.
 	xmlPreferredLocalName = "problem"
@@ -192,6 +246,7 @@ var (
 	_ json.Marshaler   = (*Problem)(nil)
 	_ json.Unmarshaler = (*Problem)(nil)
 	_ xml.Marshaler    = (*Problem)(nil)
+	_ xml.Unmarshaler  = (*Problem)(nil)
 )
 
 // reservedExtensions contains extension keys that are reserved. These are typically the names of serialized fields on a
@@ -202,13 +257,68 @@ var reservedExtensions = map[string]struct{}{
 	"detail":     {},
 	"extensions": {},
 	"instance":   {},
+	"spanId":     {},
 	"stack":      {},
 	"status":     {},
+	"timestamp":  {},
 	"title":      {},
+	"traceId":    {},
 	"type":       {},
 	"uuid":       {},
 }
 
+// Causes returns the underlying causes of the Problem, which are the errors within the tree of the error wrapped by the
+// Problem that do not themselves wrap any other error, in the same order as errors.As (i.e. a depth-first pre-order
+// traversal). Any Problem found within the tree is also traversed, so a nested Problem is only considered a cause if it
+// does not wrap an error itself.
+//
+// For example, a Problem wrapping errors.Join(errA, fmt.Errorf("b: %w", errB)) has the causes errA and errB.
+//
+// The tree is traversed to a maximum depth of MaxTreeDepth and any error that has already been visited (i.e. a cycle
+// within an Unwrap chain) is not traversed again.
+//
+// Nil is returned if the Problem does not wrap an error.
+func (p *Problem) Causes() []error {
+	if p == nil || p.err == nil {
+		return nil
+	}
+	var causes []error
+	walkErrors(p.err, func(err error) bool {
+		if wrapsNothing(err) {
+			causes = append(causes, err)
+		}
+		return true
+	})
+	return causes
+}
+
+// DeleteExtension removes the extension with the given key from the Problem, if present.
+//
+// Problem.Extensions is never modified in place; instead it is replaced with a shallow clone without the extension, if
+// present, so that anything that has already obtained Problem.Extensions (e.g. a Logger operating concurrently) is
+// unaffected. However, a Problem is not safe for concurrent modification so a Problem should either be treated as
+// immutable once shared or Problem.Freeze should be used to share a ProblemView instead.
+func (p *Problem) DeleteExtension(key string) {
+	if _, found := p.Extensions[key]; !found {
+		return
+	}
+	extensions := make(Extensions, len(p.Extensions)-1)
+	for k, v := range p.Extensions {
+		if k != key {
+			extensions[k] = v
+		}
+	}
+	if len(extensions) == 0 {
+		extensions = nil
+	}
+	p.Extensions = extensions
+}
+
+// DocsURL returns the URL of documentation describing the Problem, if any. See ExtensionDocsURL for more information.
+func (p *Problem) DocsURL() string {
+	return p.stringExtension(ExtensionDocsURL)
+}
+
 // Error returns the most suitable error message for the Problem.
 //
 // If the Problem wraps another error, the message of that error will be included.
@@ -224,6 +334,73 @@ func (p *Problem) Extension(key string) (value any, found bool) {
 	return
 }
 
+// FieldErrors returns each FieldError contained within the ExtensionFieldErrors extension of the Problem, if any.
+//
+// In addition to a Problem built using Builder.FieldErrors or WithFieldErrors, this supports a Problem decoded from JSON
+// or XML, where the extension contains objects (or an object) with the same members as a FieldError. Any member that is
+// not a string is ignored.
+//
+// Returns nil if the Problem is nil or has no field errors. The returned slice can be modified without affecting the
+// Problem.
+func (p *Problem) FieldErrors() []FieldError {
+	value, found := p.Extension(ExtensionFieldErrors)
+	if !found {
+		return nil
+	}
+	var errs []FieldError
+	switch v := value.(type) {
+	case []FieldError:
+		errs = append(errs, v...)
+	case FieldError:
+		errs = append(errs, v)
+	case []any:
+		for _, item := range v {
+			if fe, ok := fieldErrorFromValue(item); ok {
+				errs = append(errs, fe)
+			}
+		}
+	default:
+		if fe, ok := fieldErrorFromValue(v); ok {
+			errs = append(errs, fe)
+		}
+	}
+	return errs
+}
+
+// HelpURL returns the URL of actionable remediation steps for the Problem, if any. See ExtensionHelpURL for more
+// information.
+func (p *Problem) HelpURL() string {
+	return p.stringExtension(ExtensionHelpURL)
+}
+
+// IsRetryable returns whether a request that resulted in the Problem can be retried. false is always returned if the
+// Problem is nil.
+//
+// If the Problem explicitly indicates whether it is retryable (see ExtensionRetryable), that is always returned.
+// Otherwise, a Problem is considered retryable if it contains a retry hint (see ExtensionRetryAfter) or its status is
+// either 429 (Too Many Requests), 502 (Bad Gateway), 503 (Service Unavailable), or 504 (Gateway Timeout).
+//
+// Unlike BackoffPolicy, which considers more statuses retryable by default, IsRetryable only considers those statuses
+// that are almost always transient.
+func (p *Problem) IsRetryable() bool {
+	if p == nil {
+		return false
+	}
+	if v, found := p.Extension(ExtensionRetryable); found {
+		retryable, ok := v.(bool)
+		return ok && retryable
+	}
+	if _, hasHint := retryAfterHint(p); hasHint {
+		return true
+	}
+	switch p.Status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
 // MarshalJSON marshals the Problem into JSON.
 //
 // This is required in order to allow Problem.Extensions to be marshaled at the top-level of a Problem. Unfortunately,
@@ -232,7 +409,16 @@ func (p *Problem) Extension(key string) (value any, found bool) {
 // An error is returned if unable to marshal the Problem or Problem.Extensions contains a key that is either empty or
 // reserved (i.e. conflicts with Problem-level fields).
 func (p *Problem) MarshalJSON() ([]byte, error) {
-	b, err := json.Marshal(*p)
+	p = p.sanitized()
+	// Timestamp is encoded separately as encoding/json never omits a zero time.Time
+	x := struct {
+		*jsonProblem
+		Timestamp *time.Time `json:"timestamp,omitempty"`
+	}{jsonProblem: (*jsonProblem)(p)}
+	if !p.Timestamp.IsZero() {
+		x.Timestamp = &p.Timestamp
+	}
+	b, err := json.Marshal(x)
 	if err != nil {
 		return nil, err
 	}
@@ -261,13 +447,77 @@ func (p *Problem) MarshalJSON() ([]byte, error) {
 // An error is returned if unable to marshal the Problem or Problem.Extensions contains a key that is either empty or
 // reserved (i.e. conflicts with Problem-level fields).
 func (p *Problem) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	p = p.sanitized()
 	if start.Name.Local == xmlDefaultLocalName {
 		start.Name.Local = xmlPreferredLocalName
 	}
 	if start.Name.Space == xmlDefaultSpaceName {
 		start.Name.Space = xmlPreferredSpaceName
 	}
-	return e.EncodeElement(*p, start)
+	// Timestamp is encoded separately as encoding/xml never omits a zero time.Time
+	x := struct {
+		*jsonProblem
+		Timestamp *time.Time `xml:"timestamp,omitempty"`
+	}{jsonProblem: (*jsonProblem)(p)}
+	if !p.Timestamp.IsZero() {
+		x.Timestamp = &p.Timestamp
+	}
+	return e.EncodeElement(x, start)
+}
+
+// RangeExtensions calls fn sequentially for each extension within the Problem, sorted by key. If fn returns false,
+// RangeExtensions stops the iteration.
+//
+// Since Problem.Extensions is never modified in place by Problem.SetExtension or Problem.DeleteExtension, fn is free to
+// call either of them without affecting the iteration.
+func (p *Problem) RangeExtensions(fn func(key string, value any) bool) {
+	if p == nil {
+		return
+	}
+	rangeExtensions(p.Extensions, fn)
+}
+
+// RootCause returns the first underlying cause of the Problem, which is the first error within the tree of the error
+// wrapped by the Problem that does not itself wrap any other error. See Problem.Causes for more information.
+//
+// This is intended to make it easy to report the underlying failure of a Problem (e.g. within logs) without having to
+// manually unwrap the error.
+//
+// Nil is returned if the Problem does not wrap an error.
+func (p *Problem) RootCause() error {
+	if p == nil || p.err == nil {
+		return nil
+	}
+	var cause error
+	walkErrors(p.err, func(err error) bool {
+		if wrapsNothing(err) {
+			cause = err
+			return false
+		}
+		return true
+	})
+	return cause
+}
+
+// SetExtension sets the given extension key and value on the Problem, replacing any existing extension with the same
+// key.
+//
+// Problem.Extensions is never modified in place; instead it is replaced with a shallow clone containing the extension
+// so that anything that has already obtained Problem.Extensions (e.g. a Logger operating concurrently) is unaffected.
+// However, a Problem is not safe for concurrent modification so a Problem should either be treated as immutable once
+// shared or Problem.Freeze should be used to share a ProblemView instead.
+//
+// Panics if key is either empty or reserved (i.e. conflicts with Problem-level fields).
+func (p *Problem) SetExtension(key string, value any) {
+	if err := validationExtensionKey(key); err != nil {
+		panic(err)
+	}
+	extensions := make(Extensions, len(p.Extensions)+1)
+	for k, v := range p.Extensions {
+		extensions[k] = v
+	}
+	extensions[key] = value
+	p.Extensions = extensions
 }
 
 // String returns a string representation of the Problem.
@@ -302,12 +552,88 @@ func (p *Problem) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
-// Unwrap returns the error wrapped by the Problem, if any, otherwise returns nil.
-func (p *Problem) Unwrap() error {
-	if p == nil {
+// UnmarshalXML unmarshals the XML element provided into the Problem.
+//
+// This is required in order to unmarshal any unknown top-level elements into Problem.Extensions, which allows
+// application/problem+xml to be round-tripped. Elements are matched based on their local names only, ignoring any
+// namespace.
+//
+// Since XML carries no type information, the value of each extension is derived from the structure of its element:
+//
+//   - An element without any child elements is unmarshaled as a string containing its character data
+//   - An element whose child elements are all "i" elements, as demonstrated by RFC 9457, is unmarshaled as a []any
+//   - Any other element with child elements is unmarshaled as a map[string]any, where child elements sharing the same
+//     name are collected into a []any
+//
+// An error is returned if unable to unmarshal the element (e.g. if <status> does not contain an integer).
+func (p *Problem) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var prob Problem
+	extensions := make(Extensions)
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "code":
+				err = d.DecodeElement(&prob.Code, &t)
+			case "detail":
+				err = d.DecodeElement(&prob.Detail, &t)
+			case "instance":
+				err = d.DecodeElement(&prob.Instance, &t)
+			case "spanId":
+				err = d.DecodeElement(&prob.SpanID, &t)
+			case "stack":
+				err = d.DecodeElement(&prob.Stack, &t)
+			case "status":
+				err = d.DecodeElement(&prob.Status, &t)
+			case "timestamp":
+				err = d.DecodeElement(&prob.Timestamp, &t)
+			case "title":
+				err = d.DecodeElement(&prob.Title, &t)
+			case "traceId":
+				err = d.DecodeElement(&prob.TraceID, &t)
+			case "type":
+				err = d.DecodeElement(&prob.Type, &t)
+			case "uuid":
+				err = d.DecodeElement(&prob.UUID, &t)
+			case "extensions":
+				err = d.Skip()
+			default:
+				var v any
+				if v, err = decodeXMLValue(d); err == nil {
+					extensions[t.Name.Local] = v
+				}
+			}
+			if err != nil {
+				return err
+			}
+		case xml.EndElement:
+			if len(extensions) > 0 {
+				prob.Extensions = extensions
+			}
+			*p = prob
+			return nil
+		}
+	}
+}
+
+// Unwrap returns the errors wrapped by the Problem, if any, otherwise returns nil.
+//
+// If the Problem wraps a joined error (e.g. via errors.Join), each of its errors is returned so that errors.Is and
+// errors.As check every branch. Otherwise, only the wrapped error is returned (including when it is another Problem).
+func (p *Problem) Unwrap() []error {
+	if p == nil || p.err == nil {
 		return nil
 	}
-	return p.err
+	if _, isProblem := p.err.(*Problem); !isProblem {
+		if joined, isJoined := p.err.(interface{ Unwrap() []error }); isJoined {
+			return joined.Unwrap()
+		}
+	}
+	return []error{p.err}
 }
 
 // buildString returns a string representation of the Problem while providing control over whether any wrapped error is
@@ -338,6 +664,17 @@ func (p *Problem) buildString(inclErr bool) string {
 	return sb.String()
 }
 
+// stringExtension returns the value of the extension with the given key only if it is a string, otherwise an empty
+// string.
+func (p *Problem) stringExtension(key string) string {
+	if v, found := p.Extension(key); found {
+		if s, ok := v.(string); ok {
+			return s
+		}
+	}
+	return ""
+}
+
 // New returns a constructed Problem using context.Background, optionally using the options provided as well.
 func (g *Generator) New(opts ...Option) *Problem {
 	return g.new(context.Background(), opts, 1)
@@ -373,3 +710,105 @@ func New(opts ...Option) *Problem {
 func NewContext(ctx context.Context, opts ...Option) *Problem {
 	return GetGenerator(ctx).new(ctx, opts, 1)
 }
+
+// decodeXMLValue decodes the value of the XML element whose xml.StartElement has just been read from the given
+// xml.Decoder. See Problem.UnmarshalXML for more information.
+func decodeXMLValue(d *xml.Decoder) (any, error) {
+	var text strings.Builder
+	var names []string
+	var values []any
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.CharData:
+			text.Write(t)
+		case xml.StartElement:
+			v, err := decodeXMLValue(d)
+			if err != nil {
+				return nil, err
+			}
+			names = append(names, t.Name.Local)
+			values = append(values, v)
+		case xml.EndElement:
+			if len(names) == 0 {
+				return text.String(), nil
+			}
+			if !slices.ContainsFunc(names, func(name string) bool { return name != xmlItemLocalName }) {
+				return values, nil
+			}
+			m := make(map[string]any, len(names))
+			for i, name := range names {
+				switch existing := m[name].(type) {
+				case nil:
+					m[name] = values[i]
+				case xmlRepeated:
+					m[name] = append(existing, values[i])
+				default:
+					m[name] = xmlRepeated{existing, values[i]}
+				}
+			}
+			for k, v := range m {
+				if repeated, ok := v.(xmlRepeated); ok {
+					m[k] = []any(repeated)
+				}
+			}
+			return m, nil
+		}
+	}
+}
+
+// encodeXMLValue encodes the given value as an XML element with the local name provided. See Extensions.MarshalXML
+// for more information.
+func encodeXMLValue(e *xml.Encoder, name string, value any) error {
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	switch v := value.(type) {
+	case map[string]any:
+		if err := e.EncodeToken(start); err != nil {
+			return err
+		}
+		var err error
+		rangeExtensions(v, func(key string, value any) bool {
+			err = encodeXMLValue(e, key, value)
+			return err == nil
+		})
+		if err != nil {
+			return err
+		}
+		return e.EncodeToken(start.End())
+	case []any:
+		if err := e.EncodeToken(start); err != nil {
+			return err
+		}
+		for _, item := range v {
+			if err := encodeXMLValue(e, xmlItemLocalName, item); err != nil {
+				return err
+			}
+		}
+		return e.EncodeToken(start.End())
+	case []FieldError:
+		items := make([]any, len(v))
+		for i, fe := range v {
+			items[i] = fe
+		}
+		return encodeXMLValue(e, name, items)
+	default:
+		return e.EncodeElement(value, start)
+	}
+}
+
+// rangeExtensions calls fn sequentially for each of the given extensions, sorted by key, until fn returns false.
+func rangeExtensions(extensions Extensions, fn func(key string, value any) bool) {
+	keys := make([]string, 0, len(extensions))
+	for k := range extensions {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		if !fn(k, extensions[k]) {
+			return
+		}
+	}
+}
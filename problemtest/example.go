@@ -0,0 +1,74 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package problemtest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/neocotic/go-problem"
+)
+
+// ErrExampleMismatch is returned by MatchExample when a problem.Problem does not match the example payload.
+var ErrExampleMismatch = errors.New("problem does not match example")
+
+// MatchExample returns an error if the given problem.Problem does not match the problem.ExampleProblem with the given
+// name within problem.Definition.Examples, otherwise nil. This allows the examples of a problem.Definition to be used
+// as golden values within tests, ensuring that they remain accurate as code evolves.
+//
+// Both are compared using their canonical JSON representation (see problem.CanonicalJSON) so that the order in which
+// extensions were added is irrelevant. Since a stack trace and UUID are typically unique to each occurrence, it is
+// recommended that they are not made visible on a problem.Problem (i.e. using problem.FlagField) that is to be
+// matched.
+//
+// An error is also returned if def has no such example or either cannot be marshaled into JSON. A nil problem.Problem
+// only matches an example without a payload.
+//
+// For example;
+//
+//	prob := svc.GetUser(ctx, "unknown")
+//	if err := problemtest.MatchExample(UserNotFound, "unknown-id", prob); err != nil {
+//		t.Error(err)
+//	}
+func MatchExample(def problem.Definition, name string, got *problem.Problem) error {
+	example, ok := def.Example(name)
+	if !ok {
+		return fmt.Errorf("example not found: %q", name)
+	}
+	if example.Problem == nil || got == nil {
+		if example.Problem != got {
+			return fmt.Errorf("%w %q: want %v, got %v", ErrExampleMismatch, name, example.Problem, got)
+		}
+		return nil
+	}
+	want, err := problem.CanonicalJSON(example.Problem)
+	if err != nil {
+		return fmt.Errorf("invalid example %q: %w", name, err)
+	}
+	actual, err := problem.CanonicalJSON(got)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(want, actual) {
+		return fmt.Errorf("%w %q:\n\twant: %s\n\tgot:  %s", ErrExampleMismatch, name, want, actual)
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package problemtest provides utilities for testing code that consumes problems, such as HTTP clients decoding
+// problems from upstream services.
+package problemtest
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"github.com/neocotic/go-problem"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ResponseSpec describes an HTTP response containing a problem to be served by Server.
+//
+// All fields are optional with default behaviour clearly documented.
+type ResponseSpec struct {
+	// Body is the raw body to be written to the HTTP response.
+	//
+	// If not nil, Problem is ignored, allowing malformed problems to be served.
+	Body []byte
+	// ContentType is the content/media type to be used in the HTTP response and also dictates how Problem is encoded.
+	// Problem is encoded as XML if the media type has an "xml" suffix, otherwise it is encoded as JSON.
+	//
+	// If empty, problem.ContentTypeJSONUTF8 will be used.
+	ContentType string
+	// Delay is the duration to wait before writing the HTTP response, allowing slow upstream services and timeouts to
+	// be simulated.
+	//
+	// The delay is cut short if the HTTP request's context.Context is done. If zero or less, there is no delay.
+	Delay time.Duration
+	// Header contains any additional headers to be written to the HTTP response (e.g. Retry-After).
+	Header http.Header
+	// Problem is the problem.Problem to be encoded and written to the HTTP response.
+	//
+	// If nil, a problem.Problem containing only the status (and its corresponding title) is written.
+	Problem *problem.Problem
+	// Status is the status code to be written to the HTTP response.
+	//
+	// If less than or equal to zero, problem.Problem.Status will be used with a fallback to
+	// http.StatusInternalServerError.
+	Status int
+}
+
+// Server returns a started httptest.Server that serves the HTTP responses described by each ResponseSpec, in order,
+// one per request. Once all responses have been served, the last ResponseSpec is served for all subsequent requests.
+// If no ResponseSpec is provided, a basic http.StatusInternalServerError problem is always served.
+//
+// The caller is responsible for calling httptest.Server.Close once finished.
+//
+// For example;
+//
+//	srv := problemtest.Server(
+//		problemtest.ResponseSpec{Status: http.StatusServiceUnavailable, Header: http.Header{"Retry-After": {"1"}}},
+//		problemtest.ResponseSpec{Status: http.StatusNotFound, ContentType: problem.ContentTypeXML},
+//	)
+//	defer srv.Close()
+func Server(specs ...ResponseSpec) *httptest.Server {
+	var (
+		mu    sync.Mutex
+		count int
+	)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var spec ResponseSpec
+		if l := len(specs); l > 0 {
+			mu.Lock()
+			spec = specs[min(count, l-1)]
+			count++
+			mu.Unlock()
+		}
+		spec.serve(w, req)
+	}))
+}
+
+// serve writes the HTTP response described by the ResponseSpec.
+func (rs ResponseSpec) serve(w http.ResponseWriter, req *http.Request) {
+	if rs.Delay > 0 {
+		t := time.NewTimer(rs.Delay)
+		select {
+		case <-req.Context().Done():
+			t.Stop()
+			return
+		case <-t.C:
+		}
+	}
+
+	ct := rs.ContentType
+	if ct == "" {
+		ct = problem.ContentTypeJSONUTF8
+	}
+	prob := rs.Problem
+	if prob == nil {
+		status := rs.Status
+		if status <= 0 {
+			status = http.StatusInternalServerError
+		}
+		prob = &problem.Problem{
+			Status: status,
+			Title:  http.StatusText(status),
+			Type:   problem.DefaultTypeURI,
+		}
+	}
+	status := rs.Status
+	if status <= 0 {
+		status = prob.Status
+	}
+	if status <= 0 {
+		status = http.StatusInternalServerError
+	}
+
+	body := rs.Body
+	if body == nil {
+		var err error
+		if isXML(ct) {
+			body, err = xml.Marshal(prob)
+		} else {
+			body, err = json.Marshal(prob)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	for k, v := range rs.Header {
+		w.Header()[k] = v
+	}
+	w.Header().Set("Content-Type", ct)
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
+
+// isXML returns whether the given content/media type represents XML.
+func isXML(ct string) bool {
+	mediaType, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml")
+}
@@ -0,0 +1,128 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package problem
+
+import (
+	"context"
+	"net/http"
+)
+
+type (
+	// Profile bundles the serialization options used when writing a Problem as an HTTP response for a specific audience
+	// (e.g. "public", "partner", or "internal"), allowing a single Generator to serve multiple audiences. See
+	// Generator.Profiles for more information.
+	Profile struct {
+		// ContentType is the content/media type to be used in the HTTP response whenever Generator.WriteError or
+		// Generator.WriteProblem are called without a WriteOptions.ContentType being passed, taking precedence over
+		// Generator.ContentType.
+		//
+		// If empty or unsupported, Generator.ContentType is used.
+		ContentType string
+		// FieldMask controls which fields of a Problem, including the keys of its extensions, are included within the
+		// HTTP response whenever WriteOptions.FieldMask is not passed. See FieldMask for more information.
+		//
+		// If zero, all fields are included.
+		FieldMask FieldMask
+		// Ownership is whether the owner and runbook URL of a Problem (see Definition.Owner and Definition.RunbookURL),
+		// which are ordinarily only visible within logs, are included within the HTTP response as extensions using
+		// ExtensionOwner and ExtensionRunbookURL as the keys, unless the extensions are already present. This is
+		// typically only desirable for an internal audience.
+		//
+		// If false, ownership is only ever visible within logs.
+		Ownership bool
+		// Sanitizers contains each Sanitizer to be called, in order, before a Problem is encoded.
+		//
+		// If empty, a Problem is not sanitized.
+		Sanitizers []Sanitizer
+	}
+
+	// Sanitizer is a function used to sanitize a Problem before it is encoded when written as an HTTP response for a
+	// Profile (e.g. to redact sensitive information from its detail for a public audience).
+	//
+	// prob is a shallow clone of the Problem being written, including its extensions, and so can be modified without
+	// affecting the original Problem, which is still logged and passed to any Notifier in its entirety.
+	Sanitizer func(ctx context.Context, prob *Problem)
+)
+
+// defaultContentType returns the content/media type to be used whenever none is passed within the given WriteOptions,
+// being either that of the resolved Profile, if supported, or that negotiated using the Accept header of req where
+// Generator.NegotiateContentType is enabled, otherwise Generator.ContentType with a fallback to ContentTypeJSONUTF8.
+//
+// See Generator.profile for how the Profile is resolved. Whenever the content/media type is negotiated, a Vary header is
+// added to w.
+func (g *Generator) defaultContentType(w http.ResponseWriter, req *http.Request, opts []WriteOptions) string {
+	var _opts WriteOptions
+	for _, o := range opts {
+		_opts = _opts.merge(o, g.isValidContentType)
+	}
+	fallback := g.contentType()
+	if ct := g.profile(req.Context(), _opts.Profile).ContentType; ct != "" && g.isValidContentType(ct) {
+		fallback = ct
+	}
+	if !g.NegotiateContentType || _opts.ContentType != "" {
+		return fallback
+	}
+	w.Header().Add("Vary", "Accept")
+	return g.negotiateContentType(req, fallback)
+}
+
+// outputProblem returns the Problem to be encoded when the given Problem is written as an HTTP response using
+// WriteOptions, that are expected to have been applied.
+//
+// This includes any information added while debugging (see Generator.DebugMode), excludes any extensions whose
+// ExtensionPredicate returns false (see Generator.ExtensionPredicates), includes any ownership where the resolved
+// Profile allows it (see Profile.Ownership), applies the Sanitizers of the resolved Profile, replaces any type URI that
+// is not allowed (see Generator.AllowedTypeURIs), and applies the most relevant FieldMask (i.e. WriteOptions.FieldMask,
+// otherwise Profile.FieldMask). prob itself is never modified.
+func (g *Generator) outputProblem(ctx context.Context, prob *Problem, opts WriteOptions) *Problem {
+	prob = g.filterExtensions(ctx, g.debugProblem(prob))
+	profile := g.profile(ctx, opts.Profile)
+	if profile.Ownership {
+		prob = ownershipProblem(prob)
+	}
+	if len(profile.Sanitizers) > 0 && prob != nil {
+		prob = prob.clone()
+		for _, sanitizer := range profile.Sanitizers {
+			sanitizer(ctx, prob)
+		}
+	}
+	if prob != nil && !g.isAllowedTypeURI(prob.Type) {
+		prob = prob.clone()
+		prob.Type = DefaultTypeURI
+	}
+	mask := opts.FieldMask
+	if mask.IsZero() {
+		mask = profile.FieldMask
+	}
+	return mask.apply(prob)
+}
+
+// profile returns the Profile within Generator.Profiles with the given name or, if name is empty, the name within the
+// given context.Context (see UsingProfile). If no such Profile exists, a zero Profile is returned, which has no effect.
+func (g *Generator) profile(ctx context.Context, name string) Profile {
+	if name == "" {
+		name, _ = GetProfile(ctx)
+	}
+	if name == "" {
+		return Profile{}
+	}
+	return g.Profiles[name]
+}
@@ -0,0 +1,118 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package problem
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// UpstreamProblem contains a compact representation of a Problem that has been propagated across process boundaries
+// (e.g. via HTTP request headers), allowing a downstream service to link its own problems to the upstream occurrence.
+type UpstreamProblem struct {
+	// Code is the Code of the upstream Problem, if any.
+	Code Code `json:"code,omitempty" xml:"code,omitempty" yaml:"code,omitempty"`
+	// UUID is the "UUID" of the upstream Problem, if any.
+	UUID string `json:"uuid,omitempty" xml:"uuid,omitempty" yaml:"uuid,omitempty"`
+}
+
+const (
+	// ExtensionUpstream is the key of the extension that may contain an UpstreamProblem, linking a Problem to the
+	// upstream occurrence that caused it.
+	ExtensionUpstream = "upstream"
+	// HeaderProblemContext is the HTTP header used to propagate an UpstreamProblem.
+	HeaderProblemContext = "Problem-Context"
+)
+
+// IsZero returns whether the UpstreamProblem contains no information.
+func (up UpstreamProblem) IsZero() bool {
+	return up.Code == "" && up.UUID == ""
+}
+
+// String returns a compact string representation of the UpstreamProblem, as used by HeaderProblemContext.
+//
+// For example;
+//
+//	UpstreamProblem{Code: "USER-404", UUID: "2fb0c5d2-5a3c-4d8e-9c0e-2b1d0c4f7a6e"}.String()  // "code=USER-404;uuid=2fb0c5d2-5a3c-4d8e-9c0e-2b1d0c4f7a6e"
+func (up UpstreamProblem) String() string {
+	var parts []string
+	if up.Code != "" {
+		parts = append(parts, "code="+url.QueryEscape(string(up.Code)))
+	}
+	if up.UUID != "" {
+		parts = append(parts, "uuid="+url.QueryEscape(up.UUID))
+	}
+	return strings.Join(parts, ";")
+}
+
+// ExtractHeader returns the UpstreamProblem propagated within the given HTTP headers using HeaderProblemContext, if any.
+//
+// Any unrecognized or malformed information within the header is ignored.
+//
+// For example;
+//
+//	func handler(w http.ResponseWriter, req *http.Request) {
+//		if up, ok := ExtractHeader(req.Header); ok {
+//			// Link any problems generated while handling the request to the upstream occurrence
+//			prob := NewContext(req.Context(), WithExtension(ExtensionUpstream, up))
+//			// ...
+//		}
+//	}
+func ExtractHeader(header http.Header) (UpstreamProblem, bool) {
+	var up UpstreamProblem
+	for _, part := range strings.Split(header.Get(HeaderProblemContext), ";") {
+		k, v, found := strings.Cut(strings.TrimSpace(part), "=")
+		if !found {
+			continue
+		}
+		if v, err := url.QueryUnescape(v); err == nil {
+			switch k {
+			case "code":
+				up.Code = Code(v)
+			case "uuid":
+				up.UUID = v
+			}
+		}
+	}
+	return up, !up.IsZero()
+}
+
+// InjectHeader injects a compact representation of the given Problem (i.e. its Code and "UUID") into the HTTP headers
+// provided using HeaderProblemContext, typically those of an outbound request, so that the receiving service can use
+// ExtractHeader to link its own problems to the occurrence.
+//
+// The "UUID" of prob is used even if it is only visible within logs (i.e. Generator.UUIDFlag only contains FlagLog). If
+// prob is nil or contains no Code or "UUID", the header is not set.
+//
+// For example;
+//
+//	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, "https://audit.example.void/events", body)
+//	InjectHeader(req.Header, prob)
+func InjectHeader(header http.Header, prob *Problem) {
+	if prob == nil {
+		return
+	}
+	up := UpstreamProblem{Code: prob.Code, UUID: firstNonZeroValue(prob.UUID, prob.logInfo.UUID)}
+	if !up.IsZero() {
+		header.Set(HeaderProblemContext, up.String())
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package problem
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// ExtensionRateLimit is the key of the extension that may contain the maximum number of requests that a client is
+	// allowed to make within the current rate limit window. See Builder.RateLimit for more information.
+	ExtensionRateLimit = "rateLimit"
+	// ExtensionRateLimitRemaining is the key of the extension that may contain the number of requests that a client is
+	// still allowed to make within the current rate limit window. See Builder.RateLimit for more information.
+	ExtensionRateLimitRemaining = "rateLimitRemaining"
+	// ExtensionRateLimitReset is the key of the extension that may contain the number of seconds until the current rate
+	// limit window resets. See Builder.RateLimit for more information.
+	ExtensionRateLimitReset = "rateLimitReset"
+)
+
+// rateLimitHeaders contains the names of the HTTP headers used to describe a rate limit, each paired with the key of
+// the extension containing its value. Both the standardized (i.e. "RateLimit-*") and the widely adopted legacy (i.e.
+// "X-RateLimit-*") headers are used.
+//
+// Where epoch is true, the value of the header is the time at which the current rate limit window resets as a Unix
+// timestamp in seconds, rather than the number of seconds until then, since that is how "X-RateLimit-Reset" is
+// conventionally interpreted by clients.
+var rateLimitHeaders = []struct {
+	epoch     bool
+	extension string
+	header    string
+}{
+	{extension: ExtensionRateLimit, header: "RateLimit-Limit"},
+	{extension: ExtensionRateLimitRemaining, header: "RateLimit-Remaining"},
+	{extension: ExtensionRateLimitReset, header: "RateLimit-Reset"},
+	{extension: ExtensionRateLimit, header: "X-RateLimit-Limit"},
+	{extension: ExtensionRateLimitRemaining, header: "X-RateLimit-Remaining"},
+	{epoch: true, extension: ExtensionRateLimitReset, header: "X-RateLimit-Reset"},
+}
+
+// addRateLimitHeaders adds a header to the given http.Header for each part of the rate limit described by the Problem
+// provided (see ExtensionRateLimit, ExtensionRateLimitRemaining, and ExtensionRateLimitReset), unless the header is
+// already present. See Builder.RateLimit for more information.
+func addRateLimitHeaders(header http.Header, prob *Problem) {
+	now := time.Now()
+	for _, rlh := range rateLimitHeaders {
+		if header.Get(rlh.header) != "" {
+			continue
+		}
+		n, found := intExtension(prob, rlh.extension)
+		if !found {
+			continue
+		}
+		if rlh.epoch {
+			header.Set(rlh.header, strconv.FormatInt(now.Add(time.Duration(n)*time.Second).Unix(), 10))
+		} else {
+			header.Set(rlh.header, strconv.Itoa(n))
+		}
+	}
+}
+
+// intExtension returns the value of the extension with the given key within the Problem as an int, if present and
+// it's a number or a string containing an integer. Any fractional number is truncated.
+func intExtension(prob *Problem, key string) (int, bool) {
+	v, found := prob.Extension(key)
+	if !found {
+		return 0, false
+	}
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case int64:
+		return int(n), true
+	case float64:
+		return int(n), true
+	case time.Duration:
+		return retryAfterSeconds(n), true
+	case string:
+		i, err := strconv.Atoi(strings.TrimSpace(n))
+		return i, err == nil
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,226 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package problem
+
+import (
+	"reflect"
+	"strings"
+)
+
+// ExtensionSanitizer is a function used by a Generator to sanitize the value of an extension, identified by the given
+// key, returning the value to be used in its place and whether the extension is to be kept at all. See
+// Generator.ExtensionSanitizer for more information.
+//
+// An ExtensionSanitizer is also called for the entries of any map[string]any (incl. Extensions) nested within the value
+// of an extension, allowing sensitive data to be scrubbed regardless of where it was included.
+type ExtensionSanitizer func(key string, value any) (any, bool)
+
+// DefaultRedactedValue is the value used in place of the value of an extension that has been masked by
+// MaskExtensionSanitizer when no value is given.
+const DefaultRedactedValue = "[REDACTED]"
+
+// ComposeExtensionSanitizer returns an ExtensionSanitizer that calls each of the given sanitizers in order, passing the
+// value returned by one to the next, and stops as soon as any drops the extension.
+func ComposeExtensionSanitizer(sanitizers ...ExtensionSanitizer) ExtensionSanitizer {
+	return func(key string, value any) (any, bool) {
+		for _, sanitizer := range sanitizers {
+			var keep bool
+			if value, keep = sanitizer(key, value); !keep {
+				return nil, false
+			}
+		}
+		return value, true
+	}
+}
+
+// DropExtensionSanitizer returns an ExtensionSanitizer that drops any extension whose key contains any of the given
+// patterns, ignoring case.
+//
+// For example;
+//
+//	g := &Generator{ExtensionSanitizer: DropExtensionSanitizer("password", "secret")}
+//	g.New(WithExtension("userPassword", "hunter2")).Extensions  // nil
+func DropExtensionSanitizer(patterns ...string) ExtensionSanitizer {
+	patterns = lowerPatterns(patterns)
+	return func(key string, value any) (any, bool) {
+		if matchesKeyPattern(key, patterns) {
+			return nil, false
+		}
+		return value, true
+	}
+}
+
+// MaskExtensionSanitizer returns an ExtensionSanitizer that replaces the value of any extension whose key contains any
+// of the given patterns, ignoring case, with the mask provided. If mask is empty, DefaultRedactedValue is used.
+//
+// For example;
+//
+//	g := &Generator{ExtensionSanitizer: MaskExtensionSanitizer("", "email", "token")}
+//	g.New(WithExtension("accessToken", "abc123")).Extensions  // map[accessToken:[REDACTED]]
+func MaskExtensionSanitizer(mask string, patterns ...string) ExtensionSanitizer {
+	if mask == "" {
+		mask = DefaultRedactedValue
+	}
+	patterns = lowerPatterns(patterns)
+	return func(key string, value any) (any, bool) {
+		if matchesKeyPattern(key, patterns) {
+			return mask, true
+		}
+		return value, true
+	}
+}
+
+// sanitized returns the Problem with its extensions sanitized using the ExtensionSanitizer of the Generator that built
+// it, if any. A shallow clone is returned if any extension was sanitized, otherwise p is returned as-is.
+func (p *Problem) sanitized() *Problem {
+	if p.sanitizer == nil || len(p.Extensions) == 0 {
+		return p
+	}
+	extensions, changed := sanitizeMap(p.Extensions, p.sanitizer)
+	if !changed {
+		return p
+	}
+	clone := p.clone()
+	clone.Extensions = extensions
+	return clone
+}
+
+// isSameValue returns whether the given values are the same, comparing any map or slice by reference instead of
+// content, and treating any other values that cannot be compared as different.
+func isSameValue(a, b any) bool {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	switch {
+	case !va.IsValid() || !vb.IsValid():
+		return va.IsValid() == vb.IsValid()
+	case va.Type() != vb.Type():
+		return false
+	}
+	switch va.Kind() {
+	case reflect.Map:
+		return va.UnsafePointer() == vb.UnsafePointer()
+	case reflect.Slice:
+		return va.UnsafePointer() == vb.UnsafePointer() && va.Len() == vb.Len()
+	default:
+		return va.Comparable() && va.Equal(vb)
+	}
+}
+
+// lowerPatterns returns a copy of the given patterns in lower case, ignoring any that are empty.
+func lowerPatterns(patterns []string) []string {
+	lower := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		if pattern != "" {
+			lower = append(lower, strings.ToLower(pattern))
+		}
+	}
+	return lower
+}
+
+// matchesKeyPattern returns whether the given key contains any of the lower case patterns provided, ignoring case.
+func matchesKeyPattern(key string, patterns []string) bool {
+	key = strings.ToLower(key)
+	for _, pattern := range patterns {
+		if strings.Contains(key, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// sanitizeExtensions returns the given extensions sanitized using the ExtensionSanitizer provided, if any.
+//
+// extensions is never modified in place; a new map is returned if any extension was sanitized, otherwise extensions is
+// returned as-is.
+func sanitizeExtensions(extensions Extensions, sanitizer ExtensionSanitizer) Extensions {
+	if sanitizer == nil || len(extensions) == 0 {
+		return extensions
+	}
+	sanitized, _ := sanitizeMap(extensions, sanitizer)
+	if len(sanitized) == 0 {
+		return nil
+	}
+	return sanitized
+}
+
+// sanitizeMap returns the given map sanitized using the ExtensionSanitizer provided, along with whether any entry was
+// sanitized, including those within any nested map[string]any (incl. Extensions) or []any.
+//
+// m is never modified in place; a new map is returned if any entry was sanitized, otherwise m is returned as-is.
+func sanitizeMap[M ~map[string]any](m M, sanitizer ExtensionSanitizer) (M, bool) {
+	var res M
+	for key, value := range m {
+		sanitized, keep := sanitizer(key, value)
+		var changed bool
+		if keep {
+			sanitized, changed = sanitizeValue(sanitized, sanitizer)
+		}
+		if !changed && keep && !isSameValue(value, sanitized) {
+			changed = true
+		}
+		if !keep || changed {
+			if res == nil {
+				res = make(M, len(m))
+				for k, v := range m {
+					res[k] = v
+				}
+			}
+			if keep {
+				res[key] = sanitized
+			} else {
+				delete(res, key)
+			}
+		}
+	}
+	if res == nil {
+		return m, false
+	}
+	return res, true
+}
+
+// sanitizeValue returns the given value with any nested map[string]any (incl. Extensions) or []any sanitized using the
+// ExtensionSanitizer provided, along with whether anything was sanitized. Any other value is returned as-is.
+func sanitizeValue(value any, sanitizer ExtensionSanitizer) (any, bool) {
+	switch v := value.(type) {
+	case Extensions:
+		return sanitizeMap(v, sanitizer)
+	case map[string]any:
+		return sanitizeMap(v, sanitizer)
+	case []any:
+		var res []any
+		for i, item := range v {
+			sanitized, changed := sanitizeValue(item, sanitizer)
+			if !changed {
+				continue
+			}
+			if res == nil {
+				res = make([]any, len(v))
+				copy(res, v)
+			}
+			res[i] = sanitized
+		}
+		if res == nil {
+			return v, false
+		}
+		return res, true
+	default:
+		return value, false
+	}
+}
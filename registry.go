@@ -0,0 +1,446 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Registry keeps track of the Code assigned to each Definition (or any other occurrence of a Problem), identified by a
+// stable key (e.g. the name of the variable containing the Definition), so that codes can be allocated sequentially
+// within each NS without any manual bookkeeping and without a Code ever being assigned twice.
+//
+// The mapping of each Code to its key can be persisted by marshaling the Registry into JSON (e.g. a file checked into
+// source control) and restored by unmarshaling it again, ensuring that a key is always assigned the same Code, even as
+// new keys are introduced.
+//
+// Codes and definitions can also be declared from the init function of any package (see Registry.Declare and
+// Registry.DeclareDefinition), allowing large codebases with distributed ownership to share a single Registry (e.g.
+// DefaultRegistry). Declarations are only registered once Registry.Finalize is called, in an order that is independent
+// of the order in which packages are initialized, after which the Registry can no longer be changed.
+//
+// A Registry is safe for concurrent use and its zero value is usable. A Registry must not be copied after first use.
+type Registry struct {
+	// Generator is the Generator whose Coder is used to construct and/or parse each Code within the Registry.
+	//
+	// If Generator is nil, DefaultGenerator will be used.
+	Generator *Generator
+	// codes maps each registered Code to its key, which is empty if the Code was allocated using Registry.NextCode.
+	codes map[Code]string
+	// declarations contains each declaration that is yet to be registered by Registry.Finalize.
+	declarations []registryDeclaration
+	// finalized is whether Registry.Finalize has been called, after which the Registry can no longer be changed.
+	finalized bool
+	// finalizeErr is the error returned by Registry.Finalize, if any, which is returned again by any subsequent call.
+	finalizeErr error
+	// keys maps each registered key to its Code.
+	keys map[string]Code
+	// maxValues maps each NS to the highest value of any Code registered within it.
+	maxValues map[NS]uint
+	// mu is used to guard all other unexported fields.
+	mu sync.Mutex
+}
+
+// registryDeclaration is a Code and/or Definition declared for a key that is yet to be registered by Registry.Finalize.
+type registryDeclaration struct {
+	// code is the declared Code, which is empty if def is present.
+	code Code
+	// def is the declared Definition, if any, whose Code is allocated within ns if empty.
+	def *Definition
+	// key is the key for which code and/or def were declared.
+	key string
+	// ns is the NS in which a Code is to be allocated for def, if needed.
+	ns NS
+}
+
+// ErrRegistry is returned when a Code cannot be registered or allocated by a Registry.
+var ErrRegistry = errors.New("invalid problem code registration")
+
+// DefaultRegistry is a global Registry that may be used to declare codes and definitions from the init function of any
+// package so that they can be finalized once by the main package. See Registry.Finalize for more information.
+//
+// DefaultRegistry is not used by DefaultGenerator unless explicitly assigned to Generator.Registry.
+var DefaultRegistry = &Registry{}
+
+var (
+	_ json.Marshaler   = (*Registry)(nil)
+	_ json.Unmarshaler = (*Registry)(nil)
+)
+
+// AssignCodes assigns a Code within the given NS to each Definition within defs that does not already have one, where
+// each Definition is identified by its key within defs.
+//
+// A key that has previously been registered (e.g. within a persisted mapping) is always assigned the same Code, while
+// any other key is allocated the next available Code (see Registry.NextCode). New keys are allocated in key order so
+// that the assignment is deterministic. A Definition that already has a Code is registered with its key as-is.
+//
+// An error wrapping ErrRegistry is returned if a Definition within defs is nil, a Code conflicts with that of another
+// key, or a Code cannot be allocated, in which case some definitions may have already been assigned codes.
+//
+// For example;
+//
+//	var reg Registry
+//	if err := json.Unmarshal(mapping, &reg); err != nil {
+//		log.Fatal(err)
+//	}
+//	if err := reg.AssignCodes("USER", map[string]*Definition{
+//		"UserNotFound": &UserNotFound,
+//		"UserLocked":   &UserLocked,
+//	}); err != nil {
+//		log.Fatal(err)
+//	}
+func (r *Registry) AssignCodes(ns NS, defs map[string]*Definition) error {
+	keys := make([]string, 0, len(defs))
+	for key := range defs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkFinalized(); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		def := defs[key]
+		if def == nil {
+			return fmt.Errorf("%w: definition is nil for key %q", ErrRegistry, key)
+		}
+		if def.Code == "" {
+			if code, ok := r.keys[key]; ok {
+				def.Code = code
+				continue
+			}
+			code, err := r.nextCode(ns)
+			if err != nil {
+				return err
+			}
+			def.Code = code
+		}
+		if err := r.register(key, def.Code); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Declare declares the given Code for the key provided, to be registered when Registry.Finalize is called. This allows
+// codes to be declared from the init function of any package without depending on the order in which packages are
+// initialized. Any conflict (e.g. code being declared for a different key) is only reported by Registry.Finalize.
+//
+// Panics if key is empty or the Registry has already been finalized, as either indicates a programming error.
+//
+// For example;
+//
+//	func init() {
+//		problem.DefaultRegistry.Declare("UserNotFound", "USER-404")
+//	}
+func (r *Registry) Declare(key string, code Code) {
+	r.declare(registryDeclaration{code: code, key: key})
+}
+
+// DeclareDefinition declares the given Definition for the key provided, to be registered when Registry.Finalize is
+// called. If the Definition does not have a Code when finalized, it is assigned one within the given NS in the same
+// manner as Registry.AssignCodes. This allows definitions to be declared from the init function of any package without
+// depending on the order in which packages are initialized. Any conflict (e.g. the Code of def being declared for a
+// different key) is only reported by Registry.Finalize.
+//
+// Since the Code of def may be assigned by Registry.Finalize, def should not be used to build a Problem until then.
+//
+// Panics if key is empty, def is nil, or the Registry has already been finalized, as each indicates a programming
+// error.
+//
+// For example;
+//
+//	var UserNotFound = problem.Definition{Type: http.NotFound}
+//
+//	func init() {
+//		problem.DefaultRegistry.DeclareDefinition("USER", "UserNotFound", &UserNotFound)
+//	}
+func (r *Registry) DeclareDefinition(ns NS, key string, def *Definition) {
+	if def == nil {
+		panic(fmt.Errorf("%w: definition is nil for key %q", ErrRegistry, key))
+	}
+	r.declare(registryDeclaration{def: def, key: key, ns: ns})
+}
+
+// Finalize registers each Code and Definition declared using Registry.Declare and Registry.DeclareDefinition and
+// locks the Registry so that it can no longer be changed, typically called once by the main package after all packages
+// have been initialized.
+//
+// Declarations are registered in key order, with any declared Code (incl. the Code of any declared Definition) being
+// registered before a Code is allocated for any Definition without one, so that the outcome never depends on the order
+// in which they were declared. Every conflict is reported rather than only the first, allowing ownership issues across
+// a large codebase to be resolved at once.
+//
+// An error wrapping ErrRegistry is returned for each declaration that could not be registered, joined together (see
+// errors.Join). Any subsequent call returns the same error without doing anything else.
+//
+// For example;
+//
+//	func main() {
+//		if err := problem.DefaultRegistry.Finalize(); err != nil {
+//			log.Fatal(err)
+//		}
+//		g := &problem.Generator{Registry: problem.DefaultRegistry}
+//		// ...
+//	}
+func (r *Registry) Finalize() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.finalized {
+		return r.finalizeErr
+	}
+	decls := r.declarations
+	sort.SliceStable(decls, func(i, j int) bool {
+		return decls[i].key < decls[j].key
+	})
+	var errs []error
+	defs := make(map[string]*Definition)
+	for _, decl := range decls {
+		code := decl.code
+		if decl.def != nil {
+			if existing, ok := defs[decl.key]; ok && existing != decl.def {
+				errs = append(errs, fmt.Errorf("%w: key %q is declared for more than one definition", ErrRegistry, decl.key))
+				continue
+			}
+			defs[decl.key] = decl.def
+			code = decl.def.Code
+		}
+		if code == "" {
+			continue
+		}
+		if err := r.register(decl.key, code); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, decl := range decls {
+		if decl.def == nil || decl.def.Code != "" || defs[decl.key] != decl.def {
+			continue
+		}
+		if code, ok := r.keys[decl.key]; ok {
+			decl.def.Code = code
+			continue
+		}
+		code, err := r.nextCode(decl.ns)
+		if err == nil {
+			err = r.register(decl.key, code)
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		decl.def.Code = code
+	}
+	r.declarations = nil
+	r.finalized = true
+	r.finalizeErr = errors.Join(errs...)
+	return r.finalizeErr
+}
+
+// Finalized returns whether Registry.Finalize has been called, after which the Registry can no longer be changed.
+func (r *Registry) Finalized() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.finalized
+}
+
+// Lookup returns the Code registered for the given key, if any.
+func (r *Registry) Lookup(key string) (Code, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	code, ok := r.keys[key]
+	return code, ok
+}
+
+// MarshalJSON marshals the mapping of each Code registered within the Registry to its key into a JSON object, sorted by
+// Code, which can be persisted and later restored using Registry.UnmarshalJSON.
+func (r *Registry) MarshalJSON() ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	codes := r.codes
+	if codes == nil {
+		codes = map[Code]string{}
+	}
+	return json.Marshal(codes)
+}
+
+// NextCode allocates and returns the next available Code within the given NS, which is the Code with a value one
+// higher than that of any Code already registered within ns, starting at one.
+//
+// The allocated Code is registered without a key so that it cannot be allocated again.
+//
+// An error is returned if the Code cannot be constructed (see Coder.Build).
+func (r *Registry) NextCode(ns NS) (Code, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.checkFinalized(); err != nil {
+		return "", err
+	}
+	code, err := r.nextCode(ns)
+	if err != nil {
+		return "", err
+	}
+	if err = r.register("", code); err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+// Register registers the given Code for the key provided, reserving it so that it cannot be allocated by
+// Registry.NextCode or Registry.AssignCodes. Registering the same Code for the same key more than once has no effect.
+//
+// An error wrapping ErrRegistry is returned if key or code is empty, code is already registered for a different key,
+// key is already registered with a different Code, or the Registry has been finalized.
+func (r *Registry) Register(key string, code Code) error {
+	if key == "" {
+		return fmt.Errorf("%w: key is empty", ErrRegistry)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.checkFinalized(); err != nil {
+		return err
+	}
+	return r.register(key, code)
+}
+
+// Registered returns whether the given Code is registered within the Registry, with or without a key.
+func (r *Registry) Registered(code Code) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.codes[code]
+	return ok
+}
+
+// UnmarshalJSON unmarshals the JSON object provided, mapping each Code to its key (see Registry.MarshalJSON), and
+// registers each Code within the Registry, replacing any existing registrations.
+//
+// An error is returned if unable to unmarshal data or a Code cannot be registered (see Registry.Register).
+func (r *Registry) UnmarshalJSON(data []byte) error {
+	var codes map[Code]string
+	if err := json.Unmarshal(data, &codes); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.checkFinalized(); err != nil {
+		return err
+	}
+	r.codes, r.keys, r.maxValues = nil, nil, nil
+	for code, key := range codes {
+		if err := r.register(key, code); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkFinalized returns an error wrapping ErrRegistry if the Registry has been finalized, otherwise nil.
+//
+// r.mu must be held by the caller.
+func (r *Registry) checkFinalized() error {
+	if r.finalized {
+		return fmt.Errorf("%w: registry is finalized", ErrRegistry)
+	}
+	return nil
+}
+
+// coder returns a Coder for the Generator of the Registry using the given NS.
+func (r *Registry) coder(ns NS) Coder {
+	gen := r.Generator
+	if gen == nil {
+		gen = DefaultGenerator
+	}
+	return gen.Coder(ns)
+}
+
+// declare records the given declaration to be registered by Registry.Finalize.
+//
+// Panics if the key of decl is empty or the Registry has already been finalized.
+func (r *Registry) declare(decl registryDeclaration) {
+	if decl.key == "" {
+		panic(fmt.Errorf("%w: key is empty", ErrRegistry))
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.checkFinalized(); err != nil {
+		panic(err)
+	}
+	r.declarations = append(r.declarations, decl)
+}
+
+// pendingDeclarations returns the number of declarations that are yet to be registered by Registry.Finalize.
+func (r *Registry) pendingDeclarations() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.declarations)
+}
+
+// nextCode returns the next available Code within the given NS without registering it.
+//
+// r.mu must be held by the caller.
+func (r *Registry) nextCode(ns NS) (Code, error) {
+	code, err := r.coder(ns).Build(r.maxValues[ns] + 1)
+	if err != nil {
+		return "", fmt.Errorf("%w: cannot allocate code for namespace %q: %w", ErrRegistry, ns, err)
+	}
+	if _, exists := r.codes[code]; exists {
+		// Possible when Generator.CodeValueLen pads values such that the next value has already been registered
+		return "", fmt.Errorf("%w: next code for namespace %q is already registered: %q", ErrRegistry, ns, code)
+	}
+	return code, nil
+}
+
+// register registers the given Code for the key provided, which may be empty if the Code was allocated using
+// Registry.NextCode.
+//
+// r.mu must be held by the caller.
+func (r *Registry) register(key string, code Code) error {
+	if code == "" {
+		return fmt.Errorf("%w: code is empty for key %q", ErrRegistry, key)
+	}
+	if existing, ok := r.codes[code]; ok && existing != key {
+		return fmt.Errorf("%w: code %q is already registered for key %q", ErrRegistry, code, existing)
+	}
+	if key != "" {
+		if existing, ok := r.keys[key]; ok && existing != code {
+			return fmt.Errorf("%w: key %q is already registered with code %q", ErrRegistry, key, existing)
+		}
+	}
+	if r.codes == nil {
+		r.codes = make(map[Code]string)
+		r.keys = make(map[string]Code)
+		r.maxValues = make(map[NS]uint)
+	}
+	r.codes[code] = key
+	if key != "" {
+		r.keys[key] = code
+	}
+	if parsed, err := r.coder("").Parse(code); err == nil && parsed.Value > r.maxValues[parsed.NS] {
+		r.maxValues[parsed.NS] = parsed.Value
+	}
+	return nil
+}
@@ -0,0 +1,241 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package problem
+
+import (
+	"math"
+	"math/rand"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BackoffPolicy contains the rules used to compute how long a client should wait before retrying a request that
+// resulted in a Problem.
+//
+// All fields are optional with default behaviour clearly documented. As such, the zero value is usable.
+type BackoffPolicy struct {
+	// Base is the backoff used for the first retry attempt, which then grows exponentially for each subsequent attempt
+	// using Multiplier.
+	//
+	// If zero or less, DefaultBackoffBase will be used.
+	Base time.Duration
+	// Jitter is whether "full jitter" is applied to a computed backoff, where a random duration between zero and the
+	// computed backoff is used instead. This helps to prevent many clients from retrying at the same time.
+	//
+	// Jitter is never applied to a retry hint contained within a Problem.
+	Jitter bool
+	// Max is the maximum backoff that can be computed.
+	//
+	// If zero or less, DefaultBackoffMax will be used. Max is never applied to a retry hint contained within a Problem.
+	Max time.Duration
+	// MaxAttempts is the maximum number of retry attempts after which a Problem is no longer considered retryable.
+	//
+	// If zero or less, the number of retry attempts is unlimited.
+	MaxAttempts int
+	// Multiplier is the factor by which the backoff grows for each subsequent retry attempt.
+	//
+	// If less than one, DefaultBackoffMultiplier will be used.
+	Multiplier float64
+	// RetryableStatuses contains the status codes of a Problem that are considered retryable, unless explicitly
+	// indicated otherwise by the Problem.
+	//
+	// If empty, the status codes returned by DefaultRetryableStatuses will be used.
+	RetryableStatuses []int
+}
+
+const (
+	// DefaultBackoffBase is the default backoff used for the first retry attempt by a BackoffPolicy.
+	DefaultBackoffBase = 100 * time.Millisecond
+	// DefaultBackoffMax is the default maximum backoff that can be computed by a BackoffPolicy.
+	DefaultBackoffMax = 30 * time.Second
+	// DefaultBackoffMultiplier is the default factor by which the backoff grows for each subsequent retry attempt by a
+	// BackoffPolicy.
+	DefaultBackoffMultiplier float64 = 2
+
+	// ExtensionRetryAfter is the key of the extension that may contain a hint for how long a client should wait before
+	// retrying a request that resulted in a Problem.
+	//
+	// Its value can be a number of seconds, a time.Duration, a time.Time, or a string containing either a number of
+	// seconds or an HTTP-date, mirroring the Retry-After header.
+	ExtensionRetryAfter = "retryAfter"
+	// ExtensionRetryable is the key of the extension that may contain a boolean indicating whether a request that
+	// resulted in a Problem can be retried, taking precedence over any classification based on Problem.Status.
+	ExtensionRetryable = "retryable"
+)
+
+// retryAfterHeader is the name of the HTTP header used to indicate how long a client should wait before retrying a
+// request.
+const retryAfterHeader = "Retry-After"
+
+// defaultRetryableStatuses contains the status codes of a Problem that are considered retryable by a BackoffPolicy by
+// default.
+var defaultRetryableStatuses = []int{
+	http.StatusRequestTimeout,
+	http.StatusTooEarly,
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// ComputeBackoff returns how long a client should wait before making the given retry attempt of a request that
+// resulted in the Problem provided.
+//
+// attempt is the number of the retry attempt, starting at one, with any lower value being treated as one.
+//
+// If prob contains a retry hint (see ExtensionRetryAfter) that has not already passed, it is returned as-is since the
+// generator of the Problem knows best. Otherwise, an exponential backoff is computed based on the BackoffPolicy.
+//
+// Zero is returned if prob is nil, not retryable, or BackoffPolicy.MaxAttempts has been exceeded. A Problem is
+// considered retryable if it contains a retry hint or its status is within BackoffPolicy.RetryableStatuses, unless it
+// explicitly indicates otherwise (see ExtensionRetryable).
+func (bp BackoffPolicy) ComputeBackoff(prob *Problem, attempt int) time.Duration {
+	if prob == nil {
+		return 0
+	}
+	if attempt < 1 {
+		attempt = 1
+	}
+	if bp.MaxAttempts > 0 && attempt > bp.MaxAttempts {
+		return 0
+	}
+	hint, hasHint := retryAfterHint(prob)
+	if retryable, found := prob.Extension(ExtensionRetryable); found {
+		if b, ok := retryable.(bool); ok && !b {
+			return 0
+		}
+	} else if !hasHint && !slices.Contains(bp.retryableStatuses(), prob.Status) {
+		return 0
+	}
+	if hasHint && hint > 0 {
+		return hint
+	}
+
+	base := bp.Base
+	if base <= 0 {
+		base = DefaultBackoffBase
+	}
+	maxBackoff := bp.Max
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultBackoffMax
+	}
+	multiplier := bp.Multiplier
+	if multiplier < 1 {
+		multiplier = DefaultBackoffMultiplier
+	}
+
+	backoff := maxBackoff
+	if f := float64(base) * math.Pow(multiplier, float64(attempt-1)); f < float64(maxBackoff) {
+		backoff = time.Duration(f)
+	}
+	if bp.Jitter && backoff > 0 {
+		backoff = time.Duration(rand.Int63n(int64(backoff) + 1))
+	}
+	return backoff
+}
+
+// retryableStatuses returns BackoffPolicy.RetryableStatuses if not empty, otherwise the default retryable statuses (see
+// DefaultRetryableStatuses).
+func (bp BackoffPolicy) retryableStatuses() []int {
+	if len(bp.RetryableStatuses) > 0 {
+		return bp.RetryableStatuses
+	}
+	return defaultRetryableStatuses
+}
+
+// ComputeBackoff is a convenient shorthand for calling BackoffPolicy.ComputeBackoff on the zero value of
+// BackoffPolicy.
+func ComputeBackoff(prob *Problem, attempt int) time.Duration {
+	return BackoffPolicy{}.ComputeBackoff(prob, attempt)
+}
+
+// DefaultRetryableStatuses returns the status codes of a Problem that are considered retryable by a BackoffPolicy by
+// default.
+//
+// A new slice is returned on each call, so it can be safely modified (e.g. to be extended for use within
+// BackoffPolicy.RetryableStatuses).
+func DefaultRetryableStatuses() []int {
+	return slices.Clone(defaultRetryableStatuses)
+}
+
+// addRetryAfterHeader adds a Retry-After header to the given http.Header containing the number of seconds, rounded up,
+// of the retry hint contained within the Problem provided (see ExtensionRetryAfter), if it has one and the header is
+// not already present.
+func addRetryAfterHeader(header http.Header, prob *Problem) {
+	if header.Get(retryAfterHeader) != "" {
+		return
+	}
+	if hint, found := retryAfterHint(prob); found {
+		header.Set(retryAfterHeader, strconv.Itoa(retryAfterSeconds(hint)))
+	}
+}
+
+// retryAfterHint returns the retry hint contained within the given Problem (see ExtensionRetryAfter), if present and
+// valid.
+//
+// A retry hint that has already passed is considered valid but is returned as zero.
+func retryAfterHint(prob *Problem) (time.Duration, bool) {
+	v, found := prob.Extension(ExtensionRetryAfter)
+	if !found {
+		return 0, false
+	}
+	var d time.Duration
+	switch hint := v.(type) {
+	case time.Duration:
+		d = hint
+	case time.Time:
+		d = time.Until(hint)
+	case float64:
+		d = time.Duration(hint * float64(time.Second))
+	case int:
+		d = time.Duration(hint) * time.Second
+	case int64:
+		d = time.Duration(hint) * time.Second
+	case string:
+		hint = strings.TrimSpace(hint)
+		if secs, err := strconv.ParseInt(hint, 10, 64); err == nil {
+			d = time.Duration(secs) * time.Second
+		} else if t, err := http.ParseTime(hint); err == nil {
+			d = time.Until(t)
+		} else {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+	if d < 0 {
+		d = 0
+	}
+	return d, true
+}
+
+// retryAfterSeconds returns the given duration as a number of seconds, rounded up, with any negative duration being
+// treated as zero.
+func retryAfterSeconds(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+	return int((d + time.Second - 1) / time.Second)
+}
@@ -0,0 +1,51 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package problem
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func Test_BackoffPolicy_ComputeBackoff_Jitter(t *testing.T) {
+	policy := BackoffPolicy{Base: time.Second, Jitter: true, Max: time.Minute}
+	prob := &Problem{Status: http.StatusServiceUnavailable}
+
+	var belowBase bool
+	for i := 0; i < 1000; i++ {
+		backoff := policy.ComputeBackoff(prob, 2)
+		assert.GreaterOrEqual(t, backoff, time.Duration(0))
+		assert.LessOrEqual(t, backoff, 2*time.Second)
+		belowBase = belowBase || backoff < time.Second
+	}
+	assert.True(t, belowBase, "expected full jitter to produce backoffs below Base")
+}
+
+func Test_DefaultRetryableStatuses(t *testing.T) {
+	statuses := DefaultRetryableStatuses()
+	assert.Contains(t, statuses, http.StatusServiceUnavailable)
+
+	statuses[0] = http.StatusTeapot
+
+	assert.NotContains(t, DefaultRetryableStatuses(), http.StatusTeapot)
+}
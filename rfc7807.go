@@ -0,0 +1,105 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package problem
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/url"
+	"sort"
+)
+
+// ErrRFC7807 is returned when a Problem does not conform to RFC 7807; https://datatracker.ietf.org/doc/html/rfc7807.
+var ErrRFC7807 = errors.New("problem does not conform to RFC 7807")
+
+// ValidateRFC7807 returns an error wrapping ErrRFC7807 for each way in which the given Problem does not conform to RFC
+// 7807, otherwise nil. This is intended for clients that are pinned to RFC 7807, which RFC 9457 obsoletes.
+//
+// A Problem conforms to RFC 7807 if:
+//
+//   - Problem.Type and Problem.Instance (where present) are valid URI references
+//   - Problem.Status (where present) is a valid HTTP status code
+//   - The name of each extension starts with a letter, is composed only of letters, digits, and underscores, and is at
+//     least three characters long
+//
+// For example;
+//
+//	ValidateRFC7807(&Problem{Status: 404, Title: "Not Found", Type: "about:blank"})  // nil
+//	ValidateRFC7807(&Problem{Extensions: Extensions{"x-id": 1}, Status: 1000})     // error
+func ValidateRFC7807(prob *Problem) error {
+	if prob == nil {
+		return nil
+	}
+	var errs []error
+	if _, err := url.Parse(prob.Type); err != nil {
+		errs = append(errs, fmt.Errorf("%w: type is not a URI reference: %q", ErrRFC7807, prob.Type))
+	}
+	if prob.Instance != "" {
+		if _, err := url.Parse(prob.Instance); err != nil {
+			errs = append(errs, fmt.Errorf("%w: instance is not a URI reference: %q", ErrRFC7807, prob.Instance))
+		}
+	}
+	if prob.Status != 0 && (prob.Status < 100 || prob.Status > 599) {
+		errs = append(errs, fmt.Errorf("%w: status is not a valid HTTP status code: %d", ErrRFC7807, prob.Status))
+	}
+	keys := make([]string, 0, len(prob.Extensions))
+	for key := range prob.Extensions {
+		if !isRFC7807ExtensionName(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		errs = append(errs, fmt.Errorf("%w: extension name is not recommended: %q", ErrRFC7807, key))
+	}
+	return errors.Join(errs...)
+}
+
+// contentTypeRFC7807 returns the given content/media type without any parameters (e.g. charset) if
+// Generator.RFC7807Mode is enabled, since the media types registered by RFC 7807 define no parameters. Otherwise,
+// contentType is returned as-is.
+func (g *Generator) contentTypeRFC7807(contentType string) string {
+	if !g.RFC7807Mode {
+		return contentType
+	}
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		return mediaType
+	}
+	return contentType
+}
+
+// isRFC7807ExtensionName returns whether the given extension name conforms to the recommendation of RFC 7807 (i.e.
+// starts with a letter, is composed only of letters, digits, and underscores, and is at least three characters long).
+func isRFC7807ExtensionName(name string) bool {
+	if len(name) < 3 {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case i > 0 && (r >= '0' && r <= '9' || r == '_'):
+		default:
+			return false
+		}
+	}
+	return true
+}
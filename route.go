@@ -0,0 +1,129 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package problem
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+type (
+	// RouteCatalog maps route patterns (e.g. those registered with an http.ServeMux) to the Definitions that the
+	// handlers for those routes are allowed to write as problems.
+	//
+	// A RouteCatalog can be used to document the problems that each route can produce and, using
+	// RouteCatalog.Middleware, validate during development that handlers only write declared problems, catching any
+	// undocumented error responses before release.
+	RouteCatalog map[string][]Definition
+
+	// RouteViolationHandler is a function used to handle a Problem being written for an HTTP request whose route pattern
+	// has not declared the Problem within a RouteCatalog.
+	//
+	// A RouteViolationHandler is called before the HTTP response is written.
+	RouteViolationHandler func(req *http.Request, pattern string, prob *Problem)
+
+	// routeValidation contains the information required to validate a Problem being written for an HTTP request.
+	routeValidation struct {
+		// catalog is the RouteCatalog containing the declared Definitions.
+		catalog RouteCatalog
+		// onViolation is the RouteViolationHandler to be called with any Problem that has not been declared.
+		onViolation RouteViolationHandler
+		// pattern is the route pattern matched for the HTTP request.
+		pattern string
+	}
+)
+
+// Declares returns whether the given Problem has been declared by the RouteCatalog for the route pattern provided.
+//
+// A Problem is considered to match a declared Definition if Definition.Code is not empty and equal to Problem.Code,
+// otherwise if Definition.Type.Status is equal to Problem.Status. No problems are declared for a route pattern that is
+// not within the RouteCatalog.
+func (rc RouteCatalog) Declares(pattern string, prob *Problem) bool {
+	if prob == nil {
+		return false
+	}
+	for _, def := range rc[pattern] {
+		if def.Code != "" {
+			if def.Code == prob.Code {
+				return true
+			}
+		} else if def.Type.Status == prob.Status {
+			return true
+		}
+	}
+	return false
+}
+
+// Middleware returns a middleware function that validates that any Problem written by the Generator (e.g. via
+// Generator.WriteError or Generator.WriteProblem) for an HTTP request has been declared by the RouteCatalog for the
+// route pattern that mux matches for the request. This includes any Problem written by the panic recovery of
+// MiddlewareUsing, provided that it is wrapped by the returned middleware.
+//
+// If a Problem has not been declared, onViolation is called before the HTTP response is written. If onViolation is
+// nil, the default behaviour is to panic, which is intended to be as loud as possible. As such, the returned
+// middleware is intended for use in development and testing rather than in production.
+//
+// For example;
+//
+//	mux := http.NewServeMux()
+//	mux.HandleFunc("/users/", getUser)
+//	catalog := RouteCatalog{
+//		"/users/": {http.NotFoundDefinition, http.InternalServerDefinition},
+//	}
+//	var handler http.Handler = mux
+//	if devMode {
+//		handler = catalog.Middleware(mux, nil)(handler)
+//	}
+func (rc RouteCatalog) Middleware(mux *http.ServeMux, onViolation RouteViolationHandler) func(http.Handler) http.Handler {
+	if onViolation == nil {
+		onViolation = panicRouteViolation
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			_, pattern := mux.Handler(req)
+			rv := &routeValidation{
+				catalog:     rc,
+				onViolation: onViolation,
+				pattern:     pattern,
+			}
+			next.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), contextKeyRouteValidation, rv)))
+		})
+	}
+}
+
+// panicRouteViolation is the default RouteViolationHandler used by RouteCatalog.Middleware, which panics with an error
+// describing the undeclared Problem.
+func panicRouteViolation(_ *http.Request, pattern string, prob *Problem) {
+	panic(fmt.Errorf("undeclared problem written for route %q: %s", pattern, prob))
+}
+
+// validateRoute validates that the given Problem has been declared for the HTTP request provided, but only if a
+// RouteCatalog has been bound to the context.Context using RouteCatalog.Middleware.
+func validateRoute(ctx context.Context, req *http.Request, prob *Problem) {
+	rv, ok := ctx.Value(contextKeyRouteValidation).(*routeValidation)
+	if !ok || rv == nil {
+		return
+	}
+	if !rv.catalog.Declares(rv.pattern, prob) {
+		rv.onViolation(req, rv.pattern, prob)
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package problem
+
+import "context"
+
+type (
+	// Router evaluates an ordered set of rules against each Problem built by a Generator, applying the actions of each
+	// rule that the Problem matches (e.g. overriding its LogLevel, sending a notification, removing sensitive fields,
+	// or mapping it to another Definition). See Generator.Router for more information.
+	//
+	// A Router consolidates cross-cutting concerns into a single composable pipeline that can be declared in one place.
+	//
+	// For example;
+	//
+	//	router := &Router{Rules: []RouterRule{
+	//		{
+	//			Matchers: []Matcher{HasStatus(http.StatusInternalServerError, OperatorGreaterThanOrEqual)},
+	//			Actions: []RouterAction{
+	//				LogLevelAction(LogLevelError),
+	//				NotifyAction(WebhookNotifier("https://hooks.example.void/problems")),
+	//			},
+	//		},
+	//		{
+	//			Matchers: []Matcher{HasCodeNS("DB")},
+	//			Actions:  []RouterAction{SanitizeAction(FieldMask{Exclude: []string{"detail"}})},
+	//			Final:    true,
+	//		},
+	//	}}
+	Router struct {
+		// Rules contains each RouterRule to be evaluated, in order, against a Problem.
+		//
+		// If empty, a Problem is never changed by the Router.
+		Rules []RouterRule
+	}
+
+	// RouterAction is a function used by a Router to act upon a Problem that matches a RouterRule, returning the Problem
+	// to be passed to any subsequent RouterAction. This may be prob itself, after it has been modified, or another
+	// Problem entirely.
+	//
+	// A RouterAction is never passed a nil pointer to a Problem and must never return a nil pointer to a Problem.
+	RouterAction func(ctx context.Context, gen *Generator, prob *Problem) *Problem
+
+	// RouterRule contains the actions to be applied to any Problem that matches all the matchers provided.
+	RouterRule struct {
+		// Actions contains each RouterAction to be applied, in order, to any Problem matching Matchers.
+		//
+		// If empty, a matching Problem is not changed but Final is still honored.
+		Actions []RouterAction
+		// Final is whether no further rules are to be evaluated once a Problem has matched this RouterRule.
+		//
+		// If false, the Problem returned by the last RouterAction continues to be evaluated against all subsequent
+		// rules.
+		Final bool
+		// Matchers contains each Matcher that a Problem must match in order for Actions to be applied. A MatcherExpr can
+		// be used to declare matchers within configuration.
+		//
+		// If empty, all problems are matched.
+		Matchers []Matcher
+	}
+)
+
+// Route evaluates each RouterRule within the Router against the given Problem, in order, and returns the Problem
+// returned by the last RouterAction that was applied. If no RouterRule is matched or prob is nil, prob is returned
+// as-is.
+//
+// Route is called automatically whenever a Problem is built by a Generator whose Generator.Router is the Router.
+func (r *Router) Route(ctx context.Context, gen *Generator, prob *Problem) *Problem {
+	if r == nil || prob == nil {
+		return prob
+	}
+	if gen == nil {
+		gen = GetGenerator(ctx)
+	}
+	for _, rule := range r.Rules {
+		if !Match(prob, rule.Matchers...) {
+			continue
+		}
+		for _, action := range rule.Actions {
+			if action != nil {
+				prob = action(ctx, gen, prob)
+			}
+		}
+		if rule.Final {
+			break
+		}
+	}
+	return prob
+}
+
+// LogLevelAction returns a RouterAction that overrides the LogLevel of a Problem with the given LogLevel.
+func LogLevelAction(level LogLevel) RouterAction {
+	return func(_ context.Context, _ *Generator, prob *Problem) *Problem {
+		prob.logInfo.Level = level
+		return prob
+	}
+}
+
+// MapDefinitionAction returns a RouterAction that replaces a Problem with one built from the given Definition, which
+// wraps the original Problem. As such, any fields of the original Problem that are unwrapped by Generator.Unwrapper
+// (e.g. captured stack trace, generated "UUID") are retained and both errors.Is and errors.As can still find it.
+//
+// The replacement Problem is not itself evaluated by Generator.Router again, avoiding any infinite recursion.
+//
+// For example;
+//
+//	RouterRule{
+//		Matchers: []Matcher{HasCodeNS("LEGACY")},
+//		Actions:  []RouterAction{MapDefinitionAction(http.InternalServerDefinition)},
+//	}
+func MapDefinitionAction(def Definition) RouterAction {
+	return func(ctx context.Context, gen *Generator, prob *Problem) *Problem {
+		b := gen.BuildContext(ctx).Definition(def).Wrap(prob)
+		b.routed = true
+		return b.build(0)
+	}
+}
+
+// NotifyAction returns a RouterAction that passes a Problem to the given Notifier. The Notifier is invoked
+// asynchronously in the same way as those within Generator.Notifiers, however, this occurs when the Problem is built
+// rather than when it is written as an HTTP response.
+//
+// If notifier is nil, the returned RouterAction does nothing.
+func NotifyAction(notifier Notifier) RouterAction {
+	return func(ctx context.Context, gen *Generator, prob *Problem) *Problem {
+		if notifier != nil {
+			gen.notify(context.WithoutCancel(ctx), notifier, prob)
+		}
+		return prob
+	}
+}
+
+// SanitizeAction returns a RouterAction that removes any fields and extensions of a Problem that are excluded by the
+// given FieldMask (e.g. sensitive information that must never leave the service).
+//
+// Unlike WriteOptions.FieldMask, the fields and extensions are removed from the Problem itself rather than only when it
+// is written as an HTTP response.
+func SanitizeAction(mask FieldMask) RouterAction {
+	return func(_ context.Context, _ *Generator, prob *Problem) *Problem {
+		return mask.apply(prob)
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package problem
+
+import "net/http"
+
+type (
+	// SLOClass is the classification of a Problem in terms of its impact on a service level objective (SLO) and its
+	// error budget. See Generator.SLOClassifier for more information.
+	//
+	// While SLOClassClientError and SLOClassServerFault are provided, an SLOClassifier may return any SLOClass that is
+	// meaningful to its consumers (e.g. "dependency_fault").
+	SLOClass string
+
+	// SLOClassifier is a function used by a Generator to classify a Problem in terms of its impact on a service level
+	// objective (SLO) and its error budget. See Generator.SLOClassifier for more information.
+	//
+	// If the function returns an empty SLOClass, the Problem is unclassified.
+	SLOClassifier func(prob *Problem) SLOClass
+)
+
+const (
+	// SLOClassClientError is the SLOClass of a Problem that is an expected result of a client error (e.g. invalid
+	// input) and so does not burn any error budget.
+	SLOClassClientError SLOClass = "client_error"
+	// SLOClassServerFault is the SLOClass of a Problem that is the result of a fault on the server and so burns error
+	// budget.
+	SLOClassServerFault SLOClass = "server_fault"
+)
+
+// StatusSLOClassifier is an SLOClassifier that classifies a Problem based solely on its status, where any status of 500
+// or greater is considered SLOClassServerFault and any other status SLOClassClientError.
+//
+// It is not used by default and so must be assigned to Generator.SLOClassifier explicitly.
+func StatusSLOClassifier(prob *Problem) SLOClass {
+	if prob.Status >= http.StatusInternalServerError {
+		return SLOClassServerFault
+	}
+	return SLOClassClientError
+}
+
+// SLOClass returns the SLOClass of the Problem, as classified by the Generator.SLOClassifier of the Generator used to
+// build it, if any.
+//
+// An empty SLOClass is returned if the Problem is unclassified.
+func (p *Problem) SLOClass() SLOClass {
+	if p == nil {
+		return ""
+	}
+	return p.logInfo.SLOClass
+}
+
+// classifySLO returns the SLOClass of the given Problem using Generator.SLOClassifier, if present. Otherwise, an empty
+// SLOClass is returned.
+func (g *Generator) classifySLO(prob *Problem) SLOClass {
+	if g.SLOClassifier == nil {
+		return ""
+	}
+	return g.SLOClassifier(prob)
+}
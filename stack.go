@@ -0,0 +1,197 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package problem
+
+import (
+	"fmt"
+	"github.com/neocotic/go-problem/internal/buffer"
+	"github.com/neocotic/go-problem/internal/stack"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+type (
+	// StackCaptureMode controls when a stack trace that is captured while building a Problem is formatted into its
+	// string representation.
+	StackCaptureMode uint8
+
+	// StackFormatter is a function used to format the frames of a captured stack trace into its string representation
+	// (i.e. Problem.Stack and LogInfo.Stack). frames is ordered from the innermost frame (i.e. the caller that captured
+	// the stack trace) outwards and excludes the final runtime.main/runtime.goexit frame.
+	StackFormatter func(frames []runtime.Frame) string
+
+	// StackFrameFilter is a function used to decide whether a frame of a captured stack trace is to be retained,
+	// returning false only if the frame is to be dropped. See Generator.StackFrameFilter for more information.
+	StackFrameFilter func(frame runtime.Frame) bool
+
+	// lazyStack is a captured stack trace that is only formatted when first needed.
+	lazyStack struct {
+		// format is the function used to filter and format the frames of the stack trace.
+		format func(frames []runtime.Frame) string
+		// once is used to ensure that the stack trace is only formatted once.
+		once sync.Once
+		// pcs contains the program counters of the captured stack trace, which are released once formatted.
+		pcs []uintptr
+		// value is the formatted stack trace.
+		value string
+	}
+)
+
+const (
+	// StackCaptureEager formats a stack trace as soon as it is captured.
+	StackCaptureEager StackCaptureMode = iota
+	// StackCaptureLazy only captures the program counters of a stack trace that is visible only within logs (i.e. where
+	// the stack Flag contains FlagLog but not FlagField), deferring the cost of resolving and formatting its frames until
+	// the stack trace is first accessed (e.g. when the Problem is logged). A stack trace that is visible via
+	// Problem.Stack is always formatted eagerly.
+	StackCaptureLazy
+)
+
+// ExcludePackagesStackFrameFilter returns a StackFrameFilter that drops any frame whose function belongs to any of the
+// given packages, identified by their import paths (e.g. "net/http"), including any of their sub-packages. This is
+// useful for dropping framework and middleware frames that add noise to a stack trace.
+//
+// For example;
+//
+//	g := &Generator{StackFrameFilter: ExcludePackagesStackFrameFilter("net/http", "github.com/go-chi/chi/v5")}
+func ExcludePackagesStackFrameFilter(pkgs ...string) StackFrameFilter {
+	return func(frame runtime.Frame) bool {
+		for _, pkg := range pkgs {
+			if rest, ok := strings.CutPrefix(frame.Function, pkg); ok && (strings.HasPrefix(rest, ".") || strings.HasPrefix(rest, "/")) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// RuntimeStackFormatter returns a StackFormatter that formats a stack trace in the same format as the frames within a
+// trace produced by runtime.Stack (or debug.Stack), which can be parsed by tools that expect that format.
+//
+// Since only the frames are captured, the goroutine header is omitted and argument values are always elided (i.e.
+// "(...)").
+//
+// For example;
+//
+//	main.handle(...)
+//		/app/main.go:42 +0x1d
+//	main.main(...)
+//		/app/main.go:12 +0x25
+func RuntimeStackFormatter() StackFormatter {
+	return func(frames []runtime.Frame) string {
+		var sb strings.Builder
+		for i, frame := range frames {
+			if i > 0 {
+				sb.WriteByte('\n')
+			}
+			fmt.Fprintf(&sb, "%s(...)\n\t%s:%d", frame.Function, frame.File, frame.Line)
+			if frame.PC >= frame.Entry && frame.Entry != 0 {
+				fmt.Fprintf(&sb, " +0x%x", frame.PC-frame.Entry)
+			}
+		}
+		return sb.String()
+	}
+}
+
+// ZapStackFormatter returns a StackFormatter that formats a stack trace in the same format as that used by zap, which
+// is the format used when Generator.StackFormatter is nil.
+//
+// For example;
+//
+//	main.handle
+//		/app/main.go:42
+//	main.main
+//		/app/main.go:12
+func ZapStackFormatter() StackFormatter {
+	return func(frames []runtime.Frame) string {
+		buf := buffer.Get()
+		defer buf.Free()
+
+		f := stack.NewFormatter(buf)
+		for _, frame := range frames {
+			f.FormatFrame(frame)
+		}
+		return buf.String()
+	}
+}
+
+// captureStack captures the program counters of the current stack trace and returns a lazyStack that filters and
+// formats them in the same way as takeStack when first needed.
+//
+// skip is the number of frames before recording the stack trace with zero identifying the caller of captureStack.
+func (g *Generator) captureStack(skip int) *lazyStack {
+	return &lazyStack{format: g.stackFramesFormatter(), pcs: stack.Callers(skip + 1)}
+}
+
+// stackFramesFormatter returns a function that drops any frames rejected by Generator.StackFrameFilter, where present,
+// and any frames exceeding Generator.StackMaxDepth, where positive, before formatting the remaining frames using
+// Generator.StackFormatter, where present. Otherwise, the frames are formatted using ZapStackFormatter.
+func (g *Generator) stackFramesFormatter() func(frames []runtime.Frame) string {
+	filter, formatter, maxDepth := g.StackFrameFilter, g.StackFormatter, g.StackMaxDepth
+	if formatter == nil {
+		formatter = ZapStackFormatter()
+	}
+	return func(frames []runtime.Frame) string {
+		if filter != nil {
+			retained := frames[:0]
+			for _, frame := range frames {
+				if filter(frame) {
+					retained = append(retained, frame)
+				}
+			}
+			frames = retained
+		}
+		if maxDepth > 0 && len(frames) > maxDepth {
+			frames = frames[:maxDepth]
+		}
+		return formatter(frames)
+	}
+}
+
+// takeStack captures the current stack trace and returns its string representation, filtered using
+// Generator.StackFrameFilter and Generator.StackMaxDepth, where present, and formatted using Generator.StackFormatter,
+// where present. Otherwise, the stack trace is formatted in the same way as ZapStackFormatter, albeit more efficiently.
+//
+// skip is the number of frames before recording the stack trace with zero identifying the caller of takeStack.
+func (g *Generator) takeStack(skip int) string {
+	if g.StackFormatter == nil && g.StackFrameFilter == nil && g.StackMaxDepth <= 0 {
+		return stack.Take(skip + 1)
+	}
+	return g.stackFramesFormatter()(stack.Frames(stack.Callers(skip + 1)))
+}
+
+// stack returns the stack trace within the LogInfo, formatting any lazily captured stack trace if needed.
+func (li LogInfo) stack() string {
+	if li.Stack == "" && li.lazyStack != nil {
+		return li.lazyStack.String()
+	}
+	return li.Stack
+}
+
+// String returns the formatted stack trace, formatting it on the first call.
+func (ls *lazyStack) String() string {
+	ls.once.Do(func() {
+		ls.value = ls.format(stack.Frames(ls.pcs))
+		ls.pcs = nil
+	})
+	return ls.value
+}
@@ -0,0 +1,49 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package problem
+
+import "time"
+
+// Clock is a function used by a Generator to return the current time, which is used to generate the timestamp of a
+// Problem. See Generator.Clock for more information.
+type Clock func() time.Time
+
+// SystemClock returns a Clock that returns the current local time using time.Now and is used by DefaultGenerator.
+func SystemClock() Clock {
+	return time.Now
+}
+
+// UTCClock returns a Clock that returns the current time in UTC.
+func UTCClock() Clock {
+	return func() time.Time {
+		return time.Now().UTC()
+	}
+}
+
+// now returns the current time using Generator.Clock, where possible.
+//
+// If Generator.Clock is nil, time.Now is used.
+func (g *Generator) now() time.Time {
+	if g.Clock == nil {
+		return time.Now()
+	}
+	return g.Clock()
+}
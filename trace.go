@@ -0,0 +1,115 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package problem
+
+import "maps"
+
+type (
+	// BuildSource identifies the source from which the value of a Problem field was taken when it was built. See
+	// Generator.TraceBuild for more information.
+	BuildSource string
+
+	// BuildStep describes the outcome of resolving a single field when building a Problem. See Generator.TraceBuild for
+	// more information.
+	BuildStep struct {
+		// Field is the Problem field that was resolved.
+		Field Field
+		// Source is the source from which Value was taken, which is empty if the field has no value.
+		Source BuildSource
+		// Value is the resolved value of the field.
+		//
+		// For FieldSpanID, FieldStack, FieldTimestamp, FieldTraceID, and FieldUUID, Value also reflects any span ID,
+		// stack trace, timestamp, trace ID, or "UUID" that is only visible within logs.
+		Value any
+	}
+
+	// BuildTracer is a function used to observe each BuildStep when building a Problem. See Generator.TraceBuild for
+	// more information.
+	BuildTracer func(step BuildStep)
+)
+
+const (
+	// BuildSourceDefault indicates that a value was a built-in default (e.g. DefaultTitle or DefaultTypeURI) as no
+	// other source provided one.
+	BuildSourceDefault BuildSource = "default"
+	// BuildSourceDefinition indicates that a value was derived from a Definition or its Type (e.g. via FromDefinition,
+	// including any translation keys).
+	BuildSourceDefinition BuildSource = "definition"
+	// BuildSourceExplicit indicates that a value was explicitly provided (e.g. via Builder.Title or WithTitle).
+	BuildSourceExplicit BuildSource = "explicit"
+	// BuildSourceGenerator indicates that a value was provided or derived by the Generator (e.g.
+	// Generator.StatusTitles, a captured stack trace, or a generated "UUID").
+	BuildSourceGenerator BuildSource = "generator"
+	// BuildSourceWrapped indicates that a value was unwrapped from an error (e.g. via Builder.Wrap or Wrap).
+	BuildSourceWrapped BuildSource = "wrapped"
+)
+
+// Provenance returns the BuildSource of each field of the Problem, describing which source (e.g. explicit, wrapped,
+// definition, or default) the value of the field was taken from when it was built, which can be useful for tests and
+// debug endpoints. Fields without a value are omitted.
+//
+// Provenance is only recorded if Generator.RecordProvenance was enabled when the Problem was built, otherwise nil is
+// returned. The returned map can be safely modified.
+//
+// For example;
+//
+//	g := &Generator{RecordProvenance: true}
+//	prob := g.New(FromDefinition(http.NotFoundDefinition), WithDetail("User not found"))
+//	prob.Provenance()[FieldDetail]  // BuildSourceExplicit
+//	prob.Provenance()[FieldTitle]   // BuildSourceDefinition
+func (p *Problem) Provenance() map[Field]BuildSource {
+	if p == nil {
+		return nil
+	}
+	return maps.Clone(p.provenance)
+}
+
+// traceBuild calls Generator.TraceBuild, where present, with each of the given steps and records their provenance on
+// the Problem provided if Generator.RecordProvenance is enabled.
+func (g *Generator) traceBuild(prob *Problem, steps []BuildStep) {
+	if g.RecordProvenance {
+		prob.provenance = make(map[Field]BuildSource, len(steps))
+	}
+	for _, step := range steps {
+		if trace := g.TraceBuild; trace != nil {
+			trace(step)
+		}
+		if prob.provenance != nil && step.Source != "" {
+			prob.provenance[step.Field] = step.Source
+		}
+	}
+}
+
+// propagatedSource returns the BuildSource of the given value that is either inherited from a wrapped Problem (i.e. a
+// stack trace, timestamp, trace context, or "UUID"), where it matches any of the wrapped values, or otherwise
+// captured/generated by the Generator. An empty BuildSource is returned if value is zero.
+func propagatedSource[T comparable](value T, wrapped ...T) BuildSource {
+	var zero T
+	if value == zero {
+		return ""
+	}
+	for _, w := range wrapped {
+		if w != zero && w == value {
+			return BuildSourceWrapped
+		}
+	}
+	return BuildSourceGenerator
+}
@@ -0,0 +1,58 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package problem
+
+import "context"
+
+type (
+	// SpanEventRecorder is a function used by a Generator to record a Problem as an event on the span that is active
+	// within the given context.Context (e.g. an OpenTelemetry span), allowing problems to be surfaced within a
+	// distributed trace without needing to log them. See Generator.SpanEventRecorder for more information.
+	//
+	// A SpanEventRecorder is called synchronously and is never passed a nil pointer to a Problem, which must not be
+	// modified.
+	SpanEventRecorder func(ctx context.Context, prob *Problem)
+
+	// TraceContextReader is a function used by a Generator to read the trace context (i.e. the hex-encoded trace and
+	// span IDs) of the span that is active within the given context.Context (e.g. an OpenTelemetry span). See
+	// Generator.TraceContextReader for more information.
+	//
+	// Empty strings should be returned if ctx contains no valid span.
+	TraceContextReader func(ctx context.Context) (traceID, spanID string)
+)
+
+// readTraceContext returns the trace and span IDs of the span within ctx using Generator.TraceContextReader, if not
+// nil. Otherwise, empty strings are returned.
+func (g *Generator) readTraceContext(ctx context.Context) (traceID, spanID string) {
+	if g.TraceContextReader == nil {
+		return "", ""
+	}
+	return g.TraceContextReader(ctx)
+}
+
+// recordSpanEvent records the given Problem as an event on the span within ctx using Generator.SpanEventRecorder, if
+// not nil.
+func (g *Generator) recordSpanEvent(ctx context.Context, prob *Problem) {
+	if g.SpanEventRecorder == nil || prob == nil {
+		return
+	}
+	g.SpanEventRecorder(ctx, prob)
+}
@@ -22,10 +22,22 @@ package problem
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/neocotic/go-optional"
+	"strings"
 )
 
 type (
+	// Field is the name of a Problem field (e.g. one that may be required to be explicitly provided when generating a
+	// Problem from a Type). See Type.RequiredFields for more information.
+	Field string
+
+	// Format is the format in which a Problem is encoded for a content/media type. See Generator.ContentTypes for more
+	// information.
+	Format uint
+
 	// Type represents a reusable problem type that may contain default values that can be used when generating a Problem
 	// from a specific type.
 	//
@@ -33,6 +45,22 @@ type (
 	// where they can be used to dictate all information populated within a Problem and/or combined with options to
 	// provide more granular control and overrides.
 	Type struct {
+		// DocsURL is the URL of documentation describing a Problem generated from the Type, which is assigned to the
+		// Problem as an extension using ExtensionDocsURL as the key, unless the extension is already present. Unlike URI,
+		// it is intended to be followed by clients to learn more about the problem.
+		//
+		// Definition.DocsURL will always take precedence over DocsURL.
+		//
+		// If DocsURL is empty, no default is used.
+		DocsURL string `json:"docsUrl,omitempty" xml:"docsUrl,omitempty" yaml:"docsUrl,omitempty"`
+		// HelpURL is the URL of actionable remediation steps for a Problem generated from the Type, which is assigned to
+		// the Problem as an extension using ExtensionHelpURL as the key, unless the extension is already present. Unlike
+		// URI, it is intended to be followed by clients to resolve the problem.
+		//
+		// Definition.HelpURL will always take precedence over HelpURL.
+		//
+		// If HelpURL is empty, no default is used.
+		HelpURL string `json:"helpUrl,omitempty" xml:"helpUrl,omitempty" yaml:"helpUrl,omitempty"`
 		// LogLevel is the default LogLevel to be assigned to a Problem generated from the Type. See Problem.LogLevel for
 		// more information.
 		//
@@ -40,6 +68,31 @@ type (
 		//
 		// If LogLevel is zero, the default used is DefaultLogLevel.
 		LogLevel LogLevel `json:"logLevel" xml:"logLevel" yaml:"logLevel"`
+		// RequiredFields contains each Field that must have a value when a Problem is generated from the Type (e.g. a
+		// Type whose problems are meaningless without a detail or Code).
+		//
+		// RequiredFields are only enforced when Generator.StrictMode is enabled, in which case Builder.Problem (and
+		// therefore Generator.New etc.) panics if any are missing. Otherwise, they are ignored.
+		//
+		// For example;
+		//
+		//	Conflict := Type{RequiredFields: []Field{FieldCode, FieldDetail}, Status: http.StatusConflict}
+		//
+		// If RequiredFields is empty, no fields are required.
+		RequiredFields []Field `json:"requiredFields,omitempty" xml:"requiredField,omitempty" yaml:"requiredFields,omitempty"`
+		// StackFlag is the default Flag used to control if/how a captured stack trace is visible on a Problem generated
+		// from the Type. See Problem.Stack for more information.
+		//
+		// If present, it takes precedence over Generator.StackFlag, however, Definition.StackFlag, Builder.Stack, and
+		// WithStack will always take precedence over StackFlag.
+		//
+		// For example;
+		//
+		//	InternalServer := Type{StackFlag: optional.Of(FlagField | FlagLog), Status: http.StatusInternalServerError}
+		//	NotFound := Type{StackFlag: optional.Of(FlagDisable), Status: http.StatusNotFound}
+		//
+		// If StackFlag is empty, no default is used.
+		StackFlag optional.Optional[Flag] `json:"stackFlag,omitempty" xml:"stackFlag,omitempty" yaml:"stackFlag,omitempty"`
 		// Status is the default status to be assigned to a Problem generated from the Type. See Problem.Status for more
 		// information.
 		//
@@ -58,6 +111,8 @@ type (
 		// The localized title will be looked up using Generator.Translator, where possible. If resolved, it will take
 		// precedence over Title.
 		//
+		// TitleKey is typically a string, however, a Key may be used where a structured translation key is required.
+		//
 		// If TitleKey is empty it, it is ignored.
 		TitleKey any `json:"titleKey" xml:"titleKey" yaml:"titleKey"`
 		// URI is the default type URI to be assigned to a Problem generated from the Type. See Problem.Type for more
@@ -67,6 +122,14 @@ type (
 		//
 		// If URI is empty, the default used is DefaultTypeURI.
 		URI string `json:"uri" xml:"uri" yaml:"uri"`
+		// UUIDFlag is the default Flag used to control if/how a generated UUID is visible on a Problem generated from
+		// the Type. See Problem.UUID for more information.
+		//
+		// If present, it takes precedence over Generator.UUIDFlag, however, Definition.UUIDFlag, Builder.UUID, and
+		// WithUUID will always take precedence over UUIDFlag.
+		//
+		// If UUIDFlag is empty, no default is used.
+		UUIDFlag optional.Optional[Flag] `json:"uuidFlag,omitempty" xml:"uuidFlag,omitempty" yaml:"uuidFlag,omitempty"`
 	}
 
 	// Typer is a function that can be used by a Generator to override the type URI reference derived from a Type (i.e.
@@ -77,6 +140,44 @@ type (
 	// It is important to note that if the function returns an empty string, it will fall back to DefaultTypeURI and not
 	// Type.URI.
 	Typer func(defType Type) string
+
+	// jsonType is used to allow a Type struct to be marshaled into, and JSON data to be unmarshaled into a Type struct,
+	// without having Type.MarshalJSON or Type.UnmarshalJSON invoked, resulting in a stack overflow.
+	jsonType Type
+)
+
+const (
+	// FieldCode is the Field representing Problem.Code.
+	FieldCode Field = "code"
+	// FieldDetail is the Field representing Problem.Detail.
+	FieldDetail Field = "detail"
+	// FieldExtensions is the Field representing Problem.Extensions, which is considered missing if empty.
+	FieldExtensions Field = "extensions"
+	// FieldInstance is the Field representing Problem.Instance.
+	FieldInstance Field = "instance"
+	// FieldSpanID is the Field representing Problem.SpanID.
+	FieldSpanID Field = "spanId"
+	// FieldStack is the Field representing Problem.Stack.
+	FieldStack Field = "stack"
+	// FieldStatus is the Field representing Problem.Status, which is never missing.
+	FieldStatus Field = "status"
+	// FieldTimestamp is the Field representing Problem.Timestamp.
+	FieldTimestamp Field = "timestamp"
+	// FieldTitle is the Field representing Problem.Title, which is never missing.
+	FieldTitle Field = "title"
+	// FieldTraceID is the Field representing Problem.TraceID.
+	FieldTraceID Field = "traceId"
+	// FieldType is the Field representing Problem.Type, which is never missing.
+	FieldType Field = "type"
+	// FieldUUID is the Field representing Problem.UUID.
+	FieldUUID Field = "uuid"
+)
+
+const (
+	// FormatJSON indicates that a Problem is encoded in JSON format.
+	FormatJSON Format = iota + 1
+	// FormatXML indicates that a Problem is encoded in XML format.
+	FormatXML
 )
 
 const (
@@ -139,6 +240,27 @@ func (t Type) BuildUsing(gen *Generator) *Builder {
 	}
 }
 
+// MarshalJSON marshals the Type into JSON.
+//
+// This is required in order to omit Type.StackFlag and Type.UUIDFlag when they are not present, as encoding/json never
+// omits an optional.Optional.
+//
+// An error is returned if unable to marshal the Type.
+func (t Type) MarshalJSON() ([]byte, error) {
+	aux := struct {
+		jsonType
+		StackFlag *Flag `json:"stackFlag,omitempty"`
+		UUIDFlag  *Flag `json:"uuidFlag,omitempty"`
+	}{jsonType: jsonType(t)}
+	if flag, present := t.StackFlag.Get(); present {
+		aux.StackFlag = &flag
+	}
+	if flag, present := t.UUIDFlag.Get(); present {
+		aux.UUIDFlag = &flag
+	}
+	return json.Marshal(aux)
+}
+
 // New is a convenient shorthand for calling Generator.New on DefaultGenerator, including FromType with the Type along
 // with any specified options.
 func (t Type) New(opts ...Option) *Problem {
@@ -168,50 +290,154 @@ func (t Type) NewUsing(gen *Generator, opts ...Option) *Problem {
 	return gen.new(context.Background(), opts, 1)
 }
 
+// UnmarshalJSON unmarshals the JSON data provided into the Type.
+//
+// This is required in order to unmarshal Type.TitleKey as either a string or a Key, rather than a generic map for the
+// latter.
+//
+// An error is returned if unable to unmarshal data.
+func (t *Type) UnmarshalJSON(data []byte) error {
+	aux := struct {
+		*jsonType
+		TitleKey json.RawMessage `json:"titleKey"`
+	}{jsonType: (*jsonType)(t)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if aux.TitleKey != nil {
+		key, err := unmarshalKey(aux.TitleKey)
+		if err != nil {
+			return err
+		}
+		t.TitleKey = key
+	}
+	return nil
+}
+
+// ErrTypeURI is returned when the type URI reference of a Problem is not allowed by Generator.AllowedTypeURIs.
+var ErrTypeURI = errors.New("problem type URI is not allowed")
+
+// checkRequiredFields returns an error if the given Problem, generated from the Type provided, is missing a value for
+// any of Type.RequiredFields, otherwise nil.
+func checkRequiredFields(prob *Problem, defType Type) error {
+	var errs []error
+	for _, field := range defType.RequiredFields {
+		var missing bool
+		switch field {
+		case FieldCode:
+			missing = prob.Code == ""
+		case FieldDetail:
+			missing = prob.Detail == ""
+		case FieldExtensions:
+			missing = len(prob.Extensions) == 0
+		case FieldInstance:
+			missing = prob.Instance == ""
+		case FieldSpanID:
+			missing = prob.SpanID == ""
+		case FieldStack:
+			missing = prob.Stack == ""
+		case FieldStatus, FieldTitle, FieldType:
+			continue
+		case FieldTimestamp:
+			missing = prob.Timestamp.IsZero()
+		case FieldTraceID:
+			missing = prob.TraceID == ""
+		case FieldUUID:
+			missing = prob.UUID == ""
+		default:
+			errs = append(errs, fmt.Errorf("unsupported required field: %q", field))
+			continue
+		}
+		if missing {
+			errs = append(errs, fmt.Errorf("missing required field: %q", field))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("invalid problem for type %q: %w", prob.Type, err)
+	}
+	return nil
+}
+
+// checkTypeURI returns an error wrapping ErrTypeURI if the given type URI reference is not allowed by
+// Generator.AllowedTypeURIs, otherwise nil.
+func (g *Generator) checkTypeURI(uri string) error {
+	if !g.isAllowedTypeURI(uri) {
+		return fmt.Errorf("%w: %q", ErrTypeURI, uri)
+	}
+	return nil
+}
+
 // contentType returns Generator.ContentType if not empty and valid, otherwise ContentTypeJSONUTF8.
 func (g *Generator) contentType() string {
-	if g.ContentType != "" && isValidContentType(g.ContentType) {
+	if g.ContentType != "" && g.isValidContentType(g.ContentType) {
 		return g.ContentType
 	}
 	return ContentTypeJSONUTF8
 }
 
-// typeURI checks if Generator.Typer is present and, if so, calls it with the given Type to allow for the type URI
-// reference to be overridden, where appropriate. Otherwise, Type.URI is returned.
-func (g *Generator) typeURI(defType Type) string {
-	if t := g.Typer; t != nil {
-		return t(defType)
+// contentTypeFormat returns the Format in which a Problem is encoded for the given content-type, if supported, either
+// as a built-in content-type or within Generator.ContentTypes.
+func (g *Generator) contentTypeFormat(ct string) (Format, bool) {
+	switch ct {
+	case ContentTypeJSON, ContentTypeJSONUTF8:
+		return FormatJSON, true
+	case ContentTypeXML, ContentTypeXMLUTF8:
+		return FormatXML, true
+	}
+	switch f := g.ContentTypes[ct]; f {
+	case FormatJSON, FormatXML:
+		return f, true
+	default:
+		return 0, false
 	}
-	return defType.URI
 }
 
-// isValidContentType returns whether the given content-type is valid when representing a Problem in any supported form.
-func isValidContentType(ct string) bool {
-	switch ct {
-	case ContentTypeJSON, ContentTypeJSONUTF8, ContentTypeXML, ContentTypeXMLUTF8:
+// isAllowedTypeURI returns whether the given type URI reference is allowed by Generator.AllowedTypeURIs, either by
+// matching a pattern exactly or by starting with the prefix of a pattern ending with "*". DefaultTypeURI is always
+// allowed, as is any type URI when Generator.AllowedTypeURIs is empty.
+func (g *Generator) isAllowedTypeURI(uri string) bool {
+	if len(g.AllowedTypeURIs) == 0 || uri == DefaultTypeURI {
 		return true
-	default:
-		return false
 	}
+	for _, pattern := range g.AllowedTypeURIs {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(uri, prefix) {
+				return true
+			}
+		} else if uri == pattern {
+			return true
+		}
+	}
+	return false
 }
 
-// isValidContentTypeForJSON returns whether the given content-type is valid when representing a Problem in its JSON
-// form.
-func isValidContentTypeForJSON(ct string) bool {
-	switch ct {
-	case ContentTypeJSON, ContentTypeJSONUTF8:
+// isValidContentType returns whether the given content-type is valid when representing a Problem in any supported form,
+// including any custom format within Generator.Encoders.
+func (g *Generator) isValidContentType(ct string) bool {
+	if _, ok := g.contentTypeFormat(ct); ok {
 		return true
-	default:
-		return false
 	}
+	return g.Encoders[ct] != nil
+}
+
+// isValidContentTypeForJSON returns whether the given content-type is valid when representing a Problem in its JSON
+// form.
+func (g *Generator) isValidContentTypeForJSON(ct string) bool {
+	f, _ := g.contentTypeFormat(ct)
+	return f == FormatJSON
 }
 
 // isValidContentTypeForXML returns whether the given content-type is valid when representing a Problem in its XML form.
-func isValidContentTypeForXML(ct string) bool {
-	switch ct {
-	case ContentTypeXML, ContentTypeXMLUTF8:
-		return true
-	default:
-		return false
+func (g *Generator) isValidContentTypeForXML(ct string) bool {
+	f, _ := g.contentTypeFormat(ct)
+	return f == FormatXML
+}
+
+// typeURI checks if Generator.Typer is present and, if so, calls it with the given Type to allow for the type URI
+// reference to be overridden, where appropriate. Otherwise, Type.URI is returned.
+func (g *Generator) typeURI(defType Type) string {
+	if t := g.Typer; t != nil {
+		return t(defType)
 	}
+	return defType.URI
 }
@@ -0,0 +1,186 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package problem
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"golang.org/x/text/language"
+	"io"
+	"net/http"
+	"strings"
+	"unicode"
+)
+
+// ValidateOptions contains options that can be used by Generator.Validate to perform additional validation.
+//
+// All fields are optional with default behaviour clearly documented.
+type ValidateOptions struct {
+	// Definitions contains each Definition whose translation keys (i.e. Definition.DetailKey and Type.TitleKey) are
+	// expected to be resolvable by Generator.Translator. See ExtractTranslationKeys for more information.
+	//
+	// If empty, no translation keys are validated for any Definition.
+	Definitions []Definition
+	// Languages contains each language.Tag for which all translation keys are expected to be resolvable by
+	// Generator.Translator, where each is passed within the context.Context (see UsingLanguage).
+	//
+	// If empty, translation keys are only validated without any language.Tag being passed.
+	Languages []language.Tag
+	// Types contains each Type whose translation key (i.e. Type.TitleKey) is expected to be resolvable by
+	// Generator.Translator. See ExtractTranslationKeys for more information.
+	//
+	// If empty, no translation keys are validated for any Type.
+	Types []Type
+}
+
+// ErrGenerator is returned when a Generator is found to be misconfigured.
+var ErrGenerator = errors.New("invalid problem generator")
+
+// Validate verifies that the configuration of the Generator is consistent so that it can fail fast (e.g. at startup)
+// instead of when the first Problem is generated and/or written. ValidateOptions can also be passed to validate that
+// all translation keys within a catalog of definitions and/or types can be resolved.
+//
+// An error wrapping ErrGenerator for each inconsistency is returned in the following cases:
+//   - Generator.AllowedTypeURIs contains an empty pattern
+//   - Generator.CodeSeparator is a non-printable rune
+//   - Generator.ContentType is not empty and not supported
+//   - Generator.ContentTypes contains a content/media type mapped to an unsupported Format
+//   - Generator.FallbackJSON is not empty and not valid JSON
+//   - Generator.FallbackXML is not empty and not well-formed XML
+//   - Generator.Notifiers contains a NotifierRoute without a Notifier
+//   - Generator.Profiles contains a Profile with an unsupported content/media type or a nil Sanitizer
+//   - Generator.Registry contains declarations that have not been finalized (see Registry.Finalize)
+//   - Generator.StatusTitles contains a status that is not a valid HTTP status code
+//   - A translation key within ValidateOptions.Definitions or ValidateOptions.Types cannot be resolved by
+//     Generator.Translator, including when Generator.Translator is nil
+//
+// For example;
+//
+//	if err := g.Validate(ValidateOptions{Definitions: http.AllDefinitions()}); err != nil {
+//		log.Fatal(err)
+//	}
+func (g *Generator) Validate(opts ...ValidateOptions) error {
+	var errs []error
+	for i, pattern := range g.AllowedTypeURIs {
+		if pattern == "" {
+			errs = append(errs, fmt.Errorf("%w: Generator.AllowedTypeURIs[%d] is empty", ErrGenerator, i))
+		}
+	}
+	if sep := g.CodeSeparator; sep > 0 && !unicode.IsPrint(sep) {
+		errs = append(errs, fmt.Errorf("%w: Generator.CodeSeparator is not printable: %q", ErrGenerator, sep))
+	}
+	if ct := g.ContentType; ct != "" && !g.isValidContentType(ct) {
+		errs = append(errs, fmt.Errorf("%w: Generator.ContentType is not supported: %q", ErrGenerator, ct))
+	}
+	for ct, f := range g.ContentTypes {
+		if f != FormatJSON && f != FormatXML {
+			errs = append(errs, fmt.Errorf("%w: Generator.ContentTypes contains unsupported Format for %q: %d", ErrGenerator, ct, f))
+		}
+	}
+	if fb := g.FallbackJSON; fb != "" && !json.Valid([]byte(fb)) {
+		errs = append(errs, fmt.Errorf("%w: Generator.FallbackJSON is not valid JSON", ErrGenerator))
+	}
+	if fb := g.FallbackXML; fb != "" && !isWellFormedXML(fb) {
+		errs = append(errs, fmt.Errorf("%w: Generator.FallbackXML is not well-formed XML", ErrGenerator))
+	}
+	for i, route := range g.Notifiers {
+		if route.Notifier == nil {
+			errs = append(errs, fmt.Errorf("%w: Generator.Notifiers[%d] has no Notifier", ErrGenerator, i))
+		}
+	}
+	for name, profile := range g.Profiles {
+		if ct := profile.ContentType; ct != "" && !g.isValidContentType(ct) {
+			errs = append(errs, fmt.Errorf("%w: Generator.Profiles[%q].ContentType is not supported: %q", ErrGenerator, name, ct))
+		}
+		for i, sanitizer := range profile.Sanitizers {
+			if sanitizer == nil {
+				errs = append(errs, fmt.Errorf("%w: Generator.Profiles[%q].Sanitizers[%d] is nil", ErrGenerator, name, i))
+			}
+		}
+	}
+	if reg := g.Registry; reg != nil {
+		if n := reg.pendingDeclarations(); n > 0 {
+			errs = append(errs, fmt.Errorf("%w: Generator.Registry has %d declarations that have not been finalized", ErrGenerator, n))
+		}
+	}
+	for status := range g.StatusTitles {
+		if status < 100 || status > 599 {
+			errs = append(errs, fmt.Errorf("%w: Generator.StatusTitles contains invalid status: %d", ErrGenerator, status))
+		}
+	}
+	if len(opts) > 0 {
+		errs = append(errs, g.validateTranslationKeys(opts[0])...)
+	}
+	return errors.Join(errs...)
+}
+
+// validateTranslationKeys returns an error wrapping ErrGenerator for each translation key within the given
+// ValidateOptions that cannot be resolved by Generator.Translator, for each language.Tag, if any.
+func (g *Generator) validateTranslationKeys(opts ValidateOptions) []error {
+	infos := ExtractTranslationKeys(opts.Definitions, opts.Types)
+	if len(infos) == 0 {
+		return nil
+	}
+	if g.Translator == nil {
+		return []error{fmt.Errorf("%w: Generator.Translator is nil but %d translation keys were provided", ErrGenerator, len(infos))}
+	}
+	contexts := []context.Context{context.Background()}
+	if len(opts.Languages) > 0 {
+		contexts = make([]context.Context, len(opts.Languages))
+		for i, tag := range opts.Languages {
+			contexts[i] = UsingLanguage(context.Background(), tag)
+		}
+	}
+	var errs []error
+	for _, ctx := range contexts {
+		for _, info := range infos {
+			if g.translateOrElse(ctx, true, info.Key, "") != "" {
+				continue
+			}
+			var where string
+			if info.Code != "" {
+				where = fmt.Sprintf(" [%s]", info.Code)
+			} else if info.Status != 0 {
+				where = fmt.Sprintf(" [%d %s]", info.Status, http.StatusText(info.Status))
+			}
+			if tag, ok := GetLanguage(ctx); ok {
+				where += fmt.Sprintf(" for language %s", tag)
+			}
+			errs = append(errs, fmt.Errorf("%w: %s translation key cannot be resolved: %v%s", ErrGenerator, info.Field, info.Key, where))
+		}
+	}
+	return errs
+}
+
+// isWellFormedXML returns whether the given string contains well-formed XML.
+func isWellFormedXML(s string) bool {
+	dec := xml.NewDecoder(strings.NewReader(s))
+	for {
+		if _, err := dec.Token(); err == io.EOF {
+			return true
+		} else if err != nil {
+			return false
+		}
+	}
+}
@@ -0,0 +1,304 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package problem
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"go.uber.org/zap/zapcore"
+	"log/slog"
+	"maps"
+	"time"
+)
+
+type (
+	// ProblemView provides read-only access to a Problem, allowing it to be safely shared (e.g. by a library or between
+	// goroutines) without risk of callers mutating any of its fields, including its extensions.
+	//
+	// A ProblemView can be obtained from a Problem using Problem.Freeze. Once frozen, any changes made to the original
+	// Problem are not reflected by the ProblemView. However, since extensions could contain any type of values, only a
+	// shallow clone of Problem.Extensions is captured and so care should still be taken to not mutate any extension
+	// values that are themselves mutable (e.g. maps and slices).
+	//
+	// ProblemView.CopyOnWrite can be used to obtain a mutable clone of the underlying Problem whenever changes are
+	// required (e.g. to write it as an HTTP response).
+	//
+	// errors.As can also be used to find the Problem within a ProblemView, where the target is assigned a mutable clone
+	// of the underlying Problem, the same as ProblemView.CopyOnWrite.
+	ProblemView interface {
+		error
+		fmt.Stringer
+		json.Marshaler
+		slog.LogValuer
+		xml.Marshaler
+		zapcore.ObjectMarshaler
+
+		// Code returns the Code of the Problem. See Problem.Code for more information.
+		Code() Code
+		// CopyOnWrite returns a mutable clone of the Problem, including a shallow clone of its extensions, that can be
+		// modified without affecting the ProblemView.
+		CopyOnWrite() *Problem
+		// Detail returns the detail of the Problem. See Problem.Detail for more information.
+		Detail() string
+		// DocsURL returns the URL of documentation describing the Problem, if any. See Problem.DocsURL for more
+		// information.
+		DocsURL() string
+		// Extension returns the value of the extension with given key within the Problem, if present. See
+		// Problem.Extensions for more information.
+		Extension(key string) (value any, found bool)
+		// Extensions returns a shallow clone of the extensions within the Problem, which will be nil if it has none. See
+		// Problem.Extensions for more information.
+		Extensions() Extensions
+		// FieldErrors returns each FieldError contained within the Problem, if any. See Problem.FieldErrors for more
+		// information.
+		FieldErrors() []FieldError
+		// HelpURL returns the URL of actionable remediation steps for the Problem, if any. See Problem.HelpURL for more
+		// information.
+		HelpURL() string
+		// Instance returns the instance URI reference of the Problem. See Problem.Instance for more information.
+		Instance() string
+		// IsRetryable returns whether a request that resulted in the Problem can be retried. See Problem.IsRetryable for
+		// more information.
+		IsRetryable() bool
+		// LogInfo returns information associated with the Problem that is only relevant for logging purposes. See
+		// Problem.LogInfo for more information.
+		LogInfo() LogInfo
+		// Provenance returns the BuildSource of each field of the Problem, if recorded. See Problem.Provenance for more
+		// information.
+		Provenance() map[Field]BuildSource
+		// RangeExtensions calls fn sequentially for each extension within the Problem, sorted by key. If fn returns
+		// false, RangeExtensions stops the iteration. See Problem.RangeExtensions for more information.
+		RangeExtensions(fn func(key string, value any) bool)
+		// SLOClass returns the SLOClass of the Problem, if classified. See Problem.SLOClass for more information.
+		SLOClass() SLOClass
+		// SpanID returns the span ID of the Problem. See Problem.SpanID for more information.
+		SpanID() string
+		// Stack returns the string representation of the stack trace of the Problem. See Problem.Stack for more
+		// information.
+		Stack() string
+		// Status returns the status of the Problem. See Problem.Status for more information.
+		Status() int
+		// Timestamp returns the time at which the Problem occurred. See Problem.Timestamp for more information.
+		Timestamp() time.Time
+		// Title returns the title of the Problem. See Problem.Title for more information.
+		Title() string
+		// TraceID returns the trace ID of the Problem. See Problem.TraceID for more information.
+		TraceID() string
+		// Type returns the type URI reference of the Problem. See Problem.Type for more information.
+		Type() string
+		// Unwrap returns the errors wrapped by the Problem, if any, otherwise returns nil. See Problem.Unwrap for more
+		// information.
+		Unwrap() []error
+		// UUID returns the UUID of the Problem. See Problem.UUID for more information.
+		UUID() string
+	}
+
+	// frozenProblem is the ProblemView implementation returned by Problem.Freeze.
+	frozenProblem struct {
+		p *Problem
+	}
+)
+
+// frozenXMLLocalName is the local name derived from frozenProblem whenever it is being marshaled to XML without an
+// explicit local name so that it can be replaced with a preferred one.
+const frozenXMLLocalName = "frozenProblem"
+
+var _ ProblemView = (*frozenProblem)(nil)
+
+// Freeze returns a ProblemView for a clone of the Problem, which is unaffected by any subsequent changes made to the
+// Problem. See ProblemView for more information.
+//
+// Returns nil if the Problem is nil.
+func (p *Problem) Freeze() ProblemView {
+	if p == nil {
+		return nil
+	}
+	return &frozenProblem{p: p.clone()}
+}
+
+// As assigns a mutable clone of the Problem to target if it is a **Problem, returning true, allowing errors.As to find
+// the Problem without exposing the underlying Problem to mutation. Otherwise, false is returned.
+func (fp *frozenProblem) As(target any) bool {
+	if t, ok := target.(**Problem); ok {
+		*t = fp.CopyOnWrite()
+		return true
+	}
+	return false
+}
+
+// Code returns the Code of the Problem.
+func (fp *frozenProblem) Code() Code {
+	return fp.p.Code
+}
+
+// CopyOnWrite returns a mutable clone of the Problem.
+func (fp *frozenProblem) CopyOnWrite() *Problem {
+	return fp.p.clone()
+}
+
+// Detail returns the detail of the Problem.
+func (fp *frozenProblem) Detail() string {
+	return fp.p.Detail
+}
+
+// DocsURL returns the URL of documentation describing the Problem, if any.
+func (fp *frozenProblem) DocsURL() string {
+	return fp.p.DocsURL()
+}
+
+// Error returns the most suitable error message for the Problem.
+func (fp *frozenProblem) Error() string {
+	return fp.p.Error()
+}
+
+// Extension returns the value of the extension with given key within the Problem, if present.
+func (fp *frozenProblem) Extension(key string) (value any, found bool) {
+	return fp.p.Extension(key)
+}
+
+// Extensions returns a shallow clone of the extensions within the Problem.
+func (fp *frozenProblem) Extensions() Extensions {
+	return maps.Clone(fp.p.Extensions)
+}
+
+// FieldErrors returns each FieldError contained within the Problem, if any.
+func (fp *frozenProblem) FieldErrors() []FieldError {
+	return fp.p.FieldErrors()
+}
+
+// HelpURL returns the URL of actionable remediation steps for the Problem, if any.
+func (fp *frozenProblem) HelpURL() string {
+	return fp.p.HelpURL()
+}
+
+// Instance returns the instance URI reference of the Problem.
+func (fp *frozenProblem) Instance() string {
+	return fp.p.Instance
+}
+
+// IsRetryable returns whether a request that resulted in the Problem can be retried.
+func (fp *frozenProblem) IsRetryable() bool {
+	return fp.p.IsRetryable()
+}
+
+// LogInfo returns information associated with the Problem that is only relevant for logging purposes.
+func (fp *frozenProblem) LogInfo() LogInfo {
+	return fp.p.LogInfo()
+}
+
+// LogValue returns a slog.GroupValue representation of the Problem containing attrs for only non-empty fields.
+func (fp *frozenProblem) LogValue() slog.Value {
+	return fp.p.LogValue()
+}
+
+// MarshalJSON marshals the Problem into JSON.
+func (fp *frozenProblem) MarshalJSON() ([]byte, error) {
+	return fp.p.MarshalJSON()
+}
+
+// MarshalLogObject appends non-empty fields of the Problem to enc.
+func (fp *frozenProblem) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	return fp.p.MarshalLogObject(enc)
+}
+
+// MarshalXML marshals the Problem into XML.
+func (fp *frozenProblem) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if start.Name.Local == frozenXMLLocalName {
+		start.Name.Local = xmlDefaultLocalName
+	}
+	return fp.p.MarshalXML(e, start)
+}
+
+// Provenance returns the BuildSource of each field of the Problem, if recorded.
+func (fp *frozenProblem) Provenance() map[Field]BuildSource {
+	return fp.p.Provenance()
+}
+
+// RangeExtensions calls fn sequentially for each extension within the Problem, sorted by key.
+func (fp *frozenProblem) RangeExtensions(fn func(key string, value any) bool) {
+	fp.p.RangeExtensions(fn)
+}
+
+// SLOClass returns the SLOClass of the Problem, if classified.
+func (fp *frozenProblem) SLOClass() SLOClass {
+	return fp.p.SLOClass()
+}
+
+// SpanID returns the span ID of the Problem.
+func (fp *frozenProblem) SpanID() string {
+	return fp.p.SpanID
+}
+
+// Stack returns the string representation of the stack trace of the Problem.
+func (fp *frozenProblem) Stack() string {
+	return fp.p.Stack
+}
+
+// Status returns the status of the Problem.
+func (fp *frozenProblem) Status() int {
+	return fp.p.Status
+}
+
+// String returns a string representation of the Problem.
+func (fp *frozenProblem) String() string {
+	return fp.p.String()
+}
+
+// Timestamp returns the time at which the Problem occurred.
+func (fp *frozenProblem) Timestamp() time.Time {
+	return fp.p.Timestamp
+}
+
+// Title returns the title of the Problem.
+func (fp *frozenProblem) Title() string {
+	return fp.p.Title
+}
+
+// TraceID returns the trace ID of the Problem.
+func (fp *frozenProblem) TraceID() string {
+	return fp.p.TraceID
+}
+
+// Type returns the type URI reference of the Problem.
+func (fp *frozenProblem) Type() string {
+	return fp.p.Type
+}
+
+// Unwrap returns the errors wrapped by the Problem, if any, otherwise returns nil.
+func (fp *frozenProblem) Unwrap() []error {
+	return fp.p.Unwrap()
+}
+
+// UUID returns the UUID of the Problem.
+func (fp *frozenProblem) UUID() string {
+	return fp.p.UUID
+}
+
+// clone returns a clone of the Problem.
+//
+// A shallow clone of Problem.Extensions will have to do since extensions could contain any type of values.
+func (p *Problem) clone() *Problem {
+	clone := *p
+	clone.Extensions = maps.Clone(p.Extensions)
+	clone.pooled = false
+	return &clone
+}
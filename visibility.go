@@ -0,0 +1,85 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package problem
+
+// Visibility controls, typically per environment (e.g. development, staging, or production), whether information is
+// exposed on a Problem (i.e. within its exported fields) or only within logs. See Generator.Visibility for more
+// information.
+//
+// Since it is applied whenever a Problem is built, rather than when it is written as an HTTP response, any information
+// that is only visible within logs can never leak, even if the Problem is marshaled directly (e.g. via json.Marshal).
+//
+// The zero value of Visibility does not restrict anything.
+type Visibility struct {
+	// LogOnlyDetail is whether the detail of a Problem is only visible within logs. If true, Problem.Detail is always
+	// empty, and the detail is never included within its extensions (see I18NOutputDual), however, the detail is still
+	// logged.
+	LogOnlyDetail bool
+	// LogOnlyErrorMessages is whether the messages of any errors wrapped by a Problem are only visible within logs. If
+	// true, Generator.DetailComposer is never used and ExtensionDebugError is never included while debugging (see
+	// Generator.DebugMode), however, any wrapped error is still logged.
+	LogOnlyErrorMessages bool
+	// LogOnlyExtensions contains the keys of any extensions that are only visible within logs. Any such extensions are
+	// removed from Problem.Extensions when a Problem is built, however, they are still logged.
+	LogOnlyExtensions []string
+	// LogOnlyStack is whether the stack trace of a Problem is only visible within logs. If true, Problem.Stack is always
+	// empty and any stack trace that would otherwise have been visible on the Problem (see FlagField) is only visible
+	// within logs instead.
+	LogOnlyStack bool
+}
+
+// visibleStackFlag returns the given Flag controlling if/how a stack trace is visible after applying
+// Visibility.LogOnlyStack, where FlagField is replaced with FlagLog.
+func (v Visibility) visibleStackFlag(flag Flag) Flag {
+	if v.LogOnlyStack && checkFlag(flag, FlagField) {
+		return flag&^FlagField | FlagLog
+	}
+	return flag
+}
+
+// splitExtensions returns the given extensions split into those that are visible on a Problem and those that are only
+// visible within logs (see Visibility.LogOnlyExtensions). extensions is never modified and is returned as-is for the
+// former if it contains no extensions that are only visible within logs.
+func (v Visibility) splitExtensions(extensions Extensions) (visible, logOnly Extensions) {
+	for _, key := range v.LogOnlyExtensions {
+		value, ok := extensions[key]
+		if !ok {
+			continue
+		}
+		if logOnly == nil {
+			logOnly = make(Extensions, len(v.LogOnlyExtensions))
+		}
+		logOnly[key] = value
+	}
+	if len(logOnly) == 0 {
+		return extensions, nil
+	}
+	visible = make(Extensions, len(extensions)-len(logOnly))
+	for key, value := range extensions {
+		if _, ok := logOnly[key]; !ok {
+			visible[key] = value
+		}
+	}
+	if len(visible) == 0 {
+		visible = nil
+	}
+	return visible, logOnly
+}
@@ -24,9 +24,23 @@ import (
 	"cmp"
 	"errors"
 	"fmt"
+	"reflect"
+	"strings"
 )
 
 type (
+	// DetailComposer is a function used to compose the detail of a Problem from its resolved detail and the error that
+	// it wraps. See Generator.DetailComposer for more information.
+	//
+	// A DetailComposer is never passed a nil error, however, detail may be empty.
+	DetailComposer func(detail string, err error) string
+
+	// ErrorMapper is a function used to map an error to a Definition, returning true only if err was mapped. See
+	// Generator.ErrorMappers for more information.
+	//
+	// An ErrorMapper is never passed a nil error.
+	ErrorMapper func(err error) (Definition, bool)
+
 	// Matcher is a function used to conditionally match on a Problem, returning true only if the match is successful.
 	//
 	// A Matcher is never passed a nil pointer to a Problem.
@@ -41,8 +55,39 @@ type (
 	// used to construct the new Problem. Any such information will not take precedence over any explicitly defined
 	// Problem fields, however, it will take precedence over any information derived from a Definition or its Type.
 	Unwrapper func(err error) Problem
+
+	// ValueError is an error representing a value that is not itself an error (e.g. a value recovered from a panic),
+	// allowing it to be wrapped by a Problem. See Builder.WrapValue and WrapValue for more information.
+	//
+	// The original value is preserved so that it can be retrieved using errors.As.
+	ValueError struct {
+		// Value is the value that is represented by the error.
+		Value any
+	}
 )
 
+const (
+	// ExtensionDeadline is the key of the extension containing the deadline of the context.Context used to build a
+	// Problem wrapping context.DeadlineExceeded. See Builder.DeadlineExtensions for more information.
+	ExtensionDeadline = "deadline"
+	// ExtensionElapsed is the key of the extension containing the elapsed time, as a duration string (e.g. "1.5s"),
+	// before a Problem wrapping context.DeadlineExceeded was built. See Builder.DeadlineExtensions for more information.
+	ExtensionElapsed = "elapsed"
+	// ExtensionTimeoutBudget is the key of the extension containing the total time budget, as a duration string (e.g.
+	// "2s"), permitted by the deadline of the context.Context used to build a Problem wrapping
+	// context.DeadlineExceeded. See Builder.DeadlineExtensions for more information.
+	ExtensionTimeoutBudget = "timeout_budget"
+	// ExtensionValue is the key of the extension used to preserve a structured value (i.e. a map, slice, array, or
+	// struct, or a pointer to one) represented by a ValueError that is wrapped by a Problem.
+	ExtensionValue = "value"
+)
+
+// MaxTreeDepth is the maximum depth to which an error's tree is traversed when searching for problems (e.g. by
+// AsMatch).
+const MaxTreeDepth = 100
+
+var _ error = (*ValueError)(nil)
+
 const (
 	// OperatorEquals is used to check if two values of the same type are equal.
 	OperatorEquals Operator = iota
@@ -57,8 +102,27 @@ const (
 	OperatorLessThan
 	// OperatorLessThanOrEqual is used to check if one value is less than or equal to another value of the same type.
 	OperatorLessThanOrEqual
+	// OperatorHasPrefix is used to check if one value starts with another value of the same type. It is intended for
+	// string values (e.g. HasType), while any other value is compared using its string representation.
+	OperatorHasPrefix
+	// OperatorHasSuffix is used to check if one value ends with another value of the same type. It is intended for
+	// string values (e.g. HasInstance), while any other value is compared using its string representation.
+	OperatorHasSuffix
+	// OperatorContains is used to check if one value contains another value of the same type. It is intended for
+	// string values (e.g. HasDetail), while any other value is compared using its string representation.
+	OperatorContains
+	// OperatorGlob is used to check if one value matches a glob pattern contained within another value of the same type,
+	// where "*" matches any sequence of characters (including "/") and "?" matches any single character. It is intended
+	// for string values (e.g. HasType("https://example.com/problems/payments/*", OperatorGlob)), while any other value
+	// is compared using its string representation.
+	OperatorGlob
 )
 
+// Error returns the string representation of ValueError.Value, as formatted by fmt.Sprint.
+func (ve *ValueError) Error() string {
+	return fmt.Sprint(ve.Value)
+}
+
 // As is a convenient shorthand for calling errors.As with a Problem target, however, it also gracefully handles the
 // case where err is nil without a panic.
 func As(err error) (*Problem, bool) {
@@ -70,6 +134,15 @@ func As(err error) (*Problem, bool) {
 	return p, isProblem
 }
 
+// AsAll returns every Problem within err's tree, including the trees of any joined errors (e.g. via errors.Join), in
+// the order in which they are found (i.e. the same order as errors.As). This can be useful for aggregating multiple
+// wrapped failures (e.g. within batch pipelines).
+//
+// err's tree is traversed in the same way as AsMatch. nil is returned if err is nil or no Problem is found.
+func AsAll(err error) []*Problem {
+	return AsMatchAll(err)
+}
+
 // AsOrElse is a convenient shorthand for calling errors.As with a Problem target, however, it also gracefully handles
 // the case where err is nil without a panic.
 //
@@ -111,24 +184,37 @@ func AsOrElseGet(err error, defaultProbFunc func() *Problem) (*Problem, bool) {
 // AsMatch is a convenient shorthand for calling errors.As with a Problem target, however, it also gracefully handles
 // the case where err is nil without a panic.
 //
-// Additionally, if a Problem is found in err's tree, it must match all matchers provided, otherwise it will be
-// unwrapped, and it's tree (excluding itself) will continue to be checked until either a matching Problem is found or
-// no Problem is found.
+// Additionally, if a Problem is found in err's tree, it must match all matchers provided, otherwise err's tree will
+// continue to be checked, in the same order as errors.As (including its tree and those of any joined errors), until
+// either a matching Problem is found or no Problem is found.
+//
+// Unlike errors.As, err's tree is traversed iteratively, to a maximum depth of MaxTreeDepth, and any error that has
+// already been visited (i.e. a cycle within an Unwrap chain) is not traversed again.
 func AsMatch(err error, matchers ...Matcher) (*Problem, bool) {
-	if err == nil {
-		return nil, false
-	}
-	var p *Problem
-	if !errors.As(err, &p) {
-		return nil, false
-	}
-	if Match(p, matchers...) {
-		return p, true
-	}
-	if p == nil {
-		return nil, false
-	}
-	return AsMatch(p.Unwrap(), matchers...)
+	var match *Problem
+	walkProblems(err, func(p *Problem) bool {
+		if Match(p, matchers...) {
+			match = p
+			return false
+		}
+		return true
+	})
+	return match, match != nil
+}
+
+// AsMatchAll returns all problems within err's tree that match all matchers provided, in the same order as they would
+// be found by AsMatch.
+//
+// nil is returned if err is nil or no matching Problem is found.
+func AsMatchAll(err error, matchers ...Matcher) []*Problem {
+	var matches []*Problem
+	walkProblems(err, func(p *Problem) bool {
+		if Match(p, matchers...) {
+			matches = append(matches, p)
+		}
+		return true
+	})
+	return matches
 }
 
 // AsMatchOrElse is a convenient shorthand for calling errors.As with a Problem target, however, it also gracefully
@@ -212,7 +298,28 @@ func HasCodeNSUsing(gen *Generator, ns NS, operator ...Operator) Matcher {
 	op := operatorOrDefault(operator)
 	return func(p *Problem) bool {
 		parsed, err := c.Parse(p.Code)
-		return err != nil && operate(op, parsed.NS, ns)
+		return err == nil && operate(op, parsed.NS, ns)
+	}
+}
+
+// HasCodeParts is used to match a Problem based on both the NS and value within its Code using DefaultGenerator.
+//
+// Unlike HasCode, this match is unaffected by how the Code is formatted (e.g. any padding applied using
+// Generator.CodeValueLen).
+func HasCodeParts(ns NS, value uint) Matcher {
+	return HasCodePartsUsing(DefaultGenerator, ns, value)
+}
+
+// HasCodePartsUsing is used to match a Problem based on both the NS and value within its Code using the given
+// Generator.
+//
+// Unlike HasCode, this match is unaffected by how the Code is formatted (e.g. any padding applied using
+// Generator.CodeValueLen).
+func HasCodePartsUsing(gen *Generator, ns NS, value uint) Matcher {
+	c := gen.Coder()
+	return func(p *Problem) bool {
+		parsed, err := c.Parse(p.Code)
+		return err == nil && parsed.NS == ns && parsed.Value == value
 	}
 }
 
@@ -233,7 +340,7 @@ func HasCodeValueUsing(gen *Generator, value uint, operator ...Operator) Matcher
 	op := operatorOrDefault(operator)
 	return func(p *Problem) bool {
 		parsed, err := c.Parse(p.Code)
-		return err != nil && operate(op, parsed.Value, value)
+		return err == nil && operate(op, parsed.Value, value)
 	}
 }
 
@@ -344,6 +451,39 @@ func Match(prob *Problem, matchers ...Matcher) bool {
 	return true
 }
 
+// MatchesDefinition is used to match a Problem based on whether its Code, type URI, and status are all equal to those
+// of the given Definition (i.e. Definition.Code, Type.URI, and Type.Status), using DefaultGenerator to resolve the type
+// URI. This allows a Problem to be routed precisely (e.g. within error middleware) based on the Definition from which
+// it was generated.
+//
+// Any of these fields that is empty on def is ignored, however, if all are empty, no Problem is matched.
+//
+// For example;
+//
+//	if IsMatch(err, MatchesDefinition(UserNotFound)) {
+//		// ...
+//	}
+func MatchesDefinition(def Definition) Matcher {
+	return MatchesDefinitionUsing(DefaultGenerator, def)
+}
+
+// MatchesDefinitionUsing is used to match a Problem based on whether its Code, type URI, and status are all equal to
+// those of the given Definition, using the given Generator to resolve the type URI (see Generator.Typer). See
+// MatchesDefinition for more information.
+func MatchesDefinitionUsing(gen *Generator, def Definition) Matcher {
+	typeURI := gen.typeURI(def.Type)
+	if def.Code == "" && typeURI == "" && def.Type.Status == 0 {
+		return func(_ *Problem) bool {
+			return false
+		}
+	}
+	return func(p *Problem) bool {
+		return (def.Code == "" || p.Code == def.Code) &&
+			(typeURI == "" || p.Type == typeURI) &&
+			(def.Type.Status == 0 || p.Status == def.Type.Status)
+	}
+}
+
 // Or is used to match a Problem on any of the given matchers.
 func Or(matchers ...Matcher) Matcher {
 	return func(p *Problem) bool {
@@ -371,17 +511,59 @@ func NoopUnwrapper() Unwrapper {
 }
 
 // PropagatedFieldUnwrapper returns an Unwrapper that extracts only fields that are expected to be propagated (e.g.
-// captured stack trace, generated "UUID") from a wrapped Problem in err's tree, if present. Any such fields will not
+// captured stack trace, generated "UUID") from the wrapped problems in err's tree, if present. Any such fields will not
 // take precedence over any explicitly defined Problem fields, however, it will take precedence over any fields derived
 // from a Definition or its Type.
+//
+// Each field is extracted from the first Problem in err's tree that has a value for it, in the same order as AsAll, so
+// that fields can be propagated from any branch of a joined error (e.g. via errors.Join).
 func PropagatedFieldUnwrapper() Unwrapper {
 	return unwrapPropagatedFields
 }
 
+// matchGlob returns whether the given string matches the glob pattern provided, where "*" matches any sequence of
+// characters (including "/") and "?" matches any single character. Unlike path.Match, there is no escaping or support
+// for character classes as neither are expected to be useful when matching problem fields.
+func matchGlob(pattern, s string) bool {
+	p, str := []rune(pattern), []rune(s)
+	var pi, si int
+	starPi, starSi := -1, 0
+	for si < len(str) {
+		switch {
+		case pi < len(p) && (p[pi] == '?' || p[pi] == str[si]):
+			pi++
+			si++
+		case pi < len(p) && p[pi] == '*':
+			starPi, starSi = pi, si
+			pi++
+		case starPi >= 0:
+			// Backtrack so that the last "*" consumes one more character
+			starSi++
+			pi, si = starPi+1, starSi
+		default:
+			return false
+		}
+	}
+	for pi < len(p) && p[pi] == '*' {
+		pi++
+	}
+	return pi == len(p)
+}
+
 // operate returns the result of the given operation.
 //
 // Panics if op is invalid.
 func operate[T cmp.Ordered](op Operator, probValue, otherValue T) bool {
+	switch op {
+	case OperatorHasPrefix:
+		return strings.HasPrefix(fmt.Sprint(probValue), fmt.Sprint(otherValue))
+	case OperatorHasSuffix:
+		return strings.HasSuffix(fmt.Sprint(probValue), fmt.Sprint(otherValue))
+	case OperatorContains:
+		return strings.Contains(fmt.Sprint(probValue), fmt.Sprint(otherValue))
+	case OperatorGlob:
+		return matchGlob(fmt.Sprint(otherValue), fmt.Sprint(probValue))
+	}
 	c := cmp.Compare(probValue, otherValue)
 	switch op {
 	case OperatorEquals:
@@ -421,16 +603,246 @@ func unwrapAllFields(err error) Problem {
 }
 
 // unwrapPropagatedFields extracts only fields that are expected to be propagated (e.g. captured stack trace, generated
-// "UUID") from a wrapped Problem in err's tree, if present. Any such fields will not take precedence over any
+// "UUID", timestamp, trace context, or ownership) from the wrapped problems in err's tree, if present, where each field
+// is extracted from the first Problem that has a value for it. Any such fields will not take precedence over any
 // explicitly defined Problem fields, however, it will take precedence over any fields derived from a Definition or its
 // Type.
+//
+// The LogLevel is only ever extracted from the first Problem in err's tree.
 func unwrapPropagatedFields(err error) Problem {
-	if p, isProblem := As(err); isProblem && p != nil {
-		return Problem{
-			Stack:   p.Stack,
-			UUID:    p.UUID,
-			logInfo: p.logInfo,
+	var (
+		found bool
+		prob  Problem
+	)
+	walkProblems(err, func(p *Problem) bool {
+		if !found {
+			found = true
+			prob.logInfo.Level = p.logInfo.Level
+		}
+		if prob.SpanID == "" {
+			prob.SpanID = p.SpanID
+		}
+		if prob.Stack == "" {
+			prob.Stack = p.Stack
 		}
+		if prob.Timestamp.IsZero() {
+			prob.Timestamp = p.Timestamp
+		}
+		if prob.TraceID == "" {
+			prob.TraceID = p.TraceID
+		}
+		if prob.UUID == "" {
+			prob.UUID = p.UUID
+		}
+		if prob.logInfo.Owner == "" {
+			prob.logInfo.Owner = p.logInfo.Owner
+		}
+		if prob.logInfo.RunbookURL == "" {
+			prob.logInfo.RunbookURL = p.logInfo.RunbookURL
+		}
+		if prob.logInfo.SpanID == "" {
+			prob.logInfo.SpanID = p.logInfo.SpanID
+		}
+		if prob.logInfo.Stack == "" && prob.logInfo.lazyStack == nil {
+			prob.logInfo.Stack = p.logInfo.Stack
+			prob.logInfo.lazyStack = p.logInfo.lazyStack
+		}
+		if prob.logInfo.Timestamp.IsZero() {
+			prob.logInfo.Timestamp = p.logInfo.Timestamp
+		}
+		if prob.logInfo.TraceID == "" {
+			prob.logInfo.TraceID = p.logInfo.TraceID
+		}
+		if prob.logInfo.UUID == "" {
+			prob.logInfo.UUID = p.logInfo.UUID
+		}
+		return prob.SpanID == "" || prob.Stack == "" || prob.Timestamp.IsZero() || prob.TraceID == "" ||
+			prob.UUID == "" || prob.logInfo.Owner == "" || prob.logInfo.RunbookURL == "" || prob.logInfo.SpanID == "" ||
+			(prob.logInfo.Stack == "" && prob.logInfo.lazyStack == nil) || prob.logInfo.Timestamp.IsZero() || prob.logInfo.TraceID == "" ||
+			prob.logInfo.UUID == ""
+	})
+	return prob
+}
+
+// structuredValue returns the value represented by err, but only if err is a ValueError whose value is structured (i.e.
+// a map, slice, array, or struct, or a pointer to one).
+//
+// A value that implements error or fmt.Stringer is never considered structured as it has a meaningful string
+// representation.
+func structuredValue(err error) (any, bool) {
+	ve, ok := err.(*ValueError)
+	if !ok || ve.Value == nil {
+		return nil, false
 	}
-	return Problem{}
+	switch ve.Value.(type) {
+	case error, fmt.Stringer:
+		return nil, false
+	}
+	rv := reflect.ValueOf(ve.Value)
+	if rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil, false
+		}
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.Struct:
+		return ve.Value, true
+	default:
+		return nil, false
+	}
+}
+
+// valueAsError returns the given value as an error, where a nil value results in a nil error and a value that is not
+// already an error is represented by a ValueError.
+func valueAsError(v any) error {
+	if v == nil {
+		return nil
+	}
+	if err, ok := v.(error); ok {
+		return err
+	}
+	return &ValueError{Value: v}
+}
+
+// walkErrors calls fn for each error within err's tree, including err itself, in the same order as errors.As (i.e. a
+// depth-first pre-order traversal), until fn returns false.
+//
+// err's tree is traversed iteratively to a maximum depth of MaxTreeDepth and any comparable error that has already been
+// visited is not traversed again, protecting against cycles within misbehaving Unwrap chains. An error is only
+// considered comparable if its value is (e.g. not a struct containing an interface holding a slice), as otherwise it
+// would panic when used as a map key.
+func walkErrors(err error, fn func(err error) bool) {
+	type node struct {
+		depth int
+		err   error
+	}
+	var visited map[error]struct{}
+	stack := []node{{err: err}}
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if n.err == nil || n.depth > MaxTreeDepth {
+			continue
+		}
+		if reflect.ValueOf(n.err).Comparable() {
+			if _, seen := visited[n.err]; seen {
+				continue
+			}
+			if visited == nil {
+				visited = make(map[error]struct{})
+			}
+			visited[n.err] = struct{}{}
+		}
+		if !fn(n.err) {
+			return
+		}
+		switch x := n.err.(type) {
+		case interface{ Unwrap() error }:
+			stack = append(stack, node{depth: n.depth + 1, err: x.Unwrap()})
+		case interface{ Unwrap() []error }:
+			errs := x.Unwrap()
+			for i := len(errs) - 1; i >= 0; i-- {
+				stack = append(stack, node{depth: n.depth + 1, err: errs[i]})
+			}
+		}
+	}
+}
+
+// walkProblems calls fn for each Problem within err's tree, in the same order as errors.As (i.e. a depth-first
+// pre-order traversal), until fn returns false.
+//
+// err's tree is traversed in the same manner as walkErrors. Like errors.As, an error that implements an As(any) bool
+// method is also checked using it.
+func walkProblems(err error, fn func(p *Problem) bool) {
+	walkErrors(err, func(err error) bool {
+		p, isProblem := err.(*Problem)
+		if !isProblem {
+			if x, ok := err.(interface{ As(any) bool }); ok {
+				x.As(&p)
+			}
+		}
+		return p == nil || fn(p)
+	})
+}
+
+// wrapsNothing returns whether the given error does not wrap any other non-nil error (i.e. it is a leaf within an
+// error's tree).
+func wrapsNothing(err error) bool {
+	switch x := err.(type) {
+	case interface{ Unwrap() error }:
+		return x.Unwrap() == nil
+	case interface{ Unwrap() []error }:
+		for _, e := range x.Unwrap() {
+			if e != nil {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// AppendErrorDetailComposer returns a DetailComposer that appends the message of the wrapped error to the detail,
+// separated by ": " (e.g. "user not found: sql: no rows in result set"). If the detail is empty, only the message of
+// the wrapped error is used.
+func AppendErrorDetailComposer() DetailComposer {
+	return func(detail string, err error) string {
+		msg := err.Error()
+		switch {
+		case detail == "":
+			return msg
+		case msg == "":
+			return detail
+		default:
+			return detail + ": " + msg
+		}
+	}
+}
+
+// ErrorAsMapper returns an ErrorMapper that maps an error to the given Definition if err's tree contains an error that
+// is assignable to the type T, as determined by errors.As.
+//
+// For example;
+//
+//	ErrorAsMapper[*json.SyntaxError](http.BadRequestDefinition)
+func ErrorAsMapper[T error](def Definition) ErrorMapper {
+	return func(err error) (Definition, bool) {
+		var target T
+		if errors.As(err, &target) {
+			return def, true
+		}
+		return Definition{}, false
+	}
+}
+
+// ErrorIsMapper returns an ErrorMapper that maps an error to the given Definition if err's tree contains target, as
+// determined by errors.Is.
+//
+// For example;
+//
+//	ErrorIsMapper(sql.ErrNoRows, http.NotFoundDefinition)
+func ErrorIsMapper(target error, def Definition) ErrorMapper {
+	return func(err error) (Definition, bool) {
+		if errors.Is(err, target) {
+			return def, true
+		}
+		return Definition{}, false
+	}
+}
+
+// mapError returns the Definition provided by the first ErrorMapper within Generator.ErrorMappers to map the given
+// error, if any. err is never mapped if it is nil or its tree contains a Problem.
+func (g *Generator) mapError(err error) (Definition, bool) {
+	if err == nil || len(g.ErrorMappers) == 0 {
+		return Definition{}, false
+	}
+	if _, isProblem := As(err); isProblem {
+		return Definition{}, false
+	}
+	for _, mapper := range g.ErrorMappers {
+		if def, ok := mapper(err); ok {
+			return def, true
+		}
+	}
+	return Definition{}, false
 }
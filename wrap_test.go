@@ -0,0 +1,146 @@
+// Copyright (C) 2024 neocotic
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package problem
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+// cyclicError is an error whose Unwrap chain loops back to itself.
+type cyclicError struct {
+	next error
+}
+
+func (ce *cyclicError) Error() string {
+	return "cyclic"
+}
+
+func (ce *cyclicError) Unwrap() error {
+	return ce.next
+}
+
+// dataError is a comparable error type whose values may not be hashable (e.g. when Data holds a slice).
+type dataError struct {
+	Data any
+}
+
+func (de dataError) Error() string {
+	return fmt.Sprint(de.Data)
+}
+
+// endlessError is a non-comparable error whose Unwrap chain never ends.
+type endlessError struct {
+	depth []int
+}
+
+func (ee endlessError) Error() string {
+	return "endless"
+}
+
+func (ee endlessError) Unwrap() error {
+	return endlessError{depth: append(ee.depth, len(ee.depth))}
+}
+
+func Test_AsAll(t *testing.T) {
+	p1 := &Problem{Status: http.StatusBadRequest}
+	p2 := &Problem{Status: http.StatusNotFound, err: p1}
+	p3 := &Problem{Status: http.StatusConflict}
+	err := fmt.Errorf("wrapped: %w", errors.Join(p2, errors.New("other"), p3))
+
+	assert.Equal(t, []*Problem{p2, p1, p3}, AsAll(err))
+}
+
+func Test_AsAll_NoProblem(t *testing.T) {
+	assert.Nil(t, AsAll(nil))
+	assert.Nil(t, AsAll(errors.New("no problem")))
+}
+
+func Test_AsMatch(t *testing.T) {
+	p1 := &Problem{Status: http.StatusBadRequest}
+	p2 := &Problem{Status: http.StatusInternalServerError}
+	err := errors.Join(p1, fmt.Errorf("wrapped: %w", p2))
+
+	prob, isMatch := AsMatch(err, HasStatus(http.StatusInternalServerError))
+	assert.True(t, isMatch)
+	assert.Same(t, p2, prob)
+
+	prob, isMatch = AsMatch(err)
+	assert.True(t, isMatch)
+	assert.Same(t, p1, prob)
+
+	prob, isMatch = AsMatch(err, HasStatus(http.StatusNotFound))
+	assert.False(t, isMatch)
+	assert.Nil(t, prob)
+}
+
+func Test_AsMatch_Cycle(t *testing.T) {
+	p := &Problem{Status: http.StatusBadRequest}
+	ce := &cyclicError{}
+	ce.next = errors.Join(ce, p)
+
+	prob, isMatch := AsMatch(ce)
+	assert.True(t, isMatch)
+	assert.Same(t, p, prob)
+	assert.Equal(t, []*Problem{p}, AsMatchAll(ce))
+}
+
+func Test_AsMatch_MaxTreeDepth(t *testing.T) {
+	prob, isMatch := AsMatch(endlessError{})
+	assert.False(t, isMatch)
+	assert.Nil(t, prob)
+}
+
+func Test_AsMatch_Nil(t *testing.T) {
+	prob, isMatch := AsMatch(nil)
+	assert.False(t, isMatch)
+	assert.Nil(t, prob)
+}
+
+func Test_AsMatch_UnhashableError(t *testing.T) {
+	p := &Problem{Status: http.StatusBadRequest}
+	err := errors.Join(dataError{Data: []int{1}}, p)
+
+	require.NotPanics(t, func() {
+		prob, isMatch := AsMatch(dataError{Data: []int{1}})
+		assert.False(t, isMatch)
+		assert.Nil(t, prob)
+	})
+	require.NotPanics(t, func() {
+		prob, isMatch := AsMatch(err)
+		assert.True(t, isMatch)
+		assert.Same(t, p, prob)
+	})
+}
+
+func Test_AsMatchAll(t *testing.T) {
+	p1 := &Problem{Status: http.StatusBadRequest}
+	p2 := &Problem{Status: http.StatusInternalServerError}
+	p3 := &Problem{Status: http.StatusBadGateway}
+	err := errors.Join(p1, fmt.Errorf("wrapped: %w", p2), p3)
+
+	assert.Equal(t, []*Problem{p2, p3}, AsMatchAll(err, HasStatus(http.StatusInternalServerError, OperatorGreaterThanOrEqual)))
+	assert.Nil(t, AsMatchAll(err, HasStatus(http.StatusNotFound)))
+}